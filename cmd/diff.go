@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/diff"
+	"github.com/Asadus16/comapi/internal/history"
+	"github.com/Asadus16/comapi/internal/runner"
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [test-file]",
+	Short: "Compare responses between two environments or two stored runs",
+	Long: `Run the same suite against two base URLs, or load two runs from the history,
+and report per-test differences in status code, headers and JSON body structure
+and values. Exits with status 1 when any test differs.
+
+Tests with an absolute url are sent to the scheme and host of each side, keeping
+their path and query.
+
+Volatile headers (Date, Set-Cookie, X-Request-Id, ...) are ignored by default.
+Ignore body fields with --ignore, either as a JSON path where * matches any key
+or index, or as a bare field name that matches at any depth.
+
+Example:
+  comapi diff tests.yaml --left https://staging.example.com --right https://api.example.com
+  comapi diff tests.yaml --left $STAGING --right $PROD --ignore updated_at --ignore '$.items[*].id'
+  comapi diff --run k3f9x2a1 --run k3fa01bc`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runIDs, _ := cmd.Flags().GetStringArray("run")
+		leftURL, _ := cmd.Flags().GetString("left")
+		rightURL, _ := cmd.Flags().GetString("right")
+		output, _ := cmd.Flags().GetString("output")
+
+		ignorePaths, _ := cmd.Flags().GetStringArray("ignore")
+		ignoreHeaders, _ := cmd.Flags().GetStringArray("ignore-header")
+		allHeaders, _ := cmd.Flags().GetBool("all-headers")
+		structureOnly, _ := cmd.Flags().GetBool("structure-only")
+		options := diff.Options{
+			IgnorePaths:       ignorePaths,
+			IgnoreHeaders:     ignoreHeaders,
+			IncludeAllHeaders: allHeaders,
+			StructureOnly:     structureOnly,
+		}
+
+		var report diff.Report
+		switch {
+		case len(runIDs) > 0:
+			if len(runIDs) != 2 || len(args) > 0 {
+				fmt.Println("❌ Pass exactly two --run IDs and no test file to compare stored runs")
+				os.Exit(1)
+			}
+			historyFile, _ := cmd.Flags().GetString("history-file")
+			store := history.NewStore(historyFile)
+			left, right := findRun(store, runIDs[0]), findRun(store, runIDs[1])
+			report = diff.Compare("run "+left.ID, diff.FromRun(*left), "run "+right.ID, diff.FromRun(*right), options)
+
+		case len(args) == 1 && leftURL != "" && rightURL != "":
+			suite, diagnostics := config.ValidateFile(args[0])
+			if diagnostics.HasErrors() {
+				fmt.Printf("❌ Failed to load test suite:\n")
+				printDiagnostics(diagnostics)
+				os.Exit(1)
+			}
+			if err := config.ResolveSecrets(suite, filepath.Dir(args[0])); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			if output != "json" {
+				fmt.Printf("🧭 Comparing %s\n", suite.Name)
+				fmt.Printf("   ⬅️  %s\n   ➡️  %s\n\n", leftURL, rightURL)
+			}
+			// Point both copies before running either, so a bad url fails fast
+			leftSuite, rightSuite := retarget(suite, leftURL), retarget(suite, rightURL)
+			left := runner.NewSuiteRunner(leftSuite).Run()
+			right := runner.NewSuiteRunner(rightSuite).Run()
+			report = diff.Compare(leftURL, diff.FromResults(left.Results), rightURL, diff.FromResults(right.Results), options)
+
+		default:
+			fmt.Println("❌ Pass a test file with --left and --right, or two --run IDs")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		if output == "json" {
+			if report.Tests == nil {
+				report.Tests = []diff.TestDiff{}
+			}
+			printJSON(report)
+		} else {
+			printDiffReport(report)
+		}
+
+		if report.HasDifferences() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().String("left", "", "Base URL of the first environment")
+	diffCmd.Flags().String("right", "", "Base URL of the second environment")
+	diffCmd.Flags().StringArray("run", nil, "Stored run ID to compare (pass twice)")
+	diffCmd.Flags().String("history-file", defaultHistoryFile(), "History file to load runs from (env COMAPI_HISTORY)")
+	diffCmd.Flags().StringArray("ignore", nil, "Body field to ignore: JSON path ($.items[*].id) or field name (repeatable)")
+	diffCmd.Flags().StringArray("ignore-header", nil, "Response header to ignore (repeatable)")
+	diffCmd.Flags().Bool("all-headers", false, "Also compare volatile headers such as Date and Set-Cookie")
+	diffCmd.Flags().Bool("structure-only", false, "Compare JSON keys and types, not values")
+	diffCmd.Flags().StringP("output", "o", "console", "Output format (console, json)")
+}
+
+// retarget returns a copy of the suite with every request pointed at baseURL
+func retarget(suite *types.TestSuite, baseURL string) *types.TestSuite {
+	target, err := diff.Retarget(suite, baseURL)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return target
+}
+
+func findRun(store *history.Store, id string) *history.Run {
+	run, err := store.Find(id)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return run
+}
+
+// printDiffReport lists the differing tests with one line per difference
+func printDiffReport(report diff.Report) {
+	for _, test := range report.Tests {
+		fmt.Printf("❌ %s\n", test.Name)
+		for _, difference := range test.Differences {
+			switch difference.Kind {
+			case diff.KindMissing, diff.KindError:
+				fmt.Printf("    %s: %s\n", difference.Kind, difference.Message)
+			default:
+				location := string(difference.Kind)
+				if difference.Path != "" {
+					location += " " + difference.Path
+				}
+				fmt.Printf("    %s: %s\n", location, difference.Message)
+				fmt.Printf("      - %s\n", formatDiffValue(difference.Left))
+				fmt.Printf("      + %s\n", formatDiffValue(difference.Right))
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("🎯 Diff Summary:\n")
+	fmt.Printf("  ✅ Identical: %d/%d\n", report.Identical, report.Compared)
+	if report.HasDifferences() {
+		fmt.Printf("  ❌ Different: %d/%d\n", len(report.Tests), report.Compared)
+	}
+}
+
+// formatDiffValue renders a value as compact JSON, so "30" and 30 stay distinguishable
+func formatDiffValue(value interface{}) string {
+	if value == nil {
+		return "(absent)"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
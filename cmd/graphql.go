@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/graphql"
+	"github.com/spf13/cobra"
+)
+
+// graphqlCmd groups the GraphQL helpers
+var graphqlCmd = &cobra.Command{
+	Use:   "graphql",
+	Short: "Work with GraphQL tests",
+	Long: `Tests with a graphql block send a GraphQL operation instead of a body:
+
+  tests:
+    - name: Get user
+      path: /graphql                    # method defaults to POST
+      graphql:
+        query: |
+          query User($id: ID!) { user(id: $id) { id name } }
+        variables: { id: "42" }
+        operation_name: User            # needed when the query has several operations
+        schema: schema.json             # optional introspection file or URL, see below
+      assertions:
+        - type: graphql_errors          # absent (default), present, contains or equals
+        - type: json_path
+          target: data.user.name
+          expected: Ada
+
+Queries are parsed when the suite loads. With a schema, fields, arguments,
+fragments and variable types are checked against it too. The schema is either
+an introspection result saved with "comapi graphql introspect" or the URL of the
+endpoint. A URL is introspected while the suite loads, within 30s, and only when
+--fetch-schemas is passed to run, validate or server; loading a suite never
+contacts a server otherwise.`,
+}
+
+// schemaFetchTimeout bounds the introspection of each GraphQL schema URL while a suite loads
+const schemaFetchTimeout = 30 * time.Second
+
+// schemaFetchOptions returns the load options for the --fetch-schemas flag
+func schemaFetchOptions(cmd *cobra.Command) []config.Option {
+	if fetch, _ := cmd.Flags().GetBool("fetch-schemas"); fetch {
+		return []config.Option{config.WithSchemaFetch(http.DefaultClient, schemaFetchTimeout)}
+	}
+	return nil
+}
+
+// graphqlIntrospectCmd saves a server's schema for validating queries offline
+var graphqlIntrospectCmd = &cobra.Command{
+	Use:   "introspect [endpoint]",
+	Short: "Save a GraphQL server's schema for validating test queries",
+	Long: `Run the introspection query against a GraphQL endpoint and save the result,
+which tests can name as their graphql schema.
+
+Example:
+  comapi graphql introspect https://api.example.com/graphql -o schema.json
+  comapi graphql introspect http://localhost:4000/graphql -H "Authorization: Bearer $TOKEN"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		headerFlags, _ := cmd.Flags().GetStringArray("header")
+
+		headers := make(map[string]string, len(headerFlags))
+		for _, header := range headerFlags {
+			name, value, ok := strings.Cut(header, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				fmt.Printf("❌ Invalid header '%s', expected \"Name: value\"\n", header)
+				os.Exit(1)
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		schema, err := graphql.Introspect(ctx, &http.Client{Timeout: timeout}, args[0], headers)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if output == "" {
+			fmt.Println(string(schema))
+			return
+		}
+		if err := os.WriteFile(output, schema, 0o644); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Schema written to %s\n", output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphqlCmd)
+	graphqlCmd.AddCommand(graphqlIntrospectCmd)
+
+	graphqlIntrospectCmd.Flags().StringP("output", "o", "", "File to write the schema to (default stdout)")
+	graphqlIntrospectCmd.Flags().StringArrayP("header", "H", nil, "Request header as \"Name: value\" (repeatable)")
+	graphqlIntrospectCmd.Flags().Duration("timeout", 30*time.Second, "Maximum time to wait for the server")
+}
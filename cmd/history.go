@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/history"
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous test runs",
+	Long: `Show runs recorded by "comapi run". Every run is appended to a local
+JSON-lines file (.comapi/history.jsonl by default) with its results, timings,
+git commit and environment.
+
+Example:
+  comapi history
+  comapi history --suite "User API" --limit 50
+  comapi history show k3f9x2a1
+  comapi history trends
+  comapi history flaky`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runs := loadHistory(cmd)
+
+		if historyOutput(cmd) == "json" {
+			printJSON(runs)
+			return
+		}
+		if len(runs) == 0 {
+			fmt.Println("No runs recorded yet")
+			return
+		}
+
+		fmt.Printf("%-14s %-20s %-28s %-9s %-8s %s\n", "ID", "STARTED", "SUITE", "COMMIT", "TIME", "RESULT")
+		for i := len(runs) - 1; i >= 0; i-- {
+			run := runs[i]
+			icon := "✅"
+			if run.Failed > 0 {
+				icon = "❌"
+			}
+			result := fmt.Sprintf("%s %d/%d passed", icon, run.Passed, run.Total)
+			if run.Skipped > 0 {
+				result += fmt.Sprintf(", %d skipped", run.Skipped)
+			}
+			fmt.Printf("%-14s %-20s %-28s %-9s %-8s %s\n",
+				run.ID, run.StartedAt.Local().Format("2006-01-02 15:04:05"), truncate(run.Suite, 28),
+				orDash(run.Commit), fmt.Sprintf("%dms", run.DurationMs), result)
+		}
+	},
+}
+
+// historyShowCmd prints one stored run
+var historyShowCmd = &cobra.Command{
+	Use:   "show [run-id]",
+	Short: "Show the results of a stored run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		run, err := historyStore(cmd).Find(args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if historyOutput(cmd) == "json" {
+			printJSON(run)
+			return
+		}
+
+		fmt.Printf("📋 %s (run %s)\n", run.Suite, run.ID)
+		fmt.Printf("🕒 %s, %dms\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.DurationMs)
+		if run.Commit != "" {
+			fmt.Printf("🔖 %s (%s)\n", run.Commit, orDash(run.Branch))
+		}
+		if run.BaseURL != "" {
+			fmt.Printf("🌐 %s\n", run.BaseURL)
+		}
+		if run.Environment != "" {
+			fmt.Printf("🌱 %s\n", run.Environment)
+		}
+		fmt.Println()
+
+		for _, test := range run.Tests {
+			fmt.Printf("  %s %-50s %6dms\n", statusIcon(test.Status), truncate(test.Name, 50), test.DurationMs)
+			if test.Error != "" {
+				fmt.Printf("      Error: %s\n", test.Error)
+			}
+		}
+		fmt.Printf("\n  ✅ Passed: %d/%d\n", run.Passed, run.Total)
+		if run.Failed > 0 {
+			fmt.Printf("  ❌ Failed: %d/%d\n", run.Failed, run.Total)
+		}
+	},
+}
+
+// historyTrendsCmd prints per-test pass rates and latency trends
+var historyTrendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show per-test pass rate and latency trends",
+	Long: `Summarise every test across the selected runs: pass rate, average and last
+latency, how latency in the recent half of the runs compares to the older
+half, and the last results (oldest first).`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stats := history.Stats(loadHistory(cmd))
+
+		if historyOutput(cmd) == "json" {
+			printJSON(stats)
+			return
+		}
+		if len(stats) == 0 {
+			fmt.Println("No runs recorded yet")
+			return
+		}
+
+		fmt.Printf("%-40s %5s %7s %8s %8s %8s  %s\n", "TEST", "RUNS", "PASS", "AVG", "LAST", "TREND", "RECENT")
+		for _, s := range stats {
+			fmt.Printf("%-40s %5d %6.0f%% %6.0fms %6dms %8s  %s\n",
+				truncate(s.Name, 40), s.Runs, s.PassRate*100, s.AvgMs, s.LastMs,
+				formatChange(s.LatencyChange), statusStrip(s.Statuses, 20))
+			if s.LastStatus == types.StatusFail && s.FailingSince != "" {
+				fmt.Printf("%-40s ❌ failing since run %s\n", "", s.FailingSince)
+			}
+		}
+	},
+}
+
+// historyFlakyCmd lists tests that alternate between passing and failing
+var historyFlakyCmd = &cobra.Command{
+	Use:   "flaky",
+	Short: "List tests that alternate between pass and fail",
+	Long: `Detect flaky tests: tests that both passed and failed across the selected
+runs and flipped between the two at least twice. Skipped runs are ignored.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		flaky := history.Flaky(history.Stats(loadHistory(cmd)))
+
+		if historyOutput(cmd) == "json" {
+			if flaky == nil {
+				flaky = []history.TestStats{}
+			}
+			printJSON(flaky)
+			return
+		}
+		if len(flaky) == 0 {
+			fmt.Println("✅ No flaky tests detected")
+			return
+		}
+
+		fmt.Printf("⚠️  %d flaky test(s):\n\n", len(flaky))
+		for _, s := range flaky {
+			fmt.Printf("  %-40s %3.0f%% pass, %d flips  %s\n",
+				truncate(s.Name, 40), s.PassRate*100, s.Flips, statusStrip(s.Statuses, 20))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historyTrendsCmd)
+	historyCmd.AddCommand(historyFlakyCmd)
+
+	historyCmd.PersistentFlags().String("file", defaultHistoryFile(), "History file (env COMAPI_HISTORY)")
+	historyCmd.PersistentFlags().String("suite", "", "Only include runs of this suite")
+	historyCmd.PersistentFlags().IntP("limit", "n", 20, "Number of most recent runs to include (0 for all)")
+	historyCmd.PersistentFlags().StringP("output", "o", "console", "Output format (console, json)")
+}
+
+// defaultHistoryFile honours COMAPI_HISTORY before falling back to the default path
+func defaultHistoryFile() string {
+	if path := os.Getenv("COMAPI_HISTORY"); path != "" {
+		return path
+	}
+	return history.DefaultPath
+}
+
+func historyStore(cmd *cobra.Command) *history.Store {
+	path, _ := cmd.Flags().GetString("file")
+	return history.NewStore(path)
+}
+
+func historyOutput(cmd *cobra.Command) string {
+	output, _ := cmd.Flags().GetString("output")
+	return output
+}
+
+// loadHistory reads the runs selected by the --suite and --limit flags
+func loadHistory(cmd *cobra.Command) []history.Run {
+	suite, _ := cmd.Flags().GetString("suite")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	runs, err := historyStore(cmd).Load(history.Filter{Suite: suite, Limit: limit})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if runs == nil {
+		runs = []history.Run{}
+	}
+	return runs
+}
+
+// printJSON writes value to stdout as indented JSON
+func printJSON(value interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+func statusIcon(status types.TestStatus) string {
+	switch status {
+	case types.StatusPass:
+		return "✅"
+	case types.StatusSkip:
+		return "⏭️ "
+	default:
+		return "❌"
+	}
+}
+
+// statusStrip renders the last n statuses as a compact strip, e.g. "✓✓✗✓"
+func statusStrip(statuses []types.TestStatus, n int) string {
+	if len(statuses) > n {
+		statuses = statuses[len(statuses)-n:]
+	}
+	var strip strings.Builder
+	for _, status := range statuses {
+		switch status {
+		case types.StatusPass:
+			strip.WriteString("✓")
+		case types.StatusFail:
+			strip.WriteString("✗")
+		default:
+			strip.WriteString("·")
+		}
+	}
+	return strip.String()
+}
+
+func formatChange(change float64) string {
+	switch {
+	case change == 0:
+		return "-"
+	case change > 0:
+		return fmt.Sprintf("↑%.0f%%", change*100)
+	default:
+		return fmt.Sprintf("↓%.0f%%", -change*100)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
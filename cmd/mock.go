@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/mock"
+	"github.com/spf13/cobra"
+)
+
+// mockCmd represents the mock command
+var mockCmd = &cobra.Command{
+	Use:     "mock [test-file]",
+	Aliases: []string{"replay"},
+	Short:   "Serve stub responses for the requests in a test file",
+	Long: `Start an HTTP server that answers every method + path declared in a test
+file with a stub response, so frontends can be developed without the real API.
+
+Each test's mock_response is used when present; otherwise the status, headers
+and JSON body are derived from the test's assertions. Suites written by
+"comapi record" carry the recorded responses, so "comapi replay" serves them
+back for offline runs. Path segments written as
+:id, {id} or {{id}} match any value and can be echoed back with {{id}}.
+
+Example:
+  comapi mock tests.yaml
+  comapi replay recorded-tests.yaml
+  comapi mock tests.yaml --port 9090 --delay 200ms --error-rate 0.1`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetString("port")
+		delay, _ := cmd.Flags().GetDuration("delay")
+		errorRate, _ := cmd.Flags().GetFloat64("error-rate")
+		errorStatus, _ := cmd.Flags().GetInt("error-status")
+
+		suite, err := config.LoadTestSuite(args[0])
+		if err != nil {
+			fmt.Printf("❌ Failed to load test suite:\n%v\n", err)
+			os.Exit(1)
+		}
+
+		server := mock.NewServer(suite, mock.Options{
+			Delay:       delay,
+			ErrorRate:   errorRate,
+			ErrorStatus: errorStatus,
+			Logf: func(format string, args ...interface{}) {
+				fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+			},
+		})
+
+		fmt.Printf("🎭 Mocking %s on port %s\n", suite.Name, port)
+		for _, route := range server.Routes() {
+			fmt.Printf("  %-7s %s -> %d\n", route.Method, route.Path, route.Response.Status)
+		}
+		fmt.Println()
+
+		if err := http.ListenAndServe(":"+port, server); err != nil {
+			fmt.Printf("❌ Mock server failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockCmd)
+
+	mockCmd.Flags().StringP("port", "p", "8081", "Port to serve the mock API on")
+	mockCmd.Flags().Duration("delay", 0, "Delay added to every response (e.g. 250ms)")
+	mockCmd.Flags().Float64("error-rate", 0, "Fraction of requests (0-1) answered with --error-status")
+	mockCmd.Flags().Int("error-status", 500, "Status code for injected errors")
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Asadus16/comapi/internal/history"
+	"github.com/Asadus16/comapi/internal/logging"
+	"github.com/Asadus16/comapi/internal/monitor"
+	"github.com/spf13/cobra"
+)
+
+// monitorCmd represents the monitor command
+var monitorCmd = &cobra.Command{
+	Use:   "monitor [monitors-file]",
+	Short: "Run suites on a schedule and alert when they start or stop failing",
+	Long: `Run smoke suites against live environments on cron schedules. Each monitor
+remembers its state between runs and only alerts when it changes: when it
+starts failing and when it recovers.
+
+The monitors file lists the monitors and where their alerts go. ${VAR}
+references are read from the environment:
+
+  state_file: .comapi/monitor-state.json
+  monitors:
+    - name: prod-smoke
+      suite: suites/smoke.yaml
+      schedule: "*/5 * * * *"        # or @hourly, "@every 2m", ...
+      base_url: https://api.example.com
+      failure_threshold: 2           # failed runs in a row before alerting
+      notify: [ops-slack, oncall]
+  notifiers:
+    ops-slack:
+      type: slack                    # Slack-compatible incoming webhook
+      url: ${SLACK_WEBHOOK_URL}
+    hook:
+      type: webhook                  # JSON alert POSTed to url
+      url: https://alerts.example.com/comapi
+      headers: {Authorization: "Bearer ${ALERT_TOKEN}"}
+    oncall:
+      type: email
+      host: smtp.example.com
+      port: 587
+      username: ${SMTP_USER}
+      password: ${SMTP_PASSWORD}
+      from: comapi@example.com
+      to: [oncall@example.com]
+
+Monitors can also run inside "comapi server --monitors monitors.yaml".
+
+Example:
+  comapi monitor monitors.yaml
+  comapi monitor monitors.yaml --once`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := monitor.LoadConfig(args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if stateFile, _ := cmd.Flags().GetString("state-file"); stateFile != "" {
+			cfg.StateFile = stateFile
+		}
+
+		scheduler, err := monitor.NewScheduler(cfg)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// Runs and alert failures are printed below, so only surface other errors
+		logger, _ := logging.New(os.Stderr, "text", "error")
+		scheduler.SetLogger(logger)
+
+		noHistory, _ := cmd.Flags().GetBool("no-history")
+		historyFile, _ := cmd.Flags().GetString("history-file")
+		scheduler.OnRun = func(run monitor.Run) {
+			printMonitorRun(run)
+			if noHistory || run.Error != "" {
+				return
+			}
+			record := history.NewRun(run.Result, run.Monitor.Suite, "monitor:"+run.Monitor.Name, run.Monitor.BaseURL, run.StartedAt)
+			if err := history.NewStore(historyFile).Append(record); err != nil {
+				fmt.Printf("⚠️  Failed to save run history: %v\n", err)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if once, _ := cmd.Flags().GetBool("once"); once {
+			failing := false
+			for _, run := range scheduler.RunAll(ctx) {
+				failing = failing || run.State.Status == monitor.StatusFailing
+			}
+			if failing {
+				os.Exit(1)
+			}
+			return
+		}
+
+		fmt.Printf("🧭 Monitoring %d suite(s), state in %s (Ctrl-C to stop)\n", len(cfg.Monitors), cfg.StateFile)
+		for _, status := range scheduler.Statuses() {
+			fmt.Printf("  ⏱️  %s: %s (%s)\n", status.Name, status.Schedule, status.State.Status)
+		}
+		fmt.Println()
+		scheduler.Start(ctx)
+		fmt.Printf("\n👋 Monitoring stopped\n")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(monitorCmd)
+
+	monitorCmd.Flags().Bool("once", false, "Run every monitor once and exit, with status 1 if any is failing")
+	monitorCmd.Flags().String("state-file", "", "Where monitor states are kept (overrides state_file)")
+	monitorCmd.Flags().String("history-file", defaultHistoryFile(), "File to append runs to (env COMAPI_HISTORY)")
+	monitorCmd.Flags().Bool("no-history", false, "Do not record monitor runs in the history")
+}
+
+// printMonitorRun shows the outcome of one monitor run and any alert it raised
+func printMonitorRun(run monitor.Run) {
+	timestamp := run.StartedAt.Local().Format("15:04:05")
+	switch {
+	case run.Error != "":
+		fmt.Printf("%s ❌ %s: %s\n", timestamp, run.Monitor.Name, run.Error)
+	case run.Result.FailedTests > 0:
+		fmt.Printf("%s ❌ %s: %d/%d passed, %d failed (%dms)\n", timestamp, run.Monitor.Name,
+			run.Result.PassedTests, run.Result.TotalTests, run.Result.FailedTests, run.Result.Duration.Milliseconds())
+	default:
+		fmt.Printf("%s ✅ %s: %d/%d passed (%dms)\n", timestamp, run.Monitor.Name,
+			run.Result.PassedTests, run.Result.TotalTests, run.Result.Duration.Milliseconds())
+	}
+
+	if run.State.Status == monitor.StatusFailing && run.Alert == nil {
+		fmt.Printf("         still failing since %s\n", run.State.Since.Local().Format("2006-01-02 15:04"))
+	}
+	if run.Alert != nil {
+		fmt.Printf("         🔔 %s (%d notifier(s))\n", run.Alert.Subject(), len(run.Monitor.Notify))
+		for _, err := range run.NotifyErrors {
+			fmt.Printf("         ⚠️  %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/record"
+	"github.com/spf13/cobra"
+)
+
+// recordCmd represents the record command
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record API traffic through a proxy into a test file",
+	Long: `Start a local reverse proxy that forwards requests to --target and writes
+every exchange to --out as a test case. Each recorded test asserts the status,
+content type and key JSON fields, and keeps the full response as its
+mock_response so the suite can be replayed offline. Credential headers such as
+Authorization are written as {{NAME}} placeholders for secrets read from the
+environment. Credential query parameters and body fields, such as api_key or
+password, are masked in both requests and responses and get no assertions.
+
+Point your client or frontend at the proxy, exercise the API, then stop the
+recorder with Ctrl-C. The file is updated after every request.
+
+Example:
+  comapi record --target https://api.example.com --out suite.yaml
+  comapi replay suite.yaml     # serve the recorded responses offline`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, _ := cmd.Flags().GetString("target")
+		outFile, _ := cmd.Flags().GetString("out")
+		port, _ := cmd.Flags().GetString("port")
+		name, _ := cmd.Flags().GetString("name")
+
+		if name == "" {
+			name = "Recorded " + target
+		}
+
+		recorder, err := record.NewRecorder(target, outFile, name, func(format string, args ...interface{}) {
+			fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+		})
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🔴 Recording %s -> %s\n", target, outFile)
+		fmt.Printf("🌐 Send requests to http://localhost:%s\n\n", port)
+
+		if err := http.ListenAndServe(":"+port, recorder); err != nil {
+			fmt.Printf("❌ Recorder failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().String("target", "", "Base URL of the API to record (required)")
+	recordCmd.Flags().String("out", "recorded-tests.yaml", "File to write the recorded suite to")
+	recordCmd.Flags().StringP("port", "p", "8082", "Port for the recording proxy")
+	recordCmd.Flags().String("name", "", "Suite name (default \"Recorded <target>\")")
+	recordCmd.MarkFlagRequired("target")
+}
@@ -87,10 +87,23 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/grpc"
+	"github.com/Asadus16/comapi/internal/har"
+	"github.com/Asadus16/comapi/internal/history"
+	"github.com/Asadus16/comapi/internal/redact"
 	"github.com/Asadus16/comapi/internal/runner" 
+	"github.com/Asadus16/comapi/internal/webhook"
 
 	"github.com/Asadus16/comapi/pkg/types"
 	"github.com/spf13/cobra"
@@ -117,10 +130,20 @@ Example:
 		
 		fmt.Printf("🧭 Running tests from: %s\n", testFile)
 		
-		// Load and parse the test configuration
-		suite, err := config.LoadTestSuite(testFile)
-		if err != nil {
-			fmt.Printf("❌ Failed to load test suite: %v\n", err)
+		// Load, parse and validate the test configuration
+		suite, diagnostics := config.ValidateFile(testFile, schemaFetchOptions(cmd)...)
+		if diagnostics.HasErrors() {
+			fmt.Printf("❌ Failed to load test suite:\n")
+			printDiagnostics(diagnostics)
+			os.Exit(1)
+		}
+		printDiagnostics(diagnostics)
+		if err := addWebhookFlags(cmd, suite); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if err := config.ResolveSecrets(suite, filepath.Dir(testFile)); err != nil {
+			fmt.Printf("❌ %v\n", err)
 			os.Exit(1)
 		}
 		
@@ -128,59 +151,62 @@ Example:
 		fmt.Printf("🌐 Base URL: %s\n", suite.BaseURL)
 		fmt.Printf("🧪 Running %d test(s)...\n\n", len(suite.Tests))
 		
-		// Create HTTP client
-		httpClient := runner.NewHTTPClient(suite.BaseURL, suite.Headers)
+		// Run the suite, printing each result as it completes
+		suiteRunner := runner.NewSuiteRunner(suite)
+		suiteRunner.OnTestStart = func(index, total int, test types.TestCase) {
+			fmt.Printf("Running test %d/%d: %s\n", index+1, total, test.Name)
+		}
+		level, _ := cmd.Flags().GetCount("verbose")
+		if debug, _ := cmd.Flags().GetBool("debug"); debug {
+			level = verbosityDebug
+		}
+		redactor := redact.ForSuite(suite)
+		suiteRunner.OnTestComplete = func(index, total int, result types.TestResult) {
+			printTestResult(result, level, redactor)
+		}
+		startedAt := time.Now()
+		suiteResult := suiteRunner.Run()
+		
+		fmt.Printf("\n🎯 Test Summary:\n")
+		fmt.Printf("  ✅ Passed: %d/%d\n", suiteResult.PassedTests, suiteResult.TotalTests)
+		if suiteResult.FailedTests > 0 {
+			fmt.Printf("  ❌ Failed: %d/%d\n", suiteResult.FailedTests, suiteResult.TotalTests)
+		}
+		if suiteResult.SkippedTests > 0 {
+			fmt.Printf("  ⏭️  Skipped: %d/%d\n", suiteResult.SkippedTests, suiteResult.TotalTests)
+		}
 		
-		// Run each test
-		var results []types.TestResult
-		for i, test := range suite.Tests {
-			fmt.Printf("Running test %d/%d: %s\n", i+1, len(suite.Tests), test.Name)
-			
-			result := httpClient.ExecuteTest(test)
-			results = append(results, result)
-			
-			// Show basic result
-			if result.Status == types.StatusPass {
-				fmt.Printf("  ✅ %s - %dms\n", result.Status, result.Duration.Milliseconds())
+		// Save every exchange for HAR viewers and browser dev tools
+		if traceFile, _ := cmd.Flags().GetString("trace-file"); traceFile != "" {
+			if err := har.WriteFile(traceFile, har.FromResult(suiteResult, redactor)); err != nil {
+				fmt.Printf("⚠️  %v\n", err)
 			} else {
-				fmt.Printf("  ❌ %s - %dms\n", result.Status, result.Duration.Milliseconds())
-				if result.Error != "" {
-					fmt.Printf("    Error: %s\n", result.Error)
-				}
+				fmt.Printf("🗂️  Trace written to %s\n", traceFile)
 			}
-			
-			// Show assertion details
-			for _, assertion := range result.Assertions {
-				if assertion.Passed {
-					fmt.Printf("    ✅ %s: %s\n", assertion.Type, assertion.Message)
+		}
+		
+		// Tell webhook receivers, such as chat bots, how the run went
+		if len(suite.Webhooks) > 0 {
+			sender := webhook.NewSender(nil)
+			sender.SetProcessEnv(true)
+			for _, delivery := range sender.Notify(context.Background(), suite, suiteResult) {
+				if delivery.OK() {
+					fmt.Printf("📨 Webhook delivered to %s (%d, attempt %d)\n", delivery.Target(), delivery.StatusCode, delivery.Attempts)
 				} else {
-					fmt.Printf("    ❌ %s: %s\n", assertion.Type, assertion.Message)
+					fmt.Printf("⚠️  Webhook to %s failed after %d attempt(s): %s\n", delivery.Target(), delivery.Attempts, delivery.Error)
 				}
 			}
-			
-			// Show response body for failed tests (first 200 characters)
-			if result.Status == types.StatusFail {
-				responsePreview := result.Response.Body
-				if len(responsePreview) > 200 {
-					responsePreview = responsePreview[:200] + "..."
-				}
-				fmt.Printf("    📄 Response: %s\n", responsePreview)
-			}
-			
-			fmt.Println() // Add blank line between tests
 		}
 		
-		fmt.Printf("\n🎯 Test Summary:\n")
-		passed := 0
-		for _, result := range results {
-			if result.Status == types.StatusPass {
-				passed++
+		// Persist the run so "comapi history" can report trends
+		if noHistory, _ := cmd.Flags().GetBool("no-history"); !noHistory {
+			historyFile, _ := cmd.Flags().GetString("history-file")
+			envFile, _ := cmd.Flags().GetString("env")
+			run := history.NewRun(suiteResult, testFile, envFile, suite.BaseURL, startedAt)
+			if err := history.NewStore(historyFile).Append(run); err != nil {
+				fmt.Printf("⚠️  Failed to save run history: %v\n", err)
 			}
 		}
-		fmt.Printf("  ✅ Passed: %d/%d\n", passed, len(results))
-		if passed < len(results) {
-			fmt.Printf("  ❌ Failed: %d/%d\n", len(results)-passed, len(results))
-		}
 	},
 }
 
@@ -189,6 +215,157 @@ func init() {
 	
 	// Add flags for output format, verbose mode, etc.
 	runCmd.Flags().StringP("output", "o", "console", "Output format (console, json, html)")
-	runCmd.Flags().BoolP("verbose", "v", false, "Verbose output")
+	runCmd.Flags().CountP("verbose", "v", "Show request lines, timings and full failed responses; -vv adds all headers and bodies")
+	runCmd.Flags().Bool("debug", false, "Same as -vv")
+	runCmd.Flags().String("trace-file", "", "Write every request and response to this HAR file")
 	runCmd.Flags().StringP("env", "e", "", "Environment file for variable substitution")
-}
\ No newline at end of file
+	runCmd.Flags().String("history-file", defaultHistoryFile(), "File to append the run to (env COMAPI_HISTORY)")
+	runCmd.Flags().Bool("no-history", false, "Do not record this run in the history")
+	runCmd.Flags().Bool("fetch-schemas", false, "Introspect GraphQL schema URLs to validate queries against")
+	runCmd.Flags().StringArray("webhook", nil, "URL to POST a summary to when the run completes (repeatable)")
+	runCmd.Flags().String("webhook-on", webhook.OnAlways, "When --webhook URLs are notified: always or failure")
+	runCmd.Flags().String("webhook-secret", "", "Key for signing --webhook bodies with HMAC-SHA256 (env COMAPI_WEBHOOK_SECRET)")
+}
+
+// addWebhookFlags adds the --webhook URLs to the suite's own webhooks
+func addWebhookFlags(cmd *cobra.Command, suite *types.TestSuite) error {
+	urls, _ := cmd.Flags().GetStringArray("webhook")
+	on, _ := cmd.Flags().GetString("webhook-on")
+	secret, _ := cmd.Flags().GetString("webhook-secret")
+	if secret == "" {
+		secret = os.Getenv("COMAPI_WEBHOOK_SECRET")
+	}
+	if on != webhook.OnAlways && on != webhook.OnFailure {
+		return fmt.Errorf("invalid --webhook-on '%s' (expected %s or %s)", on, webhook.OnAlways, webhook.OnFailure)
+	}
+	for _, url := range urls {
+		suite.Webhooks = append(suite.Webhooks, types.Webhook{URL: url, On: on, Secret: secret})
+	}
+	return nil
+}
+
+// Console verbosity, raised by repeating -v
+const (
+	verbosityNormal  = 0 // Outcome, assertions and a preview of failed responses
+	verbosityVerbose = 1 // Adds request and status lines, timings and full failed responses
+	verbosityDebug   = 2 // Adds headers and bodies of every exchange
+)
+
+// bodyPreviewLength limits the response shown for failures at normal verbosity
+const bodyPreviewLength = 200
+
+// printTestResult shows the outcome of a single test on the console. Secret
+// header values are masked by redactor.
+func printTestResult(result types.TestResult, level int, redactor *redact.Redactor) {
+	switch result.Status {
+	case types.StatusPass:
+		fmt.Printf("  ✅ %s - %dms\n", result.Status, result.Duration.Milliseconds())
+	case types.StatusSkip:
+		fmt.Printf("  ⏭️  %s - %s\n", result.Status, result.SkipReason)
+	default:
+		fmt.Printf("  ❌ %s - %dms\n", result.Status, result.Duration.Milliseconds())
+		if result.Error != "" {
+			fmt.Printf("    Error: %s\n", result.Error)
+		}
+	}
+	sent := result.Timings != nil || result.Response.StatusCode != 0
+
+	if level >= verbosityVerbose && sent {
+		printExchange(result, level, redactor)
+	}
+	
+	// Show assertion details
+	for _, assertion := range result.Assertions {
+		if assertion.Passed {
+			fmt.Printf("    ✅ %s: %s\n", assertion.Type, assertion.Message)
+		} else {
+			fmt.Printf("    ❌ %s: %s\n", assertion.Type, assertion.Message)
+		}
+	}
+	
+	// Show the response body: a preview for failures, or in full when verbose
+	switch {
+	case level >= verbosityDebug && sent, level >= verbosityVerbose && result.Status == types.StatusFail:
+		if result.Response.Body != "" {
+			fmt.Printf("    📄 Response body:\n%s\n", indent(prettyBody(result.Response.Body), "      "))
+		}
+	case result.Status == types.StatusFail:
+		responsePreview := result.Response.Body
+		if len(responsePreview) > bodyPreviewLength {
+			responsePreview = responsePreview[:bodyPreviewLength] + "... (use -v for the full body)"
+		}
+		fmt.Printf("    📄 Response: %s\n", responsePreview)
+	}
+	
+	fmt.Println() // Add blank line between tests
+}
+
+// printExchange shows the request and status lines and timings, plus headers
+// and the request body at debug level
+func printExchange(result types.TestResult, level int, redactor *redact.Redactor) {
+	fmt.Printf("    → %s %s\n", result.Request.Method, result.Request.URL)
+	if level >= verbosityDebug {
+		printHeaders(result.Request.Headers, "      ", redactor)
+		if result.Request.Body != "" {
+			fmt.Printf("%s\n", indent(prettyBody(result.Request.Body), "      "))
+		}
+	}
+
+	response := result.Response
+	grpcCall := result.Request.Headers["Content-Type"] == grpc.ContentType
+	if response.StatusCode != 0 || (grpcCall && response.Proto != "") {
+		proto := response.Proto
+		if proto == "" {
+			proto = "HTTP"
+		}
+		if grpcCall {
+			// The status of a gRPC call is its grpc-status, 0 for OK
+			fmt.Printf("    ← %s gRPC %d %s (%d bytes)\n", proto, response.StatusCode, grpc.CodeName(response.StatusCode), response.Size)
+		} else {
+			fmt.Printf("    ← %s %d %s (%d bytes)\n", proto, response.StatusCode, http.StatusText(response.StatusCode), response.Size)
+		}
+		if level >= verbosityDebug {
+			printHeaders(response.Headers, "      ", redactor)
+		}
+	}
+
+	if result.Timings != nil {
+		printTimings(*result.Timings)
+	}
+}
+
+// printHeaders prints headers sorted by name with secret values masked
+func printHeaders(headers map[string]string, prefix string, redactor *redact.Redactor) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s%s: %s\n", prefix, name, redactor.Header(name, headers[name]))
+	}
+}
+
+// prettyBody indents JSON bodies and returns anything else unchanged
+func prettyBody(body string) string {
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(body), "", "  "); err != nil {
+		return body
+	}
+	return pretty.String()
+}
+
+// indent prefixes every line of text
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+prefix)
+}
+
+// printTimings shows where the time of a request went
+func printTimings(timings types.Timings) {
+	connection := "new connection"
+	if timings.Reused {
+		connection = "reused connection"
+	}
+	fmt.Printf("    ⏱️  dns %gms · connect %gms · tls %gms · wait %gms · ttfb %gms · transfer %gms · total %gms (%s)\n",
+		timings.DNS, timings.Connect, timings.TLS, timings.Wait, timings.TTFB, timings.Transfer, timings.Total, connection)
+}
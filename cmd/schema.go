@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for test suite files",
+	Long: `Print a JSON Schema describing the test suite file format, for editor
+completion and inline validation.
+
+To use it with the YAML language server (VS Code, Neovim, ...), save it and
+reference it from the top of your suite file:
+
+  comapi schema --out comapi.schema.json
+
+  # yaml-language-server: $schema=./comapi.schema.json
+  name: "My API Tests"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		outFile, _ := cmd.Flags().GetString("out")
+
+		encoded, err := json.MarshalIndent(config.GenerateSchema(), "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to generate schema: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outFile == "" {
+			fmt.Println(string(encoded))
+			return
+		}
+
+		if err := os.WriteFile(outFile, append(encoded, '\n'), 0644); err != nil {
+			fmt.Printf("❌ Failed to write schema: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Wrote JSON Schema to %s\n", outFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().String("out", "", "Write the schema to a file instead of stdout")
+}
@@ -208,65 +208,434 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
+	"github.com/Asadus16/comapi/frontend"
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/jobs"
+	"github.com/Asadus16/comapi/internal/logging"
+	"github.com/Asadus16/comapi/internal/metrics"
+	"github.com/Asadus16/comapi/internal/middleware"
+	"github.com/Asadus16/comapi/internal/netguard"
+	"github.com/Asadus16/comapi/internal/redact"
 	"github.com/Asadus16/comapi/internal/runner"
+	"github.com/Asadus16/comapi/internal/suites"
+	"github.com/Asadus16/comapi/internal/webui"
 	"github.com/Asadus16/comapi/pkg/types"
+	"gopkg.in/yaml.v3"
 )
 
+// runManager executes suites submitted to /api/v1/runs in the background
+var runManager = jobs.NewManager(4, 100)
+
+// suiteStore holds the suites saved through /api/v1/suites
+var suiteStore *suites.Store
+
+// adhocSuite labels metrics for single tests sent to /api/v1/tests/run
+const adhocSuite = "adhoc"
+
+// outboundClient sends test requests, subject to the --allow-host/--deny-host policy
+var outboundClient *http.Client
+
+// secretEnv lists the environment variables that suites sent through the API may read as secrets
+var secretEnv []string
+
+// apiSuiteOptions load suites that came from API callers: they may not read the
+// server's files, and with --fetch-schemas their GraphQL schema URLs are
+// introspected through outboundClient
+var apiSuiteOptions = []config.Option{config.WithoutFiles()}
 
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start Comapi web server",
-	Long:  `Start the Comapi web server to provide a REST API for the frontend.`,
+	Long: `Start the Comapi web server to provide a REST API for the frontend.
+
+The server sends HTTP requests on behalf of its callers, so protect it before
+exposing it beyond your machine:
+
+  --token / COMAPI_API_TOKENS        require "Authorization: Bearer <token>"
+  --basic-auth / COMAPI_BASIC_AUTH   require user:password basic auth
+  --allowed-origins                  origins the browser may call from
+  --rate-limit, --rate-burst         per-client request limits
+  --trusted-proxies                  proxies whose X-Forwarded-For is believed
+  --allow-host, --deny-host          hosts or CIDRs tests may reach
+
+Tests may not reach this machine or private networks by default. To test
+services there, replace the deny list, e.g. --deny-host 169.254.0.0/16.
+
+Example:
+  comapi server --token $(openssl rand -hex 16) --allow-host '*.staging.example.com'`,
 	Run: func(cmd *cobra.Command, args []string) {
-		port, _ := cmd.Flags().GetString("port")
-		startServer(port)
+		options, err := serverOptionsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		startServer(options)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(serverCmd)
 	serverCmd.Flags().StringP("port", "p", "8080", "Port to run the server on")
+	serverCmd.Flags().String("listen", "", "Address to listen on, e.g. 127.0.0.1:8080 (overrides --port)")
+	serverCmd.Flags().String("tls-cert", "", "TLS certificate file; serves HTTPS together with --tls-key")
+	serverCmd.Flags().String("tls-key", "", "TLS private key file")
+	serverCmd.Flags().Duration("read-timeout", 30*time.Second, "Maximum time to read a request")
+	serverCmd.Flags().Duration("write-timeout", 5*time.Minute, "Maximum time to write a response (event streams are exempt)")
+	serverCmd.Flags().Duration("idle-timeout", 2*time.Minute, "How long idle keep-alive connections stay open")
+	serverCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long to wait for running tests on shutdown before cancelling them")
+	serverCmd.Flags().String("suites-dir", "suites", "Directory where suites saved through the API are stored")
+	serverCmd.Flags().StringSlice("allowed-origins", []string{"http://localhost:3000", "http://127.0.0.1:3000"}, "Origins allowed to call the API from a browser (\"*\" for any)")
+	serverCmd.Flags().StringArray("token", nil, "API token clients must send (repeatable, env COMAPI_API_TOKENS)")
+	serverCmd.Flags().StringArray("basic-auth", nil, "user:password accepted via basic auth (repeatable, env COMAPI_BASIC_AUTH)")
+	serverCmd.Flags().Float64("rate-limit", 300, "Requests per minute allowed per client IP (0 disables)")
+	serverCmd.Flags().Int("rate-burst", 60, "Requests a client may make in a burst")
+	serverCmd.Flags().StringSlice("trusted-proxies", nil, "Proxy IPs or CIDRs whose X-Forwarded-For is believed when identifying clients")
+	serverCmd.Flags().StringSlice("allow-host", nil, "Only let tests reach these hosts, *.domains, IPs or CIDRs")
+	serverCmd.Flags().StringSlice("deny-host", netguard.DefaultDeny, "Never let tests reach these hosts, *.domains, IPs or CIDRs")
+	serverCmd.Flags().Bool("fetch-schemas", false, "Introspect GraphQL schema URLs of suites when they load, subject to --allow-host/--deny-host")
+	serverCmd.Flags().StringSlice("secret-env", nil, "Environment variables suites sent through the API may use as secrets (env COMAPI_SECRET_ENV)")
+	serverCmd.Flags().String("ui-dir", "", "Serve the web UI from this build directory instead of the embedded one")
+	serverCmd.Flags().Bool("no-ui", false, "Do not serve the web UI")
+	serverCmd.Flags().String("monitors", "", "Monitors file with suites to run on a schedule (see comapi monitor --help)")
+	serverCmd.Flags().String("log-format", "text", "Log format: text (logfmt) or json")
+	serverCmd.Flags().String("log-level", "info", "Minimum log level: debug, info, warn or error")
+}
+
+// serverOptions configure startServer
+type serverOptions struct {
+	Listen          string
+	TLSCert         string
+	TLSKey          string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	SuitesDir      string
+	MonitorsFile   string
+	AllowedOrigins []string
+	Credentials    middleware.Credentials
+	RateLimit      float64
+	RateBurst      int
+	TrustedProxies []string
+	Outbound       *netguard.Policy
+	FetchSchemas   bool
+	SecretEnv      []string
+	UI             fs.FS // nil when no UI is served
+	ServeUI        bool
+	Logger         *slog.Logger
 }
 
-func startServer(port string) {
+// serverOptionsFromFlags reads the server flags, falling back to the environment for credentials
+func serverOptionsFromFlags(cmd *cobra.Command) (serverOptions, error) {
+	var options serverOptions
+	port, _ := cmd.Flags().GetString("port")
+	options.Listen, _ = cmd.Flags().GetString("listen")
+	if options.Listen == "" {
+		options.Listen = ":" + port
+	}
+	options.TLSCert, _ = cmd.Flags().GetString("tls-cert")
+	options.TLSKey, _ = cmd.Flags().GetString("tls-key")
+	if (options.TLSCert == "") != (options.TLSKey == "") {
+		return options, fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+	options.ReadTimeout, _ = cmd.Flags().GetDuration("read-timeout")
+	options.WriteTimeout, _ = cmd.Flags().GetDuration("write-timeout")
+	options.IdleTimeout, _ = cmd.Flags().GetDuration("idle-timeout")
+	options.ShutdownTimeout, _ = cmd.Flags().GetDuration("shutdown-timeout")
+	options.SuitesDir, _ = cmd.Flags().GetString("suites-dir")
+	options.MonitorsFile, _ = cmd.Flags().GetString("monitors")
+	options.AllowedOrigins, _ = cmd.Flags().GetStringSlice("allowed-origins")
+	options.RateLimit, _ = cmd.Flags().GetFloat64("rate-limit")
+	options.RateBurst, _ = cmd.Flags().GetInt("rate-burst")
+	options.TrustedProxies, _ = cmd.Flags().GetStringSlice("trusted-proxies")
+
+	logFormat, _ := cmd.Flags().GetString("log-format")
+	logLevel, _ := cmd.Flags().GetString("log-level")
+	logger, err := logging.New(os.Stderr, logFormat, logLevel)
+	if err != nil {
+		return options, err
+	}
+	options.Logger = logger
+
+	tokens, _ := cmd.Flags().GetStringArray("token")
+	if len(tokens) == 0 {
+		tokens = splitList(os.Getenv("COMAPI_API_TOKENS"))
+	}
+	options.Credentials.Tokens = tokens
+
+	users, _ := cmd.Flags().GetStringArray("basic-auth")
+	if len(users) == 0 {
+		users = splitList(os.Getenv("COMAPI_BASIC_AUTH"))
+	}
+	for _, user := range users {
+		name, password, ok := strings.Cut(user, ":")
+		if !ok || name == "" || password == "" {
+			return options, fmt.Errorf("invalid basic auth value, expected user:password")
+		}
+		if options.Credentials.BasicAuth == nil {
+			options.Credentials.BasicAuth = make(map[string]string)
+		}
+		options.Credentials.BasicAuth[name] = password
+	}
+
+	allow, _ := cmd.Flags().GetStringSlice("allow-host")
+	deny, _ := cmd.Flags().GetStringSlice("deny-host")
+	policy, err := netguard.NewPolicy(allow, deny)
+	if err != nil {
+		return options, err
+	}
+	options.Outbound = policy
+	options.FetchSchemas, _ = cmd.Flags().GetBool("fetch-schemas")
+
+	options.SecretEnv, _ = cmd.Flags().GetStringSlice("secret-env")
+	if len(options.SecretEnv) == 0 {
+		options.SecretEnv = splitList(os.Getenv("COMAPI_SECRET_ENV"))
+	}
+
+	// Prefer an explicit build directory, then the UI embedded at compile time
+	noUI, _ := cmd.Flags().GetBool("no-ui")
+	uiDir, _ := cmd.Flags().GetString("ui-dir")
+	options.ServeUI = !noUI
+	switch {
+	case noUI:
+	case uiDir != "":
+		if _, err := os.Stat(filepath.Join(uiDir, "index.html")); err != nil {
+			return options, fmt.Errorf("no index.html in UI directory %s", uiDir)
+		}
+		options.UI = os.DirFS(uiDir)
+	default:
+		options.UI, _ = frontend.Files()
+	}
+
+	return options, nil
+}
+
+func startServer(options serverOptions) {
+	store, err := suites.NewStore(options.SuitesDir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	suiteStore = store
+
+	// Every request made on behalf of an API caller goes through the outbound policy
+	outboundClient = options.Outbound.Client(30 * time.Second)
+	runManager.SetHTTPClient(outboundClient)
+	secretEnv = options.SecretEnv
+	if options.FetchSchemas {
+		apiSuiteOptions = append(apiSuiteOptions, config.WithSchemaFetch(outboundClient, schemaFetchTimeout))
+	}
+
+	// Structured logs go to stderr, the banner below stays on stdout
+	slog.SetDefault(options.Logger)
+	runManager.SetLogger(options.Logger)
+
+	if options.MonitorsFile != "" {
+		scheduler, err := newMonitorScheduler(options.MonitorsFile)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		scheduler.SetHTTPClient(outboundClient)
+		scheduler.SetLogger(options.Logger)
+		monitorScheduler = scheduler
+	}
+
 	// Set Gin to release mode for cleaner output
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.Default()
-	
-	// Enable CORS for React frontend
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type")
-		
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		
-		c.Next()
-	})
+	r := gin.New()
+
+	// Client IPs key the rate limiter, so forwarding headers are only believed
+	// from configured proxies; by default gin would trust them from anyone
+	if err := r.SetTrustedProxies(options.TrustedProxies); err != nil {
+		fmt.Printf("❌ Invalid --trusted-proxies: %v\n", err)
+		os.Exit(1)
+	}
+	r.Use(middleware.RequestLogger(options.Logger), middleware.Metrics(), gin.Recovery())
+
+	// Only the configured origins may call the API from a browser
+	r.Use(middleware.CORS(options.AllowedOrigins))
+
+	// Prometheus scrape endpoint, behind the same credentials as the API
+	r.GET("/metrics", middleware.Auth(options.Credentials), gin.WrapH(metrics.Default.Handler()))
 
 	// API Routes
 	api := r.Group("/api/v1")
+	api.GET("/health", healthCheckEndpoint)
+
+	protected := api.Group("", middleware.RateLimit(options.RateLimit, options.RateBurst), middleware.Auth(options.Credentials))
 	{
-		api.POST("/tests/run", runTestsEndpoint)
-		api.POST("/tests/validate", validateTestsEndpoint)
-		api.GET("/health", healthCheckEndpoint)
+		protected.POST("/tests/run", runTestsEndpoint)
+		protected.POST("/tests/validate", validateTestsEndpoint)
+		protected.POST("/runs", createRunEndpoint)
+		protected.GET("/runs", listRunsEndpoint)
+		protected.GET("/runs/:id", getRunEndpoint)
+		protected.GET("/runs/:id/events", runEventsEndpoint)
+		protected.DELETE("/runs/:id", cancelRunEndpoint)
+		protected.GET("/suites", listSuitesEndpoint)
+		protected.POST("/suites", createSuiteEndpoint)
+		protected.GET("/suites/:id", getSuiteEndpoint)
+		protected.PUT("/suites/:id", updateSuiteEndpoint)
+		protected.DELETE("/suites/:id", deleteSuiteEndpoint)
+		protected.GET("/suites/:id/versions", listSuiteVersionsEndpoint)
+		protected.GET("/suites/:id/versions/:version", getSuiteVersionEndpoint)
+		protected.GET("/monitors", listMonitorsEndpoint)
+		protected.POST("/monitors/:name/run", runMonitorEndpoint)
+		protected.GET("/schema", schemaEndpoint)
+	}
+
+	// Everything outside the API is the web UI, with index.html as the SPA fallback
+	if options.ServeUI {
+		r.NoRoute(webui.Handler(options.UI, webui.Config{
+			APIBaseURL:   "/api/v1",
+			AuthRequired: options.Credentials.Enabled(),
+		}))
 	}
 
-	fmt.Printf("🧭 Comapi server starting on port %s\n", port)
-	fmt.Printf("🌐 API: http://localhost:%s/api/v1\n", port)
-	fmt.Printf("📡 Health Check: http://localhost:%s/api/v1/health\n", port)
+	baseURL := displayURL(options.Listen, options.TLSCert != "")
+	fmt.Printf("🧭 Comapi server starting on %s\n", options.Listen)
+	fmt.Printf("🌐 API: %s/api/v1\n", baseURL)
+	fmt.Printf("📡 Health Check: %s/api/v1/health\n", baseURL)
+	fmt.Printf("📈 Metrics: %s/metrics\n", baseURL)
+	switch {
+	case options.UI != nil:
+		fmt.Printf("🖥️  Web UI: %s/\n", baseURL)
+	case options.ServeUI:
+		fmt.Printf("🖥️  Web UI not bundled in this build, see %s/\n", baseURL)
+	}
+	fmt.Printf("💾 Suites: %s\n", options.SuitesDir)
+	if monitorScheduler != nil {
+		fmt.Printf("⏱️  Monitors: %d scheduled from %s\n", len(monitorScheduler.Statuses()), options.MonitorsFile)
+	}
+	if options.Credentials.Enabled() {
+		fmt.Printf("🔒 Authentication required\n")
+	} else {
+		fmt.Printf("🔓 Authentication disabled, use --token or --basic-auth before exposing the server\n")
+	}
 	fmt.Printf("🚀 Ready to accept requests from React frontend!\n\n")
-	
-	r.Run(":" + port)
+
+	server := &http.Server{
+		Addr:              options.Listen,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       options.ReadTimeout,
+		WriteTimeout:      options.WriteTimeout,
+		IdleTimeout:       options.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if options.TLSCert != "" {
+			serveErr <- server.ListenAndServeTLS(options.TLSCert, options.TLSKey)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	// Monitors stop with the server; a run cut short leaves the monitor's state untouched
+	monitorsCtx, stopMonitors := context.WithCancel(context.Background())
+	monitorsDone := make(chan struct{})
+	go func() {
+		defer close(monitorsDone)
+		if monitorScheduler != nil {
+			monitorScheduler.Start(monitorsCtx)
+		}
+	}()
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		options.Logger.Error("server failed", "error", err)
+		os.Exit(1)
+	case <-signals:
+	}
+
+	// A second signal skips the drain
+	go func() {
+		<-signals
+		fmt.Printf("\n⚠️  Forced shutdown\n")
+		os.Exit(1)
+	}()
+
+	stopMonitors()
+	<-monitorsDone
+	shutdownServer(server, options.ShutdownTimeout)
+}
+
+// shutdownServer lets running jobs finish, then closes the HTTP server, all within timeout.
+// The API keeps answering while jobs drain so clients can follow them to the end.
+func shutdownServer(server *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if active := runManager.Active(); active > 0 {
+		fmt.Printf("\n🛑 Shutting down, waiting up to %s for %d run(s) to finish (Ctrl-C again to force)...\n", timeout, active)
+	} else {
+		fmt.Printf("\n🛑 Shutting down...\n")
+	}
+
+	if err := runManager.Shutdown(ctx); err != nil {
+		slog.Warn("cancelled runs still in progress", "timeout", timeout.String())
+	}
+
+	// Leave a moment to close connections even if the drain used the whole timeout
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer closeCancel()
+	if err := server.Shutdown(closeCtx); err != nil {
+		slog.Warn("closing open connections", "error", err)
+		server.Close()
+	}
+	fmt.Printf("👋 Server stopped\n")
+}
+
+// displayURL turns a listen address into a URL for the startup banner
+func displayURL(listen string, useTLS bool) string {
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return scheme + "://" + listen
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// splitList splits a comma-separated environment value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // API Endpoints
@@ -277,7 +646,13 @@ func runTestsEndpoint(c *gin.Context) {
 		TestSuite types.TestSuite `json:"test_suite"`
 	}
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBody)
 	if err := c.ShouldBindJSON(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			invalidRequest(c, err)
+			return
+		}
 		c.JSON(400, gin.H{"error": "Invalid request format: " + err.Error()})
 		return
 	}
@@ -301,25 +676,36 @@ func runTestsEndpoint(c *gin.Context) {
 		return
 	}
 
-	fmt.Printf("🧪 Running test: %s\n", test.Name)
-	fmt.Printf("🌐 URL: %s\n", test.URL)
-	fmt.Printf("📡 Method: %s\n", test.Method)
+	if references := config.FileReferences(&types.TestSuite{Tests: []types.TestCase{test}}); len(references) > 0 {
+		c.JSON(400, gin.H{"error": references[0] + ": files cannot be read by suites submitted to the server"})
+		return
+	}
+
+	// Mask the suite's redact rules and secrets the same way /api/v1/runs does
+	if err := resolveServerSecrets(&request.TestSuite); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	redactor := redact.ForSuite(&request.TestSuite)
+	logger := middleware.Logger(c)
+	logger.Info("running test", "test", test.Name, "method", test.Method, "url", redactor.URL(test.URL))
 
 	// For single URL mode, we extract base URL and path
 	// Create a dummy base URL and set the full URL as the path
 	httpClient := runner.NewHTTPClient("", map[string]string{})
+	httpClient.SetHTTPClient(outboundClient)
 
 	// Run the single test
-	result := httpClient.ExecuteTestWithFullURL(test)
+	result := redactor.Result(httpClient.ExecuteTestWithFullURL(test))
 	
 	// Log result
-	if result.Status == types.StatusPass {
-		fmt.Printf("  ✅ PASS - %dms\n", result.Duration.Milliseconds())
-	} else {
-		fmt.Printf("  ❌ FAIL - %s\n", result.Error)
-	}
-
-	fmt.Printf("📊 Test completed\n\n")
+	metrics.ObserveResult(adhocSuite, result)
+	logger.Info("test completed",
+		"test", test.Name,
+		"status", result.Status,
+		"status_code", result.Response.StatusCode,
+		"duration_ms", result.Duration.Milliseconds(),
+		"error", result.Error)
 
 	response := gin.H{
 		"suite_name":    test.Name,
@@ -334,22 +720,17 @@ func runTestsEndpoint(c *gin.Context) {
 
 // validateTestsEndpoint - POST /api/v1/tests/validate
 func validateTestsEndpoint(c *gin.Context) {
-	var request struct {
-		TestSuite types.TestSuite `json:"test_suite"`
-	}
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format"})
+	_, diagnostics, err := decodeSuiteRequest(c)
+	if err != nil {
+		invalidRequest(c, err)
 		return
 	}
 
-	// Validate the test suite
-	errors := validateTestSuite(request.TestSuite)
-
-	if len(errors) > 0 {
+	if diagnostics.HasErrors() {
 		c.JSON(400, gin.H{
 			"valid": false,
-			"errors": errors,
+			"errors": diagnostics.Messages(),
+			"diagnostics": diagnostics,
 		})
 		return
 	}
@@ -357,46 +738,223 @@ func validateTestsEndpoint(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"valid": true,
 		"message": "Test suite is valid",
+		"diagnostics": diagnostics,
 	})
 }
 
-// healthCheckEndpoint - GET /api/v1/health
-func healthCheckEndpoint(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status": "ok",
-		"service": "comapi",
-		"version": "1.0.0",
-		"timestamp": time.Now().Unix(),
+// createRunEndpoint - POST /api/v1/runs
+func createRunEndpoint(c *gin.Context) {
+	suite, diagnostics, err := decodeSuiteRequest(c)
+	if errors.Is(err, suites.ErrNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if diagnostics.HasErrors() {
+		c.JSON(400, gin.H{
+			"error": "Invalid test suite",
+			"errors": diagnostics.Messages(),
+			"diagnostics": diagnostics,
+		})
+		return
+	}
+	if err := resolveServerSecrets(suite); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := runManager.Submit(suite)
+	if err != nil {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+	snapshot := job.Snapshot()
+	middleware.Logger(c).Info("run queued", "run_id", snapshot.ID, "suite", snapshot.SuiteName, "tests", snapshot.TotalTests)
+
+	c.Header("Location", "/api/v1/runs/"+snapshot.ID)
+	c.JSON(202, gin.H{
+		"id": snapshot.ID,
+		"status": snapshot.Status,
+		"total_tests": snapshot.TotalTests,
+		"links": gin.H{
+			"self": "/api/v1/runs/" + snapshot.ID,
+			"events": "/api/v1/runs/" + snapshot.ID + "/events",
+		},
 	})
 }
 
-// Helper function to validate test suite
-func validateTestSuite(suite types.TestSuite) []string {
-	var errors []string
+// listRunsEndpoint - GET /api/v1/runs
+func listRunsEndpoint(c *gin.Context) {
+	c.JSON(200, gin.H{"runs": runManager.List()})
+}
 
-	if suite.Name == "" {
-		errors = append(errors, "Test suite name is required")
+// getRunEndpoint - GET /api/v1/runs/:id
+func getRunEndpoint(c *gin.Context) {
+	job, err := runManager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(200, job.Snapshot())
+}
 
-	if suite.BaseURL == "" {
-		errors = append(errors, "Base URL is required")
+// runEventsEndpoint - GET /api/v1/runs/:id/events
+// Streams test_start, result and done events as Server-Sent Events. Events that
+// happened before the client connected are replayed first.
+func runEventsEndpoint(c *gin.Context) {
+	job, err := runManager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
 	}
 
-	if len(suite.Tests) == 0 {
-		errors = append(errors, "At least one test is required")
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+
+	// Streams last as long as the run, so lift the server's write timeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	job.Follow(c.Request.Context(), func(event jobs.Event) error {
+		c.SSEvent(event.Type, event)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+}
+
+// cancelRunEndpoint - DELETE /api/v1/runs/:id
+func cancelRunEndpoint(c *gin.Context) {
+	job, err := runManager.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, jobs.ErrFinished):
+		c.JSON(409, gin.H{"error": err.Error(), "run": job.Snapshot()})
+	default:
+		middleware.Logger(c).Info("run cancelled", "run_id", job.Snapshot().ID)
+		c.JSON(200, job.Snapshot())
+	}
+}
+
+// decodeSuiteRequest reads a {"test_suite": ...} body (or a bare suite) and validates it.
+// A {"suite_id": ...} body loads the suite from the suite store instead. Either way
+// the suite came from an API caller, so it may not read files on the server.
+func decodeSuiteRequest(c *gin.Context) (*types.TestSuite, config.Diagnostics, error) {
+	root, err := readRequestNode(c)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	for i, test := range suite.Tests {
-		if test.Name == "" {
-			errors = append(errors, fmt.Sprintf("Test %d: name is required", i+1))
+	if idNode := requestField(root, "suite_id"); idNode != nil {
+		if _, err := suiteStore.Get(idNode.Value); err != nil {
+			return nil, nil, err
 		}
-		if test.Method == "" {
-			errors = append(errors, fmt.Sprintf("Test '%s': method is required", test.Name))
+		suite, diagnostics := config.ValidateFile(suiteStore.Path(idNode.Value), apiSuiteOptions...)
+		if diagnostics == nil {
+			diagnostics = config.Diagnostics{}
 		}
-		if test.Path == "" {
-			errors = append(errors, fmt.Sprintf("Test '%s': path is required", test.Name))
+		return suite, diagnostics, nil
+	}
+
+	suiteNode := root
+	if node := requestField(root, "test_suite"); node != nil {
+		suiteNode = node
+	}
+
+	suite, diagnostics := config.ValidateNode(suiteNode, "request", apiSuiteOptions...)
+	if diagnostics == nil {
+		diagnostics = config.Diagnostics{}
+	}
+	return suite, diagnostics, nil
+}
+
+// resolveServerSecrets reads the secrets of a suite that came through the API.
+// Such suites may only read the environment variables allowed by --secret-env,
+// never files, so callers cannot send the server's own credentials elsewhere.
+func resolveServerSecrets(suite *types.TestSuite) error {
+	names := make([]string, 0, len(suite.Secrets))
+	for name := range suite.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		secret := suite.Secrets[name]
+		if secret.File != "" {
+			return fmt.Errorf("secret '%s': file secrets are not allowed on the server", name)
+		}
+		if !slices.Contains(secretEnv, secret.Env) {
+			return fmt.Errorf("secret '%s': environment variable %s is not allowed (see --secret-env)", name, secret.Env)
+		}
+	}
+	return config.ResolveSecrets(suite, ".")
+}
+
+// maxRequestBody bounds the suites and tests an API caller may send; larger
+// bodies are answered with 413 before they are parsed
+const maxRequestBody = 10 << 20
+
+// readRequestNode parses the request body as a YAML node tree. JSON is valid YAML,
+// so this gives us line/column info for diagnostics. Bodies over maxRequestBody
+// fail with *http.MaxBytesError.
+func readRequestNode(c *gin.Context) (*yaml.Node, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, errors.New("empty request body")
+	}
+	return document.Content[0], nil
+}
+
+// invalidRequest answers a request whose body could not be read or parsed:
+// 413 when it is larger than maxRequestBody, 400 otherwise
+func invalidRequest(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit)})
+		return
+	}
+	c.JSON(400, gin.H{"error": "Invalid request format"})
+}
+
+// requestField returns the value of a top-level key in a mapping node, or nil
+func requestField(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
 		}
 	}
+	return nil
+}
 
-	return errors
-}
\ No newline at end of file
+// schemaEndpoint - GET /api/v1/schema
+func schemaEndpoint(c *gin.Context) {
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(200, config.GenerateSchema())
+}
+
+// healthCheckEndpoint - GET /api/v1/health
+func healthCheckEndpoint(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"service": "comapi",
+		"version": "1.0.0",
+		"timestamp": time.Now().Unix(),
+	})
+}
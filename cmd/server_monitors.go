@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/middleware"
+	"github.com/Asadus16/comapi/internal/monitor"
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// Scheduled monitor endpoints, available when the server runs with --monitors
+
+// monitorScheduler runs the monitors from --monitors, nil when there are none
+var monitorScheduler *monitor.Scheduler
+
+// newMonitorScheduler loads the monitors file, resolving suite_id against the suite store
+func newMonitorScheduler(path string) (*monitor.Scheduler, error) {
+	cfg, err := monitor.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	scheduler, err := monitor.NewScheduler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	scheduler.SetSuiteLoader(func(m monitor.Monitor) (*types.TestSuite, error) {
+		if m.SuiteID == "" {
+			suite, err := config.LoadTestSuite(m.Suite)
+			if err != nil {
+				return nil, err
+			}
+			if err := config.ResolveSecrets(suite, filepath.Dir(m.Suite)); err != nil {
+				return nil, err
+			}
+			return suite, nil
+		}
+		if _, err := suiteStore.Get(m.SuiteID); err != nil {
+			return nil, err
+		}
+		// Saved suites come from API callers, so they get the same secrets and
+		// file restrictions as runs do; the scheduler also hides the process
+		// environment from their when expressions
+		suite, err := config.LoadTestSuite(suiteStore.Path(m.SuiteID), apiSuiteOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if err := resolveServerSecrets(suite); err != nil {
+			return nil, err
+		}
+		return suite, nil
+	})
+	return scheduler, nil
+}
+
+// listMonitorsEndpoint - GET /api/v1/monitors
+func listMonitorsEndpoint(c *gin.Context) {
+	if monitorScheduler == nil {
+		c.JSON(200, gin.H{"monitors": []monitor.MonitorStatus{}})
+		return
+	}
+	c.JSON(200, gin.H{"monitors": monitorScheduler.Statuses()})
+}
+
+// runMonitorEndpoint - POST /api/v1/monitors/:name/run
+func runMonitorEndpoint(c *gin.Context) {
+	if monitorScheduler == nil {
+		c.JSON(404, gin.H{"error": monitor.ErrUnknownMonitor.Error()})
+		return
+	}
+
+	middleware.Logger(c).Info("monitor triggered", "monitor", c.Param("name"))
+	run, err := monitorScheduler.RunMonitor(c.Request.Context(), c.Param("name"))
+	switch {
+	case errors.Is(err, monitor.ErrUnknownMonitor):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, monitor.ErrBusy):
+		c.JSON(409, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(499, gin.H{"error": err.Error()})
+	default:
+		c.JSON(200, run)
+	}
+}
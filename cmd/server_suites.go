@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/middleware"
+	"github.com/Asadus16/comapi/internal/suites"
+	"github.com/gin-gonic/gin"
+)
+
+// Suite storage endpoints. Suites are YAML files in --suites-dir, so anything
+// saved from the UI can be run with "comapi run <suites-dir>/<id>.yaml".
+
+// listSuitesEndpoint - GET /api/v1/suites
+func listSuitesEndpoint(c *gin.Context) {
+	summaries, err := suiteStore.List()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"suites": summaries})
+}
+
+// getSuiteEndpoint - GET /api/v1/suites/:id
+func getSuiteEndpoint(c *gin.Context) {
+	stored, err := suiteStore.Get(c.Param("id"))
+	if err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+	c.JSON(200, stored)
+}
+
+// createSuiteEndpoint - POST /api/v1/suites
+func createSuiteEndpoint(c *gin.Context) {
+	content, _, ok := decodeSuiteContent(c)
+	if !ok {
+		return
+	}
+
+	stored, err := suiteStore.Create(content)
+	if err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+
+	middleware.Logger(c).Info("suite created", "suite_id", stored.ID, "path", stored.Path)
+	c.Header("Location", "/api/v1/suites/"+stored.ID)
+	c.JSON(201, stored)
+}
+
+// updateSuiteEndpoint - PUT /api/v1/suites/:id
+// Send the "version" you loaded to be told (409) about concurrent edits.
+func updateSuiteEndpoint(c *gin.Context) {
+	content, baseVersion, ok := decodeSuiteContent(c)
+	if !ok {
+		return
+	}
+
+	stored, err := suiteStore.Update(c.Param("id"), content, baseVersion)
+	if err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+
+	middleware.Logger(c).Info("suite updated", "suite_id", stored.ID, "version", stored.Version)
+	c.JSON(200, stored)
+}
+
+// deleteSuiteEndpoint - DELETE /api/v1/suites/:id
+func deleteSuiteEndpoint(c *gin.Context) {
+	if err := suiteStore.Delete(c.Param("id")); err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+	middleware.Logger(c).Info("suite deleted", "suite_id", c.Param("id"))
+	c.Status(204)
+}
+
+// listSuiteVersionsEndpoint - GET /api/v1/suites/:id/versions
+func listSuiteVersionsEndpoint(c *gin.Context) {
+	versions, err := suiteStore.Versions(c.Param("id"))
+	if err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"id": c.Param("id"), "versions": versions})
+}
+
+// getSuiteVersionEndpoint - GET /api/v1/suites/:id/versions/:version
+func getSuiteVersionEndpoint(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(400, gin.H{"error": "version must be a positive integer"})
+		return
+	}
+
+	stored, err := suiteStore.GetVersion(c.Param("id"), version)
+	if err != nil {
+		writeSuiteError(c, err)
+		return
+	}
+	c.JSON(200, stored)
+}
+
+// decodeSuiteContent reads a suite to store from either {"content": "<yaml>"}, which is
+// saved verbatim with its comments, or {"test_suite": {...}}, which is converted to YAML.
+// An optional "version" field is returned as the base version for updates. Invalid suites
+// are answered with 400 and ok=false.
+func decodeSuiteContent(c *gin.Context) (content []byte, baseVersion int, ok bool) {
+	root, err := readRequestNode(c)
+	if err != nil {
+		invalidRequest(c, err)
+		return nil, 0, false
+	}
+
+	if versionNode := requestField(root, "version"); versionNode != nil {
+		if baseVersion, err = strconv.Atoi(versionNode.Value); err != nil {
+			c.JSON(400, gin.H{"error": "version must be an integer"})
+			return nil, 0, false
+		}
+	}
+
+	var diagnostics config.Diagnostics
+	switch contentNode, suiteNode := requestField(root, "content"), requestField(root, "test_suite"); {
+	case contentNode != nil:
+		content = []byte(contentNode.Value)
+		_, diagnostics = config.ValidateDocument(content, "content", apiSuiteOptions...)
+	case suiteNode != nil:
+		_, diagnostics = config.ValidateNode(suiteNode, "request", apiSuiteOptions...)
+		if !diagnostics.HasErrors() {
+			content, err = suites.Encode(suiteNode)
+		}
+	default:
+		c.JSON(400, gin.H{"error": "Request must contain test_suite or content"})
+		return nil, 0, false
+	}
+
+	if diagnostics.HasErrors() {
+		c.JSON(400, gin.H{
+			"error":       "Invalid test suite",
+			"errors":      diagnostics.Messages(),
+			"diagnostics": diagnostics,
+		})
+		return nil, 0, false
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, 0, false
+	}
+	return content, baseVersion, true
+}
+
+// writeSuiteError maps store errors to HTTP statuses
+func writeSuiteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, suites.ErrNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, suites.ErrConflict):
+		c.JSON(409, gin.H{"error": err.Error()})
+	default:
+		c.JSON(500, gin.H{"error": err.Error()})
+	}
+}
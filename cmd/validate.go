@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [test-file...]",
+	Short: "Check test files for errors without running them",
+	Long: `Validate one or more YAML test files and report every problem found,
+with the file, line and column where it occurs.
+
+Example:
+  comapi validate tests.yaml
+  comapi validate suites/*.yaml --output json`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		var all config.Diagnostics
+		for _, testFile := range args {
+			_, diagnostics := config.ValidateFile(testFile, schemaFetchOptions(cmd)...)
+			all = append(all, diagnostics...)
+		}
+
+		if output == "json" {
+			if all == nil {
+				all = config.Diagnostics{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(gin.H{"valid": !all.HasErrors(), "diagnostics": all})
+		} else {
+			printDiagnostics(all)
+			if !all.HasErrors() {
+				fmt.Printf("✅ %d file(s) valid\n", len(args))
+			}
+		}
+
+		if all.HasErrors() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringP("output", "o", "console", "Output format (console, json)")
+	validateCmd.Flags().Bool("fetch-schemas", false, "Introspect GraphQL schema URLs to validate queries against")
+}
+
+// printDiagnostics prints validation problems one per line
+func printDiagnostics(diagnostics config.Diagnostics) {
+	for _, diagnostic := range diagnostics {
+		icon := "❌"
+		if diagnostic.Severity == config.SeverityWarning {
+			icon = "⚠️ "
+		}
+		fmt.Printf("%s %s\n", icon, diagnostic)
+	}
+}
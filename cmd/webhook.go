@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+// webhookCmd groups the webhook helpers
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Work with run completion webhooks",
+	Long: `Suites can notify URLs when a run completes:
+
+  webhooks:
+    - url: "{{CHATOPS_WEBHOOK_URL}}"
+      on: failure                      # or always (default)
+      secret: "{{CHATOPS_SECRET}}"      # signs the body, see below
+      max_attempts: 5                  # retries network errors, 429 and 5xx
+      template: '{"text": {{json .Suite}}, "status": "{{.Status}}"}'
+
+"comapi run --webhook URL" adds webhooks from the command line.
+
+The default body is a JSON summary with event, suite, status, total_tests,
+passed_tests, failed_tests, skipped_tests, duration_ms, finished_at and
+failures. Templates see the same fields (.Suite, .Status, .Failures, ...) and
+the full result as .Result.
+
+When a secret is set, X-Comapi-Signature-256 holds "sha256=" followed by the
+hex HMAC-SHA256 of the body. Receivers should compute it themselves and
+compare in constant time.`,
+}
+
+// webhookReceiveCmd runs a local receiver for trying webhooks out
+var webhookReceiveCmd = &cobra.Command{
+	Use:   "receive",
+	Short: "Print incoming webhooks and check their signatures",
+	Long: `Start a local receiver that prints every webhook it gets and checks its
+signature, for trying suite webhooks out before pointing them at a real bot.
+
+Example:
+  comapi webhook receive --port 9090 --secret s3cret
+  comapi run tests.yaml --webhook http://localhost:9090/ --webhook-secret s3cret
+  comapi webhook receive --fail-first 2     # answer 503 twice to exercise retries`,
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetString("port")
+		secret, _ := cmd.Flags().GetString("secret")
+		if secret == "" {
+			secret = os.Getenv("COMAPI_WEBHOOK_SECRET")
+		}
+		status, _ := cmd.Flags().GetInt("status")
+		failFirst, _ := cmd.Flags().GetInt("fail-first")
+
+		var mu sync.Mutex
+		received := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+			if err != nil {
+				http.Error(w, err.Error(), 400)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			received++
+
+			fmt.Printf("📨 %s %s %s (%s, delivery %s)\n", time.Now().Format("15:04:05"), r.Method, r.URL.Path,
+				orDash(r.Header.Get(webhook.EventHeader)), orDash(r.Header.Get(webhook.DeliveryHeader)))
+
+			signature := r.Header.Get(webhook.SignatureHeader)
+			switch {
+			case secret == "" && signature != "":
+				fmt.Printf("  🔏 Signed, pass --secret to verify\n")
+			case secret == "":
+			case signature == "":
+				fmt.Printf("  ❌ Missing %s\n", webhook.SignatureHeader)
+			case webhook.Verify(secret, body, signature):
+				fmt.Printf("  🔏 Signature valid\n")
+			default:
+				fmt.Printf("  ❌ Signature does not match\n")
+			}
+
+			var pretty bytes.Buffer
+			if json.Indent(&pretty, body, "  ", "  ") == nil {
+				fmt.Printf("  %s\n", pretty.String())
+			} else {
+				fmt.Printf("  %s\n", body)
+			}
+
+			answer := status
+			if received <= failFirst {
+				answer = http.StatusServiceUnavailable
+			}
+			fmt.Printf("  ↩️  %d\n\n", answer)
+			w.WriteHeader(answer)
+		})
+
+		fmt.Printf("📬 Receiving webhooks on http://localhost:%s/\n\n", port)
+		if err := http.ListenAndServe(":"+port, handler); err != nil {
+			fmt.Printf("❌ Receiver failed: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(webhookReceiveCmd)
+
+	webhookReceiveCmd.Flags().StringP("port", "p", "9090", "Port to listen on")
+	webhookReceiveCmd.Flags().String("secret", "", "Secret to verify signatures with (env COMAPI_WEBHOOK_SECRET)")
+	webhookReceiveCmd.Flags().Int("status", 200, "Status code to answer with")
+	webhookReceiveCmd.Flags().Int("fail-first", 0, "Answer the first N deliveries with 503")
+}
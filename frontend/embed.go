@@ -0,0 +1,25 @@
+//go:build embedui
+
+// Package frontend exposes the production build of the React UI to the Go server.
+// Build the UI first, then compile with the embedui tag:
+//
+//	cd frontend && npm ci && npm run build && cd ..
+//	go build -tags embedui .
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:build
+var files embed.FS
+
+// Files returns the built UI, rooted at the directory holding index.html
+func Files() (fs.FS, bool) {
+	build, err := fs.Sub(files, "build")
+	if err != nil {
+		return nil, false
+	}
+	return build, true
+}
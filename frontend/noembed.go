@@ -0,0 +1,12 @@
+//go:build !embedui
+
+// Package frontend exposes the production build of the React UI to the Go server.
+// This binary was built without the embedui tag, so no UI is bundled; see embed.go.
+package frontend
+
+import "io/fs"
+
+// Files reports that no UI is embedded in this binary
+func Files() (fs.FS, bool) {
+	return nil, false
+}
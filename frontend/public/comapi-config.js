@@ -0,0 +1,3 @@
+// Development default. `comapi server` serves its own version of this file
+// pointing the UI at the API it hosts.
+window.COMAPI_CONFIG = window.COMAPI_CONFIG || {};
@@ -1,8 +1,8 @@
-import React, { useState } from 'react';
+import React, { useEffect, useRef, useState } from 'react';
 import TestConfiguration from './components/TestConfiguration';
 import TestResults from './components/TestResults';
 import LoadingSpinner from './components/LoadingSpinner';
-import { runTests } from './services/api';
+import { startRun, streamRun, getRun, cancelRun, API_BASE_URL } from './services/api';
 import Header from './components/header';
 
 const App = () => {
@@ -17,18 +17,67 @@ const App = () => {
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState(null);
 
+  const [run, setRun] = useState(null);
+  const [progress, setProgress] = useState(null);
+  const stopStreaming = useRef(null);
+
+  // Stop listening for run events when the page goes away
+  useEffect(() => () => stopStreaming.current && stopStreaming.current(), []);
+
+  // The done event carries the run's summary; the results arrive one by one before it
+  const finishRun = (job, streamed) => {
+    setRun(null);
+    setProgress(null);
+    setLoading(false);
+
+    if (!job.summary) {
+      setError(`Run ${job.status} without results`);
+      return;
+    }
+    setResults({ ...job.summary, results: job.results || streamed });
+    if (job.status === 'cancelled') setError('Run was cancelled before all tests finished');
+  };
+
   const handleRunTests = async () => {
     setLoading(true);
     setError(null);
     setResults(null);
-    
+    setProgress(null);
+
+    let started;
     try {
-      const data = await runTests(testSuite);
-      setResults(data);
+      started = await startRun(testSuite);
     } catch (err) {
       setError(err.message);
-    } finally {
       setLoading(false);
+      return;
+    }
+    setRun(started);
+
+    const streamed = [];
+    stopStreaming.current = streamRun(started.id, {
+      onTestStart: (event) => setProgress(event),
+      onResult: (event) => streamed.push(event.result),
+      onDone: (job) => finishRun(job, streamed),
+      // The run keeps going on the server, so fetch whatever it has got to
+      onError: async (err) => {
+        try {
+          finishRun(await getRun(started.id), streamed);
+        } catch {
+          setError(err.message);
+          setRun(null);
+          setLoading(false);
+        }
+      },
+    });
+  };
+
+  const handleCancel = async () => {
+    if (!run) return;
+    try {
+      await cancelRun(run.id);
+    } catch (err) {
+      setError(err.message);
     }
   };
 
@@ -54,7 +103,21 @@ const App = () => {
           <div className="space-y-6">
             {loading && (
               <div className="bg-dark-surface rounded-xl border border-dark-border p-8">
-                <LoadingSpinner message="Running your API tests..." />
+                <LoadingSpinner
+                  message={progress
+                    ? `Running test ${progress.index + 1} of ${progress.total}: ${progress.test}`
+                    : 'Running your API tests...'}
+                />
+                {run && (
+                  <div className="mt-6 text-center">
+                    <button
+                      onClick={handleCancel}
+                      className="px-4 py-2 rounded-lg border border-red-500/30 text-red-400 hover:bg-red-500/10 transition-colors"
+                    >
+                      Cancel Run
+                    </button>
+                  </div>
+                )}
               </div>
             )}
             
@@ -75,7 +138,7 @@ const App = () => {
                       </summary>
                       <ul className="mt-2 space-y-1 text-red-200 ml-4">
                         <li>• Make sure Go backend is running: <code className="bg-red-800/30 px-1 rounded">go run main.go server</code></li>
-                        <li>• Check server is accessible at <span className="text-neon-orange">http://localhost:8080</span></li>
+                        <li>• Check server is accessible at <span className="text-neon-orange">{API_BASE_URL}</span></li>
                         <li>• Verify your test configuration is valid</li>
                       </ul>
                     </details>
@@ -1,6 +1,19 @@
 // API service to communicate with Go backend
 
-const API_BASE_URL = 'http://localhost:8080/api/v1';
+// When served by `comapi server`, /comapi-config.js tells us where the API lives.
+// In development (`npm start`) set REACT_APP_API_URL or use the default local server.
+export const API_BASE_URL =
+  (window.COMAPI_CONFIG && window.COMAPI_CONFIG.apiBaseUrl) ||
+  process.env.REACT_APP_API_URL ||
+  'http://localhost:8080/api/v1';
+
+// API token for servers started with --token, kept in localStorage under "comapi_token"
+const getToken = () => localStorage.getItem('comapi_token');
+
+const authHeaders = () => {
+  const token = getToken();
+  return token ? { Authorization: `Bearer ${token}` } : {};
+};
 
 // Run tests endpoint
 export const runTests = async (testSuite) => {
@@ -9,6 +22,7 @@ export const runTests = async (testSuite) => {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ test_suite: testSuite }),
     });
@@ -21,7 +35,7 @@ export const runTests = async (testSuite) => {
     return await response.json();
   } catch (error) {
     if (error.name === 'TypeError' && error.message.includes('fetch')) {
-      throw new Error('Unable to connect to Comapi server. Make sure the Go backend is running on port 8080.');
+      throw new Error(`Unable to connect to Comapi server at ${API_BASE_URL}. Make sure the Go backend is running.`);
     }
     throw error;
   }
@@ -34,6 +48,7 @@ export const validateTests = async (testSuite) => {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        ...authHeaders(),
       },
       body: JSON.stringify({ test_suite: testSuite }),
     });
@@ -52,4 +67,98 @@ export const healthCheck = async () => {
   } catch (error) {
     throw new Error('Backend server is not responding');
   }
-};
\ No newline at end of file
+};
+
+// Start a background run of a whole suite; resolves to { id, status, total_tests, links }
+export const startRun = async (testSuite) => {
+  const response = await fetch(`${API_BASE_URL}/runs`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...authHeaders(),
+    },
+    body: JSON.stringify({ test_suite: testSuite }),
+  });
+
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || `HTTP ${response.status}: ${response.statusText}`);
+  }
+  return data;
+};
+
+// Fetch the current state of a run, including the results so far
+export const getRun = async (runId) => {
+  const response = await fetch(`${API_BASE_URL}/runs/${runId}`, { headers: authHeaders() });
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || `HTTP ${response.status}: ${response.statusText}`);
+  }
+  return data;
+};
+
+// Cancel a queued or running run
+export const cancelRun = async (runId) => {
+  const response = await fetch(`${API_BASE_URL}/runs/${runId}`, { method: 'DELETE', headers: authHeaders() });
+  return await response.json();
+};
+
+// Stream progress of a run. Handlers: onTestStart(event), onResult(event), onDone(job), onError(error).
+// Returns a function that stops streaming.
+export const streamRun = (runId, { onTestStart, onResult, onDone, onError } = {}) => {
+  // EventSource cannot send headers, so the token goes in the query string
+  const token = getToken();
+  const query = token ? `?access_token=${encodeURIComponent(token)}` : '';
+  const source = new EventSource(`${API_BASE_URL}/runs/${runId}/events${query}`);
+
+  source.addEventListener('test_start', (e) => onTestStart && onTestStart(JSON.parse(e.data)));
+  source.addEventListener('result', (e) => onResult && onResult(JSON.parse(e.data)));
+  source.addEventListener('done', (e) => {
+    source.close();
+    if (onDone) onDone(JSON.parse(e.data).job);
+  });
+  source.onerror = () => {
+    if (source.readyState === EventSource.CLOSED) return;
+    source.close();
+    if (onError) onError(new Error('Lost connection to Comapi server'));
+  };
+
+  return () => source.close();
+};
+
+// Saved suites. Each suite is a YAML file on the server that `comapi run` can execute.
+const requestJSON = async (path, options = {}) => {
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    headers: { 'Content-Type': 'application/json', ...authHeaders() },
+    ...options,
+  });
+  if (response.status === 204) return null;
+
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || `HTTP ${response.status}: ${response.statusText}`);
+  }
+  return data;
+};
+
+export const listSuites = async () => (await requestJSON('/suites')).suites;
+
+export const getSuite = (suiteId) => requestJSON(`/suites/${suiteId}`);
+
+// Create a suite, or update it when suiteId is given. Pass the version you loaded to detect conflicting edits.
+export const saveSuite = (testSuite, suiteId, version) =>
+  requestJSON(suiteId ? `/suites/${suiteId}` : '/suites', {
+    method: suiteId ? 'PUT' : 'POST',
+    body: JSON.stringify({ test_suite: testSuite, version }),
+  });
+
+export const deleteSuite = (suiteId) => requestJSON(`/suites/${suiteId}`, { method: 'DELETE' });
+
+export const listSuiteVersions = async (suiteId) => (await requestJSON(`/suites/${suiteId}/versions`)).versions;
+
+export const getSuiteVersion = (suiteId, version) => requestJSON(`/suites/${suiteId}/versions/${version}`);
+
+// Scheduled monitors, when the server runs with --monitors
+export const listMonitors = async () => (await requestJSON('/monitors')).monitors;
+
+export const runMonitor = (name) => requestJSON(`/monitors/${encodeURIComponent(name)}/run`, { method: 'POST' });
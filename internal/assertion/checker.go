@@ -6,17 +6,24 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Asadus16/comapi/pkg/assertions"
 	"github.com/Asadus16/comapi/pkg/types"
 	"github.com/tidwall/gjson"
 )
 
 // CheckAssertions validates all assertions for a test result
 func CheckAssertions(testCase types.TestCase, result *types.TestResult) {
+	CheckAssertionsWithVariables(testCase, result, nil)
+}
+
+// CheckAssertionsWithVariables validates all assertions, making the run
+// variables available to expression assertions
+func CheckAssertionsWithVariables(testCase types.TestCase, result *types.TestResult, variables map[string]string) {
 	var assertionResults []types.AssertionResult
 	allPassed := true
 
 	for _, assertion := range testCase.Assertions {
-		assertionResult := checkSingleAssertion(assertion, result)
+		assertionResult := checkSingleAssertion(assertion, result, variables)
 		assertionResults = append(assertionResults, assertionResult)
 		
 		if !assertionResult.Passed {
@@ -33,7 +40,7 @@ func CheckAssertions(testCase types.TestCase, result *types.TestResult) {
 }
 
 // checkSingleAssertion validates a single assertion
-func checkSingleAssertion(assertion types.Assertion, result *types.TestResult) types.AssertionResult {
+func checkSingleAssertion(assertion types.Assertion, result *types.TestResult, variables map[string]string) types.AssertionResult {
 	assertionResult := types.AssertionResult{
 		Type:     assertion.Type,
 		Target:   assertion.Target,
@@ -50,13 +57,44 @@ func checkSingleAssertion(assertion types.Assertion, result *types.TestResult) t
 		assertionResult = checkHeaderAssertion(assertion, result)
 	case "response_time":
 		assertionResult = checkResponseTimeAssertion(assertion, result)
+	case "expr":
+		assertionResult = checkExprAssertion(assertion, result, variables)
+	case "graphql_errors":
+		assertionResult = checkGraphQLErrorsAssertion(assertion, result)
 	default:
-		assertionResult.Message = fmt.Sprintf("Unknown assertion type: %s", assertion.Type)
+		// Fall back to custom types registered by embedding programs
+		if custom, ok := assertions.Lookup(assertion.Type); ok {
+			assertionResult = checkCustomAssertion(custom, assertion, result)
+		} else {
+			assertionResult.Message = fmt.Sprintf("Unknown assertion type: %s", assertion.Type)
+		}
 	}
 
 	return assertionResult
 }
 
+// checkCustomAssertion runs a registered checker. A panicking checker fails the
+// assertion instead of taking the whole run down with it.
+func checkCustomAssertion(custom assertions.Checker, assertion types.Assertion, result *types.TestResult) (assertionResult types.AssertionResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			assertionResult = types.AssertionResult{
+				Type:     assertion.Type,
+				Target:   assertion.Target,
+				Expected: assertion.Expected,
+				Passed:   false,
+				Message:  fmt.Sprintf("%s assertion panicked: %v", assertion.Type, r),
+			}
+		}
+	}()
+
+	assertionResult = custom.Check(assertion, result)
+	if assertionResult.Type == "" {
+		assertionResult.Type = assertion.Type
+	}
+	return assertionResult
+}
+
 // checkStatusAssertion validates HTTP status code
 func checkStatusAssertion(assertion types.Assertion, result *types.TestResult) types.AssertionResult {
 	expected, ok := assertion.Expected.(int)
@@ -229,7 +267,30 @@ func checkResponseTimeAssertion(assertion types.Assertion, result *types.TestRes
 		}
 	}
 
+	// Measure the network time from the trace when there is one; target picks a single phase
 	actualMs := float64(result.Duration.Milliseconds())
+	label := "response time"
+	if assertion.Target != "" && assertion.Target != "total" {
+		label = assertion.Target
+	}
+	if result.Timings != nil {
+		phase := assertion.Target
+		if phase == "" {
+			phase = "total"
+		}
+		value, ok := result.Timings.Phase(phase)
+		if !ok {
+			return types.AssertionResult{
+				Type:     assertion.Type,
+				Target:   assertion.Target,
+				Expected: assertion.Expected,
+				Passed:   false,
+				Message:  fmt.Sprintf("Unknown timing phase '%s' (expected one of %s)", phase, strings.Join(types.TimingPhases, ", ")),
+			}
+		}
+		actualMs = value
+	}
+
 	operator := assertion.Operator
 	if operator == "" {
 		operator = "less_than"
@@ -241,13 +302,13 @@ func checkResponseTimeAssertion(assertion types.Assertion, result *types.TestRes
 	switch operator {
 	case "less_than":
 		passed = actualMs < expectedMs
-		message = fmt.Sprintf("Expected response time < %gms, got %gms", expectedMs, actualMs)
+		message = fmt.Sprintf("Expected %s < %gms, got %gms", label, expectedMs, actualMs)
 	case "greater_than":
 		passed = actualMs > expectedMs
-		message = fmt.Sprintf("Expected response time > %gms, got %gms", expectedMs, actualMs)
+		message = fmt.Sprintf("Expected %s > %gms, got %gms", label, expectedMs, actualMs)
 	case "equals":
 		passed = actualMs == expectedMs
-		message = fmt.Sprintf("Expected response time = %gms, got %gms", expectedMs, actualMs)
+		message = fmt.Sprintf("Expected %s = %gms, got %gms", label, expectedMs, actualMs)
 	default:
 		passed = false
 		message = fmt.Sprintf("Unknown operator: %s", operator)
@@ -255,6 +316,7 @@ func checkResponseTimeAssertion(assertion types.Assertion, result *types.TestRes
 
 	return types.AssertionResult{
 		Type:     assertion.Type,
+		Target:   assertion.Target,
 		Expected: expectedMs,
 		Actual:   actualMs,
 		Passed:   passed,
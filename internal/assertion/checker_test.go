@@ -0,0 +1,38 @@
+package assertion
+
+import (
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/assertions"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestCustomAssertion(t *testing.T) {
+	assertions.MustRegister("test_ok", assertions.CheckerFunc(
+		func(a types.Assertion, r *types.TestResult) types.AssertionResult {
+			return types.AssertionResult{Passed: r.Response.StatusCode == 204}
+		}))
+	assertions.MustRegister("test_panics", assertions.CheckerFunc(
+		func(a types.Assertion, r *types.TestResult) types.AssertionResult {
+			panic("boom")
+		}))
+	defer assertions.Unregister("test_ok")
+	defer assertions.Unregister("test_panics")
+
+	tests := []struct {
+		assertionType string
+		passed        bool
+		message       string
+	}{
+		{"test_ok", true, ""},
+		{"test_panics", false, "test_panics assertion panicked: boom"},
+		{"test_missing", false, "Unknown assertion type: test_missing"},
+	}
+	result := &types.TestResult{Response: types.ResponseInfo{StatusCode: 204}}
+	for _, tt := range tests {
+		got := checkSingleAssertion(types.Assertion{Type: tt.assertionType}, result, nil)
+		if got.Type != tt.assertionType || got.Passed != tt.passed || got.Message != tt.message {
+			t.Errorf("%s: got %+v, want passed %v message %q", tt.assertionType, got, tt.passed, tt.message)
+		}
+	}
+}
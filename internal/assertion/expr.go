@@ -0,0 +1,95 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/expr-lang/expr"
+)
+
+// CompileExpression checks an expr assertion for syntax errors without running it
+func CompileExpression(source string) error {
+	_, err := expr.Compile(source)
+	return err
+}
+
+// expressionEnv builds the values an expression can see. Only plain data is
+// exposed, so expressions cannot reach the file system, network or Go runtime.
+func expressionEnv(result *types.TestResult, variables map[string]string) map[string]interface{} {
+	var body interface{}
+	if err := json.Unmarshal([]byte(result.Response.Body), &body); err != nil {
+		body = nil
+	}
+
+	headers := make(map[string]interface{}, len(result.Response.Headers)*2)
+	for name, value := range result.Response.Headers {
+		headers[name] = value
+		headers[strings.ToLower(name)] = value
+	}
+
+	vars := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		vars[name] = value
+	}
+
+	// Phases are zero when no request was sent, so expressions never see nil
+	var timings types.Timings
+	if result.Timings != nil {
+		timings = *result.Timings
+	}
+	phases := make(map[string]interface{}, len(types.TimingPhases))
+	for _, phase := range types.TimingPhases {
+		phases[phase+"_ms"], _ = timings.Phase(phase)
+	}
+
+	return map[string]interface{}{
+		"status":      result.Response.StatusCode,
+		"headers":     headers,
+		"body":        body,
+		"text":        result.Response.Body,
+		"size":        result.Response.Size,
+		"duration_ms": float64(result.Duration.Microseconds()) / 1000,
+		"timings":     phases,
+		"vars":        vars,
+	}
+}
+
+// checkExprAssertion evaluates an expression that must return a boolean
+func checkExprAssertion(assertion types.Assertion, result *types.TestResult, variables map[string]string) types.AssertionResult {
+	assertionResult := types.AssertionResult{
+		Type:     assertion.Type,
+		Target:   assertion.Expr,
+		Expected: true,
+		Passed:   false,
+	}
+
+	env := expressionEnv(result, variables)
+	program, err := expr.Compile(assertion.Expr, expr.Env(env), expr.AsBool())
+	if err != nil {
+		assertionResult.Message = fmt.Sprintf("Invalid expression: %v", err)
+		return assertionResult
+	}
+
+	output, err := expr.Run(program, env)
+	if err != nil {
+		assertionResult.Message = fmt.Sprintf("Expression failed: %v", err)
+		return assertionResult
+	}
+
+	passed, _ := output.(bool)
+	assertionResult.Actual = passed
+	assertionResult.Passed = passed
+
+	switch {
+	case passed:
+		assertionResult.Message = fmt.Sprintf("Expression is true: %s", assertion.Expr)
+	case assertion.Message != "":
+		assertionResult.Message = assertion.Message
+	default:
+		assertionResult.Message = fmt.Sprintf("Expression is false: %s", assertion.Expr)
+	}
+
+	return assertionResult
+}
@@ -0,0 +1,86 @@
+package assertion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// checkGraphQLErrorsAssertion checks the errors array of a GraphQL response.
+// absent (the default) passes when there are no errors and present when there
+// is at least one; contains and equals look for an error whose value at target
+// (default "message") matches expected.
+func checkGraphQLErrorsAssertion(assertion types.Assertion, result *types.TestResult) types.AssertionResult {
+	assertionResult := types.AssertionResult{
+		Type:     assertion.Type,
+		Target:   assertion.Target,
+		Expected: assertion.Expected,
+	}
+
+	body := result.Response.Body
+	if !gjson.Valid(body) {
+		assertionResult.Message = "Response is not a GraphQL JSON response"
+		return assertionResult
+	}
+	errors := gjson.Get(body, "errors").Array()
+
+	path := assertion.Target
+	if path == "" {
+		path = "message"
+	}
+	values := make([]interface{}, len(errors))
+	for i, err := range errors {
+		values[i] = err.Get(path).Value()
+	}
+
+	operator := assertion.Operator
+	if operator == "" {
+		operator = "absent"
+	}
+
+	switch operator {
+	case "absent":
+		assertionResult.Actual = values
+		assertionResult.Passed = len(errors) == 0
+		assertionResult.Message = fmt.Sprintf("Expected no GraphQL errors, got %d: %s", len(errors), joinValues(values))
+	case "present":
+		assertionResult.Actual = len(errors)
+		assertionResult.Passed = len(errors) > 0
+		assertionResult.Message = "Expected GraphQL errors, got none"
+	case "equals":
+		assertionResult.Actual = values
+		for _, value := range values {
+			if value != nil && compareValues(value, assertion.Expected) {
+				assertionResult.Passed = true
+			}
+		}
+		assertionResult.Message = fmt.Sprintf("Expected a GraphQL error with %s %v, got %s", path, assertion.Expected, joinValues(values))
+	case "contains":
+		expected := fmt.Sprintf("%v", assertion.Expected)
+		assertionResult.Actual = values
+		for _, value := range values {
+			if value != nil && strings.Contains(fmt.Sprintf("%v", value), expected) {
+				assertionResult.Passed = true
+			}
+		}
+		assertionResult.Message = fmt.Sprintf("Expected a GraphQL error with %s containing '%s', got %s", path, expected, joinValues(values))
+	default:
+		assertionResult.Message = fmt.Sprintf("Unknown operator: %s", operator)
+	}
+
+	return assertionResult
+}
+
+// joinValues lists error values for failure messages
+func joinValues(values []interface{}) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("'%v'", value)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,203 @@
+package condition
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Lookup resolves a variable name to its value
+type Lookup func(name string) (string, bool)
+
+// Condition is a parsed `when:` expression such as `ENV == "staging" && !SKIP_SLOW`
+type Condition struct {
+	source  string
+	program *vm.Program
+	names   []string
+}
+
+// braced matches ${NAME}, which reads variables whose names are not identifiers
+var braced = regexp.MustCompile(`\$\{\s*([^}]*?)\s*\}`)
+
+// Parse compiles a condition expression. Conditions use the same expression
+// language as expr assertions, with every variable available by name:
+//
+//	NAME               true when NAME is set, non-empty and not "false"/"0"
+//	!NAME              negation
+//	NAME == "value"    equality (also !=); ${NAME} is accepted as well
+//	a && b, a || b     boolean combination, with parentheses for grouping
+//	NAME in ["a", "b"], NAME startsWith "v2", int(NAME) > 3, ...
+//
+// Variables are strings and unset ones are empty, so numbers and booleans
+// compared with a variable are compared as written, e.g. RETRIES == 3.
+func Parse(source string) (*Condition, error) {
+	rewritten := braced.ReplaceAllStringFunc(source, func(match string) string {
+		return "$env[" + strconv.Quote(braced.FindStringSubmatch(match)[1]) + "]"
+	})
+
+	patcher := &patcher{names: make(map[string]bool)}
+	program, err := expr.Compile(rewritten,
+		expr.Function("truthy", func(params ...interface{}) (interface{}, error) {
+			return isTruthy(params[0]), nil
+		}, new(func(interface{}) bool)),
+		expr.Patch(patcher),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s", firstLine(err.Error()))
+	}
+
+	names := make([]string, 0, len(patcher.names))
+	for name := range patcher.names {
+		names = append(names, name)
+	}
+	return &Condition{source: source, program: program, names: names}, nil
+}
+
+// String returns the original expression
+func (c *Condition) String() string {
+	return c.source
+}
+
+// Eval evaluates the condition against the given variables
+func (c *Condition) Eval(lookup Lookup) (bool, error) {
+	env := make(map[string]interface{}, len(c.names))
+	for _, name := range c.names {
+		value, _ := lookup(name)
+		env[name] = value
+	}
+
+	output, err := expr.Run(c.program, env)
+	if err != nil {
+		return false, fmt.Errorf("%s", firstLine(err.Error()))
+	}
+	return isTruthy(output), nil
+}
+
+// Evaluate parses and evaluates an expression in one step
+func Evaluate(source string, lookup Lookup) (bool, error) {
+	cond, err := Parse(source)
+	if err != nil {
+		return false, err
+	}
+	return cond.Eval(lookup)
+}
+
+// isTruthy reports whether a value counts as "set"
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "false", "no", "off":
+			return false
+		}
+		return true
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return true
+	}
+}
+
+// patcher adapts the expression tree to condition semantics: it records the
+// variables to look up, applies truthiness where a boolean is expected and
+// turns literals compared with a variable into the strings variables hold.
+type patcher struct {
+	names map[string]bool
+}
+
+func (p *patcher) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if n.Value != "$env" {
+			p.names[n.Value] = true
+		}
+	case *ast.MemberNode:
+		if name, ok := envMember(n); ok {
+			p.names[name] = true
+		}
+	case *ast.CallNode:
+		// Callees are visited first, so drop them again
+		if callee, ok := n.Callee.(*ast.IdentifierNode); ok {
+			delete(p.names, callee.Value)
+		}
+	case *ast.UnaryNode:
+		if n.Operator == "!" || n.Operator == "not" {
+			truthy(&n.Node)
+		}
+	case *ast.BinaryNode:
+		switch n.Operator {
+		case "&&", "||", "and", "or":
+			truthy(&n.Left)
+			truthy(&n.Right)
+		case "==", "!=":
+			if isVariable(n.Left) {
+				asString(&n.Right)
+			}
+			if isVariable(n.Right) {
+				asString(&n.Left)
+			}
+		}
+	}
+}
+
+// truthy wraps a boolean operand so variables can be used as flags
+func truthy(node *ast.Node) {
+	ast.Patch(node, &ast.CallNode{
+		Callee:    &ast.IdentifierNode{Value: "truthy"},
+		Arguments: []ast.Node{*node},
+	})
+}
+
+// asString replaces a number or boolean literal by its text
+func asString(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IntegerNode:
+		ast.Patch(node, &ast.StringNode{Value: strconv.Itoa(n.Value)})
+	case *ast.FloatNode:
+		ast.Patch(node, &ast.StringNode{Value: strconv.FormatFloat(n.Value, 'f', -1, 64)})
+	case *ast.BoolNode:
+		ast.Patch(node, &ast.StringNode{Value: strconv.FormatBool(n.Value)})
+	}
+}
+
+// isVariable reports whether node reads a variable, by name or through ${NAME}
+func isVariable(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return n.Value != "$env"
+	case *ast.MemberNode:
+		_, ok := envMember(n)
+		return ok
+	}
+	return false
+}
+
+// envMember returns NAME for $env["NAME"]
+func envMember(n *ast.MemberNode) (string, bool) {
+	env, ok := n.Node.(*ast.IdentifierNode)
+	if !ok || env.Value != "$env" {
+		return "", false
+	}
+	property, ok := n.Property.(*ast.StringNode)
+	if !ok {
+		return "", false
+	}
+	return property.Value, true
+}
+
+// firstLine drops the source excerpt expr appends to its errors
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -0,0 +1,87 @@
+package condition
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	variables := map[string]string{
+		"ENV":       "staging",
+		"DEBUG":     "true",
+		"SKIP_SLOW": "0",
+		"OFF":       "off",
+		"RETRIES":   "3",
+		"RATIO":     "1.5",
+		"api-host":  "api.example.com",
+		"VERSION":   "v2.1",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		// Flags
+		{"DEBUG", true},
+		{"SKIP_SLOW", false},
+		{"OFF", false},
+		{"UNSET", false},
+		{"!SKIP_SLOW", true},
+		{"not DEBUG", false},
+		{"DEBUG && !SKIP_SLOW", true},
+		{"SKIP_SLOW || OFF", false},
+		{"(SKIP_SLOW || DEBUG) && ENV", true},
+
+		// Comparisons
+		{`ENV == "staging"`, true},
+		{`ENV != 'staging'`, false},
+		{`UNSET == ""`, true},
+		{"RETRIES == 3", true},
+		{"3 == RETRIES", true},
+		{"RATIO == 1.5", true},
+		{"DEBUG == true", true},
+		{`${api-host} == "api.example.com"`, true},
+		{`${ ENV } == "staging" && ${UNSET} == ""`, true},
+
+		// The rest of the expression language
+		{`ENV in ["staging", "production"]`, true},
+		{`VERSION startsWith "v2"`, true},
+		{"int(RETRIES) > 2", true},
+		{`len(ENV) == 7`, true},
+		{`ENV == "staging" ? DEBUG : SKIP_SLOW`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := Evaluate(tt.source, lookup)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"ENV ==",
+		`ENV == "staging`,
+		"(DEBUG",
+		"DEBUG &&",
+		"${ENV",
+	}
+	for _, source := range tests {
+		if _, err := Parse(source); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", source)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	lookup := func(string) (string, bool) { return "abc", true }
+	if _, err := Evaluate("int(COUNT) > 1", lookup); err == nil {
+		t.Error("Evaluate() succeeded converting a non-number, want an error")
+	}
+}
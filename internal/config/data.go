@@ -0,0 +1,266 @@
+package config
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// dataRow is one set of parameter values, with keys in display order
+type dataRow struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// label renders the row for the generated test name, e.g. "id=3, locale=en"
+func (r dataRow) label() string {
+	parts := make([]string, len(r.keys))
+	for i, key := range r.keys {
+		parts[i] = key + "=" + vars.Format(r.values[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExpandDataTests replaces every test that has a data block with one test per row.
+// Relative data files are resolved against baseDir. References to an expanded test in
+// depends_on are rewritten to depend on all of its generated cases.
+func ExpandDataTests(tests []types.TestCase, baseDir string) ([]types.TestCase, error) {
+	return expandDataTests(tests, func(types.TestCase) string { return baseDir })
+}
+
+// expandDataTests is ExpandDataTests with relative data files of each test
+// resolved against dir(test)
+func expandDataTests(tests []types.TestCase, dir func(types.TestCase) string) ([]types.TestCase, error) {
+	var expanded []types.TestCase
+	generated := make(map[string][]string)
+
+	for _, test := range tests {
+		if test.Data == nil {
+			expanded = append(expanded, test)
+			continue
+		}
+
+		rows, err := loadDataRows(test.Data, dir(test))
+		if err != nil {
+			return nil, fmt.Errorf("test '%s': %w", test.Name, err)
+		}
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("test '%s': data block produced no rows", test.Name)
+		}
+
+		for _, row := range rows {
+			generatedCase := applyDataRow(test, row)
+			generated[test.Name] = append(generated[test.Name], generatedCase.Name)
+			expanded = append(expanded, generatedCase)
+		}
+	}
+
+	if len(generated) == 0 {
+		return expanded, nil
+	}
+
+	for i := range expanded {
+		var deps []string
+		for _, dep := range expanded[i].DependsOn {
+			if names, ok := generated[dep]; ok {
+				deps = append(deps, names...)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		expanded[i].DependsOn = deps
+	}
+
+	return expanded, nil
+}
+
+// applyDataRow returns a copy of the test with the row substituted in
+func applyDataRow(test types.TestCase, row dataRow) types.TestCase {
+	lookup := vars.FromMap(row.values)
+
+	result := test
+	result.Data = nil
+	if vars.HasPlaceholders(test.Name) {
+		result.Name = vars.Expand(test.Name, lookup)
+	} else {
+		result.Name = fmt.Sprintf("%s [%s]", test.Name, row.label())
+	}
+	result.Description = vars.Expand(test.Description, lookup)
+	result.Path = vars.Expand(test.Path, lookup)
+	result.URL = vars.Expand(test.URL, lookup)
+	result.Body = vars.Expand(test.Body, lookup)
+	result.Headers = vars.ExpandMap(test.Headers, lookup)
+	if test.GraphQL != nil {
+		request := *test.GraphQL
+		request.Query = vars.Expand(request.Query, lookup)
+		if request.Variables != nil {
+			request.Variables, _ = vars.ExpandValue(request.Variables, lookup).(map[string]interface{})
+		}
+		result.GraphQL = &request
+	}
+	if test.GRPC != nil {
+		request := *test.GRPC
+		request.Message = vars.ExpandValue(request.Message, lookup)
+		request.Metadata = vars.ExpandMap(request.Metadata, lookup)
+		result.GRPC = &request
+	}
+	if test.MockResponse != nil {
+		mock := *test.MockResponse
+		mock.Body = vars.Expand(mock.Body, lookup)
+		mock.Headers = vars.ExpandMap(mock.Headers, lookup)
+		result.MockResponse = &mock
+	}
+
+	result.Assertions = make([]types.Assertion, len(test.Assertions))
+	for i, assertion := range test.Assertions {
+		assertion.Target = vars.Expand(assertion.Target, lookup)
+		assertion.Expected = vars.ExpandValue(assertion.Expected, lookup)
+		result.Assertions[i] = assertion
+	}
+
+	return result
+}
+
+// loadDataRows collects inline and file rows, then crosses them with the matrix
+func loadDataRows(data *types.TestData, baseDir string) ([]dataRow, error) {
+	var rows []dataRow
+
+	for _, values := range data.Rows {
+		rows = append(rows, dataRow{keys: sortedKeys(values), values: values})
+	}
+
+	if data.File != "" {
+		path := data.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		fileRows, err := readDataFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	if len(data.Matrix) > 0 {
+		rows = crossMatrix(rows, data.Matrix)
+	}
+
+	return rows, nil
+}
+
+// crossMatrix combines each existing row (or a single empty row) with every matrix combination
+func crossMatrix(rows []dataRow, matrix map[string][]interface{}) []dataRow {
+	if len(rows) == 0 {
+		rows = []dataRow{{values: map[string]interface{}{}}}
+	}
+
+	for _, key := range sortedKeys(matrix) {
+		var next []dataRow
+		for _, row := range rows {
+			for _, value := range matrix[key] {
+				values := make(map[string]interface{}, len(row.values)+1)
+				for k, v := range row.values {
+					values[k] = v
+				}
+				values[key] = value
+				keys := append(append([]string{}, row.keys...), key)
+				next = append(next, dataRow{keys: keys, values: values})
+			}
+		}
+		rows = next
+	}
+
+	return rows
+}
+
+// readDataFile loads rows from a .csv or .json file
+func readDataFile(path string) ([]dataRow, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseCSVRows(content, path)
+	case ".json":
+		return parseJSONRows(content, path)
+	default:
+		return nil, fmt.Errorf("unsupported data file %s (expected .csv or .json)", path)
+	}
+}
+
+func parseCSVRows(content []byte, path string) ([]dataRow, error) {
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var rows []dataRow
+	for _, record := range records[1:] {
+		values := make(map[string]interface{}, len(header))
+		for i, key := range header {
+			if i < len(record) {
+				values[key] = parseCSVValue(record[i])
+			}
+		}
+		rows = append(rows, dataRow{keys: header, values: values})
+	}
+	return rows, nil
+}
+
+// parseCSVValue turns numeric and boolean cells into typed values so they
+// compare naturally in status and json_path assertions. Only cells that read
+// back unchanged are converted, so "007", "1.50" or "nan" stay text and are
+// substituted exactly as written.
+func parseCSVValue(cell string) interface{} {
+	cell = strings.TrimSpace(cell)
+	if i, err := strconv.Atoi(cell); err == nil && strconv.Itoa(i) == cell {
+		return i
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == cell {
+		return f
+	}
+	if cell == "true" || cell == "false" {
+		return cell == "true"
+	}
+	return cell
+}
+
+func parseJSONRows(content []byte, path string) ([]dataRow, error) {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(content, &objects); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON %s (expected an array of objects): %w", path, err)
+	}
+
+	rows := make([]dataRow, len(objects))
+	for i, values := range objects {
+		rows[i] = dataRow{keys: sortedKeys(values), values: values}
+	}
+	return rows, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
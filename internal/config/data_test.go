@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestExpandDataTests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.csv":  "id, zip ,ratio,flag,note\n7,02134,1.5,true,nan\n12,90210,2.50,false,\n",
+		"users.json": `[{"id": 1234567.0, "name": "big"}, {"id": 0.5, "name": "half"}]`,
+		"empty.csv":  "id\n",
+		"users.txt":  "id\n1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type generated struct {
+		name     string
+		path     string
+		expected interface{}
+	}
+	tests := []struct {
+		name  string
+		test  types.TestCase
+		want  []generated
+		error string
+	}{
+		{
+			name: "inline rows keep their types",
+			test: types.TestCase{
+				Name: "get user", Path: "/users/{{id}}",
+				Data: &types.TestData{Rows: []map[string]interface{}{{"id": 1, "status": 200}, {"id": 2, "status": 404}}},
+			},
+			want: []generated{
+				{"get user [id=1, status=200]", "/users/1", 200},
+				{"get user [id=2, status=404]", "/users/2", 404},
+			},
+		},
+		{
+			name: "placeholders in the name replace the row label",
+			test: types.TestCase{
+				Name: "user {{id}}", Path: "/users/{{id}}",
+				Data: &types.TestData{Rows: []map[string]interface{}{{"id": "a", "status": 200}}},
+			},
+			want: []generated{{"user a", "/users/a", 200}},
+		},
+		{
+			name: "matrix crosses rows",
+			test: types.TestCase{
+				Name: "list", Path: "/{{locale}}/users?page={{page}}",
+				Data: &types.TestData{
+					Rows:   []map[string]interface{}{{"status": 200}},
+					Matrix: map[string][]interface{}{"page": {1, 2}, "locale": {"en", "de"}},
+				},
+			},
+			want: []generated{
+				{"list [status=200, locale=en, page=1]", "/en/users?page=1", 200},
+				{"list [status=200, locale=en, page=2]", "/en/users?page=2", 200},
+				{"list [status=200, locale=de, page=1]", "/de/users?page=1", 200},
+				{"list [status=200, locale=de, page=2]", "/de/users?page=2", 200},
+			},
+		},
+		{
+			name: "CSV cells are substituted as written",
+			test: types.TestCase{
+				Name: "csv", Path: "/{{id}}/{{zip}}/{{ratio}}/{{note}}",
+				Data: &types.TestData{File: "users.csv"},
+			},
+			want: []generated{
+				{"csv [id=7, zip=02134, ratio=1.5, flag=true, note=nan]", "/7/02134/1.5/nan", "{{status}}"},
+				{"csv [id=12, zip=90210, ratio=2.50, flag=false, note=]", "/12/90210/2.50/", "{{status}}"},
+			},
+		},
+		{
+			name: "JSON floats are written out in full",
+			test: types.TestCase{
+				Name: "json", Path: "/users/{{id}}",
+				Data: &types.TestData{File: filepath.Join(dir, "users.json")},
+			},
+			want: []generated{
+				{"json [id=1234567, name=big]", "/users/1234567", "{{status}}"},
+				{"json [id=0.5, name=half]", "/users/0.5", "{{status}}"},
+			},
+		},
+		{
+			name:  "no rows",
+			test:  types.TestCase{Name: "empty", Path: "/", Data: &types.TestData{File: "empty.csv"}},
+			error: "test 'empty': data block produced no rows",
+		},
+		{
+			name:  "unsupported file",
+			test:  types.TestCase{Name: "text", Path: "/", Data: &types.TestData{File: "users.txt"}},
+			error: "test 'text': unsupported data file " + filepath.Join(dir, "users.txt") + " (expected .csv or .json)",
+		},
+		{
+			name:  "missing file",
+			test:  types.TestCase{Name: "missing", Path: "/", Data: &types.TestData{File: "missing.csv"}},
+			error: "test 'missing': failed to read data file " + filepath.Join(dir, "missing.csv"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := tt.test
+			test.Assertions = []types.Assertion{{Type: "status", Expected: "{{status}}"}}
+			expanded, err := ExpandDataTests([]types.TestCase{test}, dir)
+			if tt.error != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.error) {
+					t.Fatalf("ExpandDataTests() error = %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandDataTests() error = %v", err)
+			}
+
+			var got []generated
+			for _, test := range expanded {
+				if test.Data != nil {
+					t.Errorf("%s: data block was kept", test.Name)
+				}
+				got = append(got, generated{test.Name, test.Path, test.Assertions[0].Expected})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandDataTests() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDataTestsDependencies(t *testing.T) {
+	tests := []types.TestCase{
+		{Name: "login", Path: "/login"},
+		{Name: "get", Path: "/items/{{id}}", DependsOn: []string{"login"}, Data: &types.TestData{Rows: []map[string]interface{}{{"id": 1}, {"id": 2}}}},
+		{Name: "report", Path: "/report", DependsOn: []string{"get", "login"}},
+	}
+	expanded, err := ExpandDataTests(tests, ".")
+	if err != nil {
+		t.Fatalf("ExpandDataTests() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"login":      nil,
+		"get [id=1]": {"login"},
+		"get [id=2]": {"login"},
+		"report":     {"get [id=1]", "get [id=2]", "login"},
+	}
+	if len(expanded) != len(want) {
+		t.Fatalf("ExpandDataTests() returned %d tests, want %d", len(expanded), len(want))
+	}
+	for _, test := range expanded {
+		if !reflect.DeepEqual(test.DependsOn, want[test.Name]) {
+			t.Errorf("%s depends on %v, want %v", test.Name, test.DependsOn, want[test.Name])
+		}
+	}
+}
+
+func TestExpandDataTestsRequests(t *testing.T) {
+	test := types.TestCase{
+		Name:    "create",
+		Path:    "/graphql",
+		Headers: map[string]string{"X-Id": "{{id}}"},
+		Body:    `{"id": {{id}}}`,
+		GraphQL: &types.GraphQLRequest{
+			Query:     "query($id: ID!) { user(id: $id) { name } }",
+			Variables: map[string]interface{}{"id": "{{id}}", "name": "user {{id}}"},
+		},
+		MockResponse: &types.MockResponse{Status: 200, Body: `{"id": {{id}}}`},
+		Data:         &types.TestData{Rows: []map[string]interface{}{{"id": 3}}},
+	}
+	expanded, err := ExpandDataTests([]types.TestCase{test}, ".")
+	if err != nil {
+		t.Fatalf("ExpandDataTests() error = %v", err)
+	}
+	got := expanded[0]
+
+	if got.Headers["X-Id"] != "3" || got.Body != `{"id": 3}` || got.MockResponse.Body != `{"id": 3}` {
+		t.Errorf("headers %v, body %q, mock body %q", got.Headers, got.Body, got.MockResponse.Body)
+	}
+	if want := map[string]interface{}{"id": 3, "name": "user 3"}; !reflect.DeepEqual(got.GraphQL.Variables, want) {
+		t.Errorf("variables = %v, want %v", got.GraphQL.Variables, want)
+	}
+	// The original test is left untouched
+	if test.Headers["X-Id"] != "{{id}}" || test.GraphQL.Variables["id"] != "{{id}}" {
+		t.Errorf("original test was modified: %v %v", test.Headers, test.GraphQL.Variables)
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/condition"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// ValidateDependencies checks that every depends_on entry names an existing test,
+// that `when` conditions parse, and that the dependency graph has no cycles
+func ValidateDependencies(tests []types.TestCase) Diagnostics {
+	v := newValidator()
+	v.validateDependencies(tests)
+	return v.diagnostics
+}
+
+func (v *validator) validateDependencies(tests []types.TestCase) {
+	byName := make(map[string]types.TestCase, len(tests))
+	for _, test := range tests {
+		byName[test.Name] = test
+	}
+
+	for _, test := range tests {
+		for _, dep := range test.DependsOn {
+			if dep == test.Name {
+				v.errorf(v.fieldPosition(test.Source, "depends_on"), "test '%s': cannot depend on itself", test.Name)
+			} else if _, ok := byName[dep]; !ok {
+				v.errorf(v.fieldPosition(test.Source, "depends_on"), "test '%s': depends_on references unknown test '%s'", test.Name, dep)
+			}
+		}
+		if test.When != "" {
+			if _, err := condition.Parse(test.When); err != nil {
+				v.errorf(v.fieldPosition(test.Source, "when"), "test '%s': invalid when expression: %v", test.Name, err)
+			}
+		}
+	}
+
+	// Depth-first search, tracking the current path to report the cycle
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(tests))
+	var path []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			v.errorf(v.fieldPosition(byName[name].Source, "depends_on"), "dependency cycle detected: %s", strings.Join(cycle, " -> "))
+			return false
+		case done:
+			return true
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range byName[name].DependsOn {
+			if _, ok := byName[dep]; ok && dep != name && !visit(dep) {
+				return false
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return true
+	}
+
+	// Report only the first cycle; the rest of the graph is unreliable after that
+	for _, test := range tests {
+		if !visit(test.Name) {
+			return
+		}
+	}
+}
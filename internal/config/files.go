@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Option changes how a suite is loaded and validated
+type Option func(*validator)
+
+// WithoutFiles rejects suites that name files to read: includes, data files,
+// secret files, GraphQL schema files and proto files. The server uses it for suites
+// submitted over the API, which must not read the server's own files.
+func WithoutFiles() Option {
+	return func(v *validator) {
+		v.noFiles = true
+	}
+}
+
+func newValidatorWith(options []Option) *validator {
+	v := newValidator()
+	for _, option := range options {
+		option(v)
+	}
+	return v
+}
+
+// fileReference is a field of a suite that names a file to read
+type fileReference struct {
+	pos   types.Position
+	label string
+}
+
+// FileReferences describes every field of suite that names a file to read, e.g.
+// "test 'users': data.file". Includes are not listed; they are resolved while
+// loading, and template fields count once tests inherit them.
+func FileReferences(suite *types.TestSuite) []string {
+	var descriptions []string
+	for _, reference := range newValidator().fileReferences(suite, types.Position{}) {
+		descriptions = append(descriptions, reference.label)
+	}
+	return descriptions
+}
+
+func (v *validator) fileReferences(suite *types.TestSuite, root types.Position) []fileReference {
+	var references []fileReference
+	names := make([]string, 0, len(suite.Secrets))
+	for name, secret := range suite.Secrets {
+		if secret.File != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		references = append(references, fileReference{v.fieldPosition(root, "secrets"), fmt.Sprintf("secret '%s': file", name)})
+	}
+
+	for _, test := range suite.Tests {
+		if test.Data != nil && test.Data.File != "" {
+			references = append(references, fileReference{v.fieldPosition(test.Source, "data"), fmt.Sprintf("test '%s': data.file", test.Name)})
+		}
+		if test.GraphQL != nil && test.GraphQL.Schema != "" && !isSchemaURL(test.GraphQL.Schema) {
+			references = append(references, fileReference{v.fieldPosition(test.Source, "graphql"), fmt.Sprintf("test '%s': graphql.schema", test.Name)})
+		}
+		if test.GRPC != nil && len(test.GRPC.ProtoFiles)+len(test.GRPC.ImportPaths) > 0 {
+			references = append(references, fileReference{v.fieldPosition(test.Source, "grpc"), fmt.Sprintf("test '%s': grpc.proto_files", test.Name)})
+		}
+	}
+	return references
+}
+
+// rejectFiles reports every file reference when files are not allowed
+func (v *validator) rejectFiles(suite *types.TestSuite, root types.Position) {
+	if !v.noFiles {
+		return
+	}
+	for _, reference := range v.fileReferences(suite, root) {
+		v.errorf(reference.pos, "%s: files cannot be read by suites submitted to the server", reference.label)
+	}
+}
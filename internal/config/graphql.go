@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/graphql"
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// defaultGraphQLMethods makes GraphQL tests without a method POST their operation
+func defaultGraphQLMethods(tests []types.TestCase) {
+	for i := range tests {
+		if tests[i].GraphQL != nil && tests[i].Method == "" {
+			tests[i].Method = http.MethodPost
+		}
+	}
+}
+
+// WithSchemaFetch lets GraphQL tests name their schema by URL. The endpoint is
+// introspected through client while the suite loads, once per URL and within
+// timeout. Without this option such suites are rejected, so loading a suite
+// never contacts a server unless the caller asks for it.
+func WithSchemaFetch(client *http.Client, timeout time.Duration) Option {
+	return func(v *validator) {
+		v.schemaClient = client
+		v.schemaTimeout = timeout
+	}
+}
+
+// isSchemaURL reports whether a graphql schema names an endpoint rather than a file
+func isSchemaURL(schema string) bool {
+	return strings.HasPrefix(schema, "http://") || strings.HasPrefix(schema, "https://")
+}
+
+// validateGraphQL parses the query of every GraphQL test and, when the test
+// names a schema, validates the query against it. Schema files are resolved
+// against the directory of the test's suite file; schema URLs are introspected
+// when allowed by WithSchemaFetch. Each schema is loaded once.
+func (v *validator) validateGraphQL(tests []types.TestCase, baseDir string) {
+	schemas := make(map[string]*graphql.Schema)
+	failed := make(map[string]bool)
+
+	for _, test := range tests {
+		request := test.GraphQL
+		if request == nil || request.Query == "" || vars.HasPlaceholders(request.Query) {
+			continue
+		}
+		pos := v.fieldPosition(test.Source, "graphql")
+
+		document, err := graphql.Parse(request.Query)
+		if err != nil {
+			v.errorf(pos, "test '%s': graphql query: %v", test.Name, err)
+			continue
+		}
+
+		var schema *graphql.Schema
+		if request.Schema != "" {
+			location := request.Schema
+			if isSchemaURL(location) && v.schemaClient == nil {
+				v.errorf(pos, "test '%s': graphql schema %s is a URL, which is only introspected when schema fetching is enabled (or save it with: comapi graphql introspect %s -o schema.json)", test.Name, location, location)
+				continue
+			}
+			if !isSchemaURL(location) && !filepath.IsAbs(location) {
+				location = filepath.Join(v.fileDir(test.Source, baseDir), location)
+			}
+			if failed[location] {
+				continue
+			}
+			if schema = schemas[location]; schema == nil {
+				if schema, err = v.loadSchema(location); err != nil {
+					v.errorf(pos, "test '%s': graphql schema: %v", test.Name, err)
+					failed[location] = true
+					continue
+				}
+				schemas[location] = schema
+			}
+		}
+
+		for _, err := range graphql.Validate(document, request.OperationName, request.Variables, schema) {
+			v.errorf(pos, "test '%s': graphql query: %v", test.Name, err)
+		}
+	}
+}
+
+// loadSchema reads a schema file or introspects a schema URL
+func (v *validator) loadSchema(location string) (*graphql.Schema, error) {
+	if !isSchemaURL(location) {
+		return graphql.LoadSchema(location)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), v.schemaTimeout)
+	defer cancel()
+	data, err := graphql.Introspect(ctx, v.schemaClient, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	return graphql.ParseSchema(data)
+}
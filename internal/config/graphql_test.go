@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const graphqlSchema = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "user", "args": [], "type": {"kind": "OBJECT", "name": "User", "ofType": null}}
+    ]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}}
+    ]},
+    {"kind": "SCALAR", "name": "String"}
+  ]
+}}}`
+
+func TestValidateGraphQLSchema(t *testing.T) {
+	var introspections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections.Add(1)
+		switch r.URL.Path {
+		case "/graphql":
+			w.Write([]byte(graphqlSchema))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(graphqlSchema))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(graphqlSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fetch := WithSchemaFetch(server.Client(), time.Second)
+
+	tests := []struct {
+		name           string
+		schema         string
+		query          string
+		options        []Option
+		error          string
+		introspections int32
+	}{
+		{name: "file", schema: "schema.json", query: "{ user { name } }"},
+		{name: "file rejects unknown fields", schema: "schema.json", query: "{ user { email } }", error: "type User has no field 'email'"},
+		{
+			name: "url needs fetching enabled", schema: server.URL + "/graphql", query: "{ user { name } }",
+			error: "is a URL, which is only introspected when schema fetching is enabled",
+		},
+		{name: "url", schema: server.URL + "/graphql", query: "{ user { name } }", options: []Option{fetch}, introspections: 1},
+		{
+			name: "url rejects unknown fields", schema: server.URL + "/graphql", query: "{ user { email } }",
+			options: []Option{fetch}, error: "type User has no field 'email'", introspections: 1,
+		},
+		{
+			name: "url that fails", schema: server.URL + "/missing", query: "{ user { name } }",
+			options: []Option{fetch}, error: "introspection failed with HTTP 404", introspections: 1,
+		},
+		{
+			name: "url that times out", schema: server.URL + "/slow", query: "{ user { name } }",
+			options: []Option{WithSchemaFetch(server.Client(), 50*time.Millisecond)}, error: "context deadline exceeded", introspections: 1,
+		},
+		{
+			name: "url is not a file", schema: server.URL + "/graphql", query: "{ user { name } }",
+			options: []Option{WithoutFiles(), fetch}, introspections: 1,
+		},
+		{
+			name: "file is rejected without files", schema: "schema.json", query: "{ user { name } }",
+			options: []Option{WithoutFiles(), fetch}, error: "files cannot be read",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			introspections.Store(0)
+			// Two tests share the schema, which is loaded once
+			suite := fmt.Sprintf(`name: graphql
+base_url: http://example.com
+tests:
+  - name: first
+    path: /graphql
+    graphql: {query: "%[1]s", schema: "%[2]s"}
+    assertions: [{type: graphql_errors}]
+  - name: second
+    path: /graphql
+    graphql: {query: "%[1]s", schema: "%[2]s"}
+    assertions: [{type: graphql_errors}]
+`, tt.query, tt.schema)
+			path := filepath.Join(dir, "suite.yaml")
+			if err := os.WriteFile(path, []byte(suite), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, diagnostics := ValidateFile(path, tt.options...)
+			messages := strings.Join(diagnostics.Messages(), "\n")
+			if tt.error == "" && diagnostics.HasErrors() {
+				t.Fatalf("unexpected errors:\n%s", messages)
+			}
+			if tt.error != "" && !strings.Contains(messages, tt.error) {
+				t.Fatalf("errors do not mention %q:\n%s", tt.error, messages)
+			}
+			if got := introspections.Load(); got != tt.introspections {
+				t.Errorf("introspected %d times, want %d", got, tt.introspections)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/grpc"
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// defaultGRPCTests gives gRPC tests their POST method and /service/method path,
+// and turns status names such as NOT_FOUND in their status assertions into codes
+func defaultGRPCTests(tests []types.TestCase) {
+	for i := range tests {
+		request := tests[i].GRPC
+		if request == nil {
+			continue
+		}
+		if tests[i].Method == "" {
+			tests[i].Method = http.MethodPost
+		}
+		if tests[i].Path == "" && request.Service != "" && request.Method != "" {
+			tests[i].Path = "/" + request.Service + "/" + request.Method
+		}
+
+		assertions := make([]types.Assertion, len(tests[i].Assertions))
+		for j, assertion := range tests[i].Assertions {
+			if name, ok := assertion.Expected.(string); ok && assertion.Type == "status" {
+				if code, ok := grpc.ParseCode(name); ok {
+					assertion.Expected = code
+				}
+			}
+			assertions[j] = assertion
+		}
+		tests[i].Assertions = assertions
+	}
+}
+
+// validateGRPCRequest checks the fields of a test's gRPC call
+func (v *validator) validateGRPCRequest(label string, test types.TestCase) {
+	request := test.GRPC
+	pos := v.fieldPosition(test.Source, "grpc")
+	if strings.TrimSpace(request.Service) == "" {
+		v.errorf(pos, "%s: grpc service is required", label)
+	}
+	if strings.TrimSpace(request.Method) == "" {
+		v.errorf(pos, "%s: grpc method is required", label)
+	}
+	if test.GraphQL != nil {
+		v.errorf(pos, "%s: grpc cannot be combined with graphql", label)
+	}
+	if test.Body != "" {
+		v.errorf(v.fieldPosition(test.Source, "body"), "%s: body cannot be combined with grpc; use grpc.message", label)
+	}
+	if test.Method != "" && !strings.EqualFold(test.Method, http.MethodPost) {
+		v.errorf(v.fieldPosition(test.Source, "method"), "%s: gRPC calls are always POST, not %s", label, test.Method)
+	}
+	if path := "/" + request.Service + "/" + request.Method; test.Path != "" && test.Path != path && request.Service != "" && request.Method != "" {
+		v.errorf(v.fieldPosition(test.Source, "path"), "%s: path of a grpc test is %s; set the server address with base_url or url", label, path)
+	}
+	switch request.Message.(type) {
+	case nil, string, map[string]interface{}:
+	default:
+		v.errorf(pos, "%s: grpc message must be a mapping or JSON text", label)
+	}
+}
+
+// validateGRPC resolves the proto files and import paths of gRPC tests against
+// the directory of their suite file and, for tests that give proto files, checks that the method exists
+// and the message fits its input type. Files are parsed once per set.
+func (v *validator) validateGRPC(tests []types.TestCase, baseDir string) {
+	parsed := make(map[string]*protoregistry.Files)
+	failed := make(map[string]bool)
+
+	for i := range tests {
+		if tests[i].GRPC == nil {
+			continue
+		}
+		request := *tests[i].GRPC
+		dir := v.fileDir(tests[i].Source, baseDir)
+		request.ProtoFiles = resolvePaths(request.ProtoFiles, dir)
+		request.ImportPaths = resolvePaths(request.ImportPaths, dir)
+		tests[i].GRPC = &request
+		if len(request.ProtoFiles) == 0 || request.Service == "" || request.Method == "" {
+			continue
+		}
+
+		pos := v.fieldPosition(tests[i].Source, "grpc")
+		key := strings.Join(request.ProtoFiles, "\x00") + "|" + strings.Join(request.ImportPaths, "\x00")
+		if failed[key] {
+			continue
+		}
+		files := parsed[key]
+		if files == nil {
+			var err error
+			if files, err = grpc.ParseProtoFiles(request.ProtoFiles, request.ImportPaths); err != nil {
+				v.errorf(pos, "test '%s': grpc proto_files: %v", tests[i].Name, err)
+				failed[key] = true
+				continue
+			}
+			parsed[key] = files
+		}
+
+		method, err := grpc.FindMethod(files, request.Service, request.Method)
+		if err != nil {
+			v.errorf(pos, "test '%s': grpc: %v", tests[i].Name, err)
+			continue
+		}
+		if hasMessagePlaceholders(request.Message) {
+			continue
+		}
+		if _, err := grpc.NewMessage(method, files, request.Message); err != nil {
+			v.errorf(pos, "test '%s': grpc: %v", tests[i].Name, err)
+		}
+	}
+}
+
+// resolvePaths makes relative paths relative to baseDir
+func resolvePaths(paths []string, baseDir string) []string {
+	if len(paths) == 0 {
+		return paths
+	}
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		if !filepath.IsAbs(path) && !vars.HasPlaceholders(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		resolved[i] = path
+	}
+	return resolved
+}
+
+// hasMessagePlaceholders reports whether a message still holds {{NAME}}
+// placeholders, e.g. for secrets filled in at run time
+func hasMessagePlaceholders(message interface{}) bool {
+	if text, ok := message.(string); ok {
+		return vars.HasPlaceholders(text)
+	}
+	encoded, err := json.Marshal(message)
+	return err != nil || vars.HasPlaceholders(string(encoded))
+}
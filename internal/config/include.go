@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// mergeSuite merges src into dst. Scalar settings and named definitions from src
+// override dst, headers, environment and secrets are merged key by key, and tests,
+// webhooks and redaction rules are appended.
+func mergeSuite(dst, src *types.TestSuite) {
+	if src.Name != "" {
+		dst.Name = src.Name
+	}
+	if src.BaseURL != "" {
+		dst.BaseURL = src.BaseURL
+	}
+
+	dst.Headers = mergeStringMaps(dst.Headers, src.Headers)
+	dst.Environment = mergeStringMaps(dst.Environment, src.Environment)
+
+	if len(src.Templates) > 0 && dst.Templates == nil {
+		dst.Templates = make(map[string]types.TestCase, len(src.Templates))
+	}
+	for name, template := range src.Templates {
+		dst.Templates[name] = template
+	}
+
+	if len(src.AssertionGroups) > 0 && dst.AssertionGroups == nil {
+		dst.AssertionGroups = make(map[string][]types.Assertion, len(src.AssertionGroups))
+	}
+	for name, group := range src.AssertionGroups {
+		dst.AssertionGroups[name] = group
+	}
+
+	dst.Tests = append(dst.Tests, src.Tests...)
+	dst.Webhooks = append(dst.Webhooks, src.Webhooks...)
+
+	if len(src.Secrets) > 0 && dst.Secrets == nil {
+		dst.Secrets = make(map[string]types.Secret, len(src.Secrets))
+	}
+	for name, secret := range src.Secrets {
+		dst.Secrets[name] = secret
+	}
+
+	if src.Redact != nil {
+		if dst.Redact == nil {
+			dst.Redact = &types.Redaction{}
+		}
+		dst.Redact.Headers = append(dst.Redact.Headers, src.Redact.Headers...)
+		dst.Redact.JSONPaths = append(dst.Redact.JSONPaths, src.Redact.JSONPaths...)
+	}
+}
+
+// mergeStringMaps returns base overlaid with override
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// ResolveExtends applies the templates and assertion groups each test extends.
+// Templates may themselves extend other templates or assertion groups.
+func ResolveExtends(suite *types.TestSuite) Diagnostics {
+	v := newValidator()
+	v.resolveExtends(suite)
+	return v.diagnostics
+}
+
+func (v *validator) resolveExtends(suite *types.TestSuite) {
+	resolved := make(map[string]types.TestCase, len(suite.Templates))
+
+	var resolveTemplate func(name string, chain []string) (types.TestCase, error)
+	resolveTemplate = func(name string, chain []string) (types.TestCase, error) {
+		if template, ok := resolved[name]; ok {
+			return template, nil
+		}
+		for _, seen := range chain {
+			if seen == name {
+				return types.TestCase{}, fmt.Errorf("template cycle detected: %s", strings.Join(append(chain, name), " -> "))
+			}
+		}
+
+		template := suite.Templates[name]
+		template, err := applyExtends(suite, template, func(parent string) (types.TestCase, error) {
+			return resolveTemplate(parent, append(chain, name))
+		})
+		if err != nil {
+			return types.TestCase{}, fmt.Errorf("template '%s': %w", name, err)
+		}
+		resolved[name] = template
+		return template, nil
+	}
+
+	for i, test := range suite.Tests {
+		if len(test.Extends) == 0 {
+			continue
+		}
+		test, err := applyExtends(suite, test, func(parent string) (types.TestCase, error) {
+			return resolveTemplate(parent, nil)
+		})
+		if err != nil {
+			v.errorf(v.fieldPosition(test.Source, "extends"), "test '%s': %v", test.Name, err)
+			continue
+		}
+		suite.Tests[i] = test
+	}
+}
+
+// applyExtends merges every parent named in test.Extends into the test, in order
+func applyExtends(suite *types.TestSuite, test types.TestCase, template func(name string) (types.TestCase, error)) (types.TestCase, error) {
+	var inherited []types.Assertion
+
+	for _, parent := range test.Extends {
+		if _, ok := suite.Templates[parent]; ok {
+			base, err := template(parent)
+			if err != nil {
+				return test, err
+			}
+			test = inheritTemplate(test, base)
+			inherited = append(inherited, base.Assertions...)
+			continue
+		}
+
+		if group, ok := suite.AssertionGroups[parent]; ok {
+			inherited = append(inherited, group...)
+			continue
+		}
+
+		return test, fmt.Errorf("extends unknown template or assertion group '%s'", parent)
+	}
+
+	test.Assertions = append(inherited, test.Assertions...)
+	test.Extends = nil
+	return test, nil
+}
+
+// inheritTemplate fills in the fields the test leaves empty from the template.
+// Assertions are handled by the caller so that their order follows extends.
+func inheritTemplate(test, base types.TestCase) types.TestCase {
+	if test.Description == "" {
+		test.Description = base.Description
+	}
+	if test.Method == "" {
+		test.Method = base.Method
+	}
+	if test.Path == "" {
+		test.Path = base.Path
+	}
+	if test.URL == "" {
+		test.URL = base.URL
+	}
+	if test.Body == "" {
+		test.Body = base.Body
+	}
+	if test.When == "" {
+		test.When = base.When
+	}
+	if test.Data == nil {
+		test.Data = base.Data
+	}
+	if test.MockResponse == nil {
+		test.MockResponse = base.MockResponse
+	}
+	test.GraphQL = inheritGraphQL(test.GraphQL, base.GraphQL)
+	test.GRPC = inheritGRPC(test.GRPC, base.GRPC)
+
+	test.Headers = mergeStringMaps(base.Headers, test.Headers)
+	test.DependsOn = append(append([]string{}, base.DependsOn...), test.DependsOn...)
+
+	return test
+}
+
+// inheritGraphQL fills in the GraphQL fields the test leaves empty from the
+// template, e.g. a shared schema. Variables are merged key by key.
+func inheritGraphQL(request, base *types.GraphQLRequest) *types.GraphQLRequest {
+	if base == nil {
+		return request
+	}
+	if request == nil {
+		return base
+	}
+
+	merged := *request
+	if merged.Query == "" {
+		merged.Query = base.Query
+	}
+	if merged.OperationName == "" {
+		merged.OperationName = base.OperationName
+	}
+	if merged.Schema == "" {
+		merged.Schema = base.Schema
+	}
+	if len(base.Variables) > 0 {
+		merged.Variables = make(map[string]interface{}, len(base.Variables)+len(request.Variables))
+		for name, value := range base.Variables {
+			merged.Variables[name] = value
+		}
+		for name, value := range request.Variables {
+			merged.Variables[name] = value
+		}
+	}
+	return &merged
+}
+
+// inheritGRPC fills in the gRPC fields the test leaves empty from the
+// template, e.g. a shared service and proto files. Metadata is merged key by key.
+func inheritGRPC(request, base *types.GRPCRequest) *types.GRPCRequest {
+	if base == nil {
+		return request
+	}
+	if request == nil {
+		return base
+	}
+
+	merged := *request
+	if merged.Service == "" {
+		merged.Service = base.Service
+	}
+	if merged.Method == "" {
+		merged.Method = base.Method
+	}
+	if merged.Message == nil {
+		merged.Message = base.Message
+	}
+	if len(merged.ProtoFiles) == 0 {
+		merged.ProtoFiles = base.ProtoFiles
+	}
+	if len(merged.ImportPaths) == 0 {
+		merged.ImportPaths = base.ImportPaths
+	}
+	merged.Metadata = mergeStringMaps(base.Metadata, request.Metadata)
+	return &merged
+}
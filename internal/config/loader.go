@@ -3,89 +3,259 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 
 	"github.com/Asadus16/comapi/pkg/types"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-// LoadTestSuite reads and parses a YAML test configuration file
-func LoadTestSuite(filename string) (*types.TestSuite, error) {
-	// Read the file
-	data, err := os.ReadFile(filename)
+// LoadTestSuite reads and parses a YAML test configuration file, resolving
+// includes, templates and data-driven tests. If the suite is invalid the
+// returned error is a Diagnostics value listing every problem.
+func LoadTestSuite(filename string, options ...Option) (*types.TestSuite, error) {
+	suite, diagnostics := ValidateFile(filename, options...)
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	return suite, nil
+}
+
+// ValidateFile loads a suite file and reports every problem found, with file,
+// line and column. The suite is only usable when the diagnostics hold no errors.
+func ValidateFile(filename string, options ...Option) (*types.TestSuite, Diagnostics) {
+	v := newValidatorWith(options)
+	suite, root, err := v.loadSuiteFile(filename, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+		v.errorf(types.Position{File: filename}, "%v", err)
+		return nil, v.diagnostics
 	}
 
-	// Parse YAML into our TestSuite struct
-	var suite types.TestSuite
-	err = yaml.Unmarshal(data, &suite)
+	v.finish(suite, root, filepath.Dir(filename))
+	return suite, v.diagnostics
+}
+
+// ValidateDocument validates a suite given as YAML or JSON text. name is used as
+// the file name in diagnostics. Includes are not allowed because the document
+// has no location on disk.
+func ValidateDocument(data []byte, name string, options ...Option) (*types.TestSuite, Diagnostics) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, Diagnostics{{Position: types.Position{File: name}, Severity: SeverityError, Message: fmt.Sprintf("failed to parse YAML in %s: %v", name, err)}}
+	}
+	if len(document.Content) == 0 {
+		return nil, Diagnostics{{Position: types.Position{File: name}, Severity: SeverityError, Message: "document is empty"}}
+	}
+	return ValidateNode(document.Content[0], name, options...)
+}
+
+// ValidateNode validates a suite held in an already parsed YAML node, e.g. one
+// field of a larger JSON request body
+func ValidateNode(node *yaml.Node, name string, options ...Option) (*types.TestSuite, Diagnostics) {
+	v := newValidatorWith(options)
+	suite, root, err := v.decodeSuite(node, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		v.errorf(types.Position{File: name}, "%v", err)
+		return nil, v.diagnostics
+	}
+	if len(suite.Include) > 0 {
+		v.errorf(v.fieldPosition(root, "include"), "include is only supported for suite files")
 	}
 
-	// Basic validation
-	if suite.Name == "" {
-		return nil, fmt.Errorf("test suite name is required")
+	v.finish(suite, root, ".")
+	return suite, v.diagnostics
+}
+
+// PrepareSuite validates a suite built in code and resolves its templates, data
+// rows and dependencies in place. Relative files are resolved against baseDir.
+// Suites that were loaded or prepared before are left as they are.
+func PrepareSuite(suite *types.TestSuite, baseDir string) Diagnostics {
+	if suite.Prepared {
+		return nil
 	}
-	
-	if suite.BaseURL == "" {
-		return nil, fmt.Errorf("base_url is required")
+	v := newValidator()
+	v.finish(suite, types.Position{}, baseDir)
+	return v.diagnostics
+}
+
+// finish resolves templates and data rows and runs the semantic checks
+func (v *validator) finish(suite *types.TestSuite, root types.Position, baseDir string) {
+	v.resolveExtends(suite)
+	v.rejectFiles(suite, root)
+	defaultGraphQLMethods(suite.Tests)
+	defaultGRPCTests(suite.Tests)
+	v.validateSuite(suite, root)
+	if v.diagnostics.HasErrors() {
+		return
 	}
-	
-	if len(suite.Tests) == 0 {
-		return nil, fmt.Errorf("at least one test is required")
+
+	// Expand data-driven tests into one case per parameter row
+	expanded, err := expandDataTests(suite.Tests, func(test types.TestCase) string {
+		return v.fileDir(test.Source, baseDir)
+	})
+	if err != nil {
+		v.errorf(root, "%v", err)
+		return
 	}
+	suite.Tests = expanded
 
-	// Validate each test case
-	for i, test := range suite.Tests {
-		if test.Name == "" {
-			return nil, fmt.Errorf("test %d: name is required", i+1)
-		}
-		if test.Method == "" {
-			return nil, fmt.Errorf("test '%s': method is required", test.Name)
+	// Validate depends_on references, when expressions and dependency cycles
+	v.validateDependencies(suite.Tests)
+
+	// Parse GraphQL queries now that data rows are filled in, checking them
+	// against their schema when one is given
+	v.validateGraphQL(suite.Tests, baseDir)
+
+	// Resolve proto files and check gRPC calls against them
+	v.validateGRPC(suite.Tests, baseDir)
+
+	suite.Prepared = !v.diagnostics.HasErrors()
+}
+
+// loadSuiteFile parses a single suite file and merges in everything it includes.
+// stack holds the files currently being loaded so include cycles can be reported.
+func (v *validator) loadSuiteFile(filename string, stack []string) (*types.TestSuite, types.Position, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = filename
+	}
+	for _, open := range stack {
+		if open == absPath {
+			return nil, types.Position{}, fmt.Errorf("include cycle detected: %s includes %s again", stack[len(stack)-1], filename)
 		}
-		if test.Path == "" {
-			return nil, fmt.Errorf("test '%s': path is required", test.Name)
+	}
+	stack = append(stack, absPath)
+
+	// Read the file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, types.Position{}, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	suite, root, err := v.parseSuite(data, filename)
+	if err != nil {
+		return nil, root, err
+	}
+	v.files[filename] = true
+	for name, secret := range suite.Secrets {
+		secret.Dir = filepath.Dir(filename)
+		suite.Secrets[name] = secret
+	}
+
+	if v.noFiles && len(suite.Include) > 0 {
+		return nil, root, fmt.Errorf("include: files cannot be read by suites submitted to the server")
+	}
+
+	// Merge included files; definitions in this file take precedence
+	merged := &types.TestSuite{}
+	for _, include := range suite.Include {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(filename), includePath)
 		}
-		if len(test.Assertions) == 0 {
-			return nil, fmt.Errorf("test '%s': at least one assertion is required", test.Name)
+		included, _, err := v.loadSuiteFile(includePath, stack)
+		if err != nil {
+			return nil, root, fmt.Errorf("include '%s': %w", include, err)
 		}
+		mergeSuite(merged, included)
 	}
+	mergeSuite(merged, suite)
+	merged.Include = suite.Include
 
-	return &suite, nil
+	return merged, root, nil
 }
 
-// ValidateAssertion checks if an assertion is properly formatted
-func ValidateAssertion(assertion types.Assertion) error {
-	switch assertion.Type {
-	case "status":
-		if assertion.Expected == nil {
-			return fmt.Errorf("status assertion requires 'expected' field")
-		}
-	case "json_path":
-		if assertion.Target == "" {
-			return fmt.Errorf("json_path assertion requires 'target' field")
-		}
-		if assertion.Expected == nil {
-			return fmt.Errorf("json_path assertion requires 'expected' field")
+// parseSuite parses YAML (or JSON) text into a suite
+func (v *validator) parseSuite(data []byte, filename string) (*types.TestSuite, types.Position, error) {
+	// Parse YAML into a node tree first so we know where everything was defined
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, types.Position{}, fmt.Errorf("failed to parse YAML in %s: %w", filename, err)
+	}
+	if len(document.Content) == 0 {
+		return &types.TestSuite{}, types.Position{File: filename}, nil
+	}
+	return v.decodeSuite(document.Content[0], filename)
+}
+
+// decodeSuite decodes a suite mapping node, checking for unknown fields and
+// recording where every test and assertion was defined
+func (v *validator) decodeSuite(node *yaml.Node, filename string) (*types.TestSuite, types.Position, error) {
+	root := nodePosition(filename, node)
+	v.nodes[root] = node
+
+	var suite types.TestSuite
+	if err := node.Decode(&suite); err != nil {
+		return nil, root, fmt.Errorf("failed to parse YAML in %s: %w", filename, err)
+	}
+
+	v.checkUnknownFields(filename, node, reflect.TypeOf(suite), "")
+	v.recordPositions(&suite, node, filename)
+	return &suite, root, nil
+}
+
+// recordPositions stores the file and line of every test, template and assertion
+func (v *validator) recordPositions(suite *types.TestSuite, root *yaml.Node, filename string) {
+	if tests := mappingValue(root, "tests"); tests != nil && tests.Kind == yaml.SequenceNode {
+		for i, node := range tests.Content {
+			if i < len(suite.Tests) {
+				suite.Tests[i].Source = v.position(filename, node)
+				v.recordAssertions(suite.Tests[i].Assertions, mappingValue(node, "assertions"), filename)
+			}
 		}
-	case "header":
-		if assertion.Target == "" {
-			return fmt.Errorf("header assertion requires 'target' field (header name)")
+	}
+
+	if templates := mappingValue(root, "templates"); templates != nil && templates.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(templates.Content); i += 2 {
+			name, node := templates.Content[i].Value, templates.Content[i+1]
+			if template, ok := suite.Templates[name]; ok {
+				template.Source = v.position(filename, node)
+				v.recordAssertions(template.Assertions, mappingValue(node, "assertions"), filename)
+				suite.Templates[name] = template
+			}
 		}
-		if assertion.Expected == nil {
-			return fmt.Errorf("header assertion requires 'expected' field")
+	}
+
+	if groups := mappingValue(root, "assertion_groups"); groups != nil && groups.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(groups.Content); i += 2 {
+			v.recordAssertions(suite.AssertionGroups[groups.Content[i].Value], groups.Content[i+1], filename)
 		}
-	case "response_time":
-		if assertion.Expected == nil {
-			return fmt.Errorf("response_time assertion requires 'expected' field")
+	}
+}
+
+// recordAssertions stores the position of each assertion in a sequence node
+func (v *validator) recordAssertions(assertions []types.Assertion, node *yaml.Node, filename string) {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, item := range node.Content {
+		if i < len(assertions) {
+			assertions[i].Source = v.position(filename, item)
 		}
-		if assertion.Operator == "" {
-			assertion.Operator = "less_than" // default
+	}
+}
+
+// position records a node so its fields can be located later
+func (v *validator) position(filename string, node *yaml.Node) types.Position {
+	pos := nodePosition(filename, node)
+	v.nodes[pos] = node
+	return pos
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
 		}
-	default:
-		return fmt.Errorf("unsupported assertion type: %s", assertion.Type)
 	}
-	
 	return nil
-}
\ No newline at end of file
+}
+
+// nodePosition converts a YAML node location into a types.Position
+func nodePosition(filename string, node *yaml.Node) types.Position {
+	return types.Position{File: filename, Line: node.Line, Column: node.Column}
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/webhook"
+	"github.com/Asadus16/comapi/pkg/assertions"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// SchemaID identifies the generated JSON Schema
+const SchemaID = "https://github.com/Asadus16/comapi/schema/suite.json"
+
+// fieldDescriptions documents suite fields for editor tooltips, keyed by "Type.yaml_key"
+var fieldDescriptions = map[string]string{
+	"TestSuite.name":             "Name of the test suite",
+	"TestSuite.base_url":         "Base URL prepended to each test's path",
+	"TestSuite.include":          "Other suite files to merge into this one, relative to this file",
+	"TestSuite.headers":          "Headers sent with every request",
+	"TestSuite.environment":      "Variables available to when expressions",
+	"TestSuite.templates":        "Named request templates that tests can extend",
+	"TestSuite.assertion_groups": "Named lists of assertions that tests can extend",
+	"TestSuite.tests":            "The tests to run, in order",
+	"TestSuite.webhooks":         "URLs notified with a summary when a run completes",
+	"TestSuite.secrets":          "Values read from the environment or files, used as {{NAME}} and masked in every report",
+	"TestSuite.redact":           "Header names and JSON paths masked in results, history and logs",
+	"TestCase.name":              "Unique name of the test",
+	"TestCase.description":       "Free-form description",
+	"TestCase.extends":           "Templates or assertion groups to inherit from",
+	"TestCase.method":            "HTTP method",
+	"TestCase.path":              "Request path, appended to base_url",
+	"TestCase.url":               "Complete request URL, used instead of base_url + path",
+	"TestCase.headers":           "Headers for this request, overriding the suite headers",
+	"TestCase.body":              "Raw request body",
+	"TestCase.depends_on":        "Tests that must pass before this one runs",
+	"TestCase.when":              "Expression over variables, in the same language as expr assertions, e.g. ENV == \"staging\" && !SKIP_SLOW",
+	"TestCase.data":              "Parameter rows; the test runs once per row",
+	"TestCase.assertions":        "Checks applied to the response",
+	"TestCase.mock_response":     "Stub response served by comapi mock",
+	"TestCase.graphql":           "GraphQL operation sent instead of body; method defaults to POST",
+	"TestCase.grpc":              "Unary gRPC call made instead of an HTTP request; status is the gRPC code and body the response message as JSON",
+	"MockResponse.status":        "Status code to return",
+	"MockResponse.headers":       "Response headers",
+	"MockResponse.body":          "Response body",
+	"MockResponse.delay_ms":      "Delay before responding, in milliseconds",
+	"MockResponse.error_rate":    "Fraction of requests (0-1) answered with error_status",
+	"MockResponse.error_status":  "Status code for injected errors (default 500)",
+	"TestData.rows":              "Inline parameter rows",
+	"TestData.file":              "CSV file with a header row, or JSON array of objects",
+	"TestData.matrix":            "Every combination of the listed values",
+	"Assertion.type":             "Kind of check to perform",
+	"Assertion.target":           "JSON path, header name, for response_time a timing phase (dns, connect, tls, wait, ttfb, transfer, total), or for graphql_errors a path inside each error (default message)",
+	"Assertion.expected":         "Expected value",
+	"Assertion.operator":         "Comparison operator; defaults depend on the type",
+	"Assertion.expr":             "Boolean expression over status, headers, body, text, size, duration_ms, timings and vars",
+	"Assertion.message":          "Message shown when the assertion fails",
+	"Webhook.url":                "URL the summary is POSTed to; {{NAME}} reads secrets and the suite environment, and for CLI runs the process environment",
+	"Webhook.on":                 "When to send: always (default) or failure",
+	"Webhook.headers":            "Extra request headers",
+	"Webhook.secret":             "Key for the HMAC-SHA256 X-Comapi-Signature-256 header",
+	"Webhook.template":           "Go template for the body, with the summary fields as data",
+	"Webhook.max_attempts":       "Delivery attempts before giving up (default 3)",
+	"Secret.env":                 "Environment variable holding the value",
+	"Secret.file":                "File holding the value, relative to the suite file; a trailing newline is dropped",
+	"Redaction.headers":          "Request and response headers to mask, in addition to Authorization, Cookie and similar",
+	"Redaction.json_paths":       "Paths in request and response bodies to mask, e.g. data.token or users.#.password",
+
+	"GraphQLRequest.query":          "The GraphQL query, mutation or subscription document",
+	"GraphQLRequest.variables":      "Values for the operation's variables",
+	"GraphQLRequest.operation_name": "Operation to run when the query defines several",
+	"GraphQLRequest.schema":         "Introspection result file (see comapi graphql introspect) to validate the query against at load time, or the URL of a GraphQL endpoint to introspect when schema fetching is enabled",
+
+	"GRPCRequest.service":      "Fully qualified service name, e.g. shop.v1.Orders",
+	"GRPCRequest.method":       "Method of the service to call; only unary methods are supported",
+	"GRPCRequest.message":      "Request message as a mapping or JSON text, using proto field names",
+	"GRPCRequest.metadata":     "Request metadata, added to the suite and test headers",
+	"GRPCRequest.proto_files":  "Files defining the service, relative to the suite file; server reflection is used when omitted",
+	"GRPCRequest.import_paths": "Directories imports in proto_files are resolved against",
+}
+
+// requiredFields lists the keys that must be present for each type
+var requiredFields = map[string][]string{
+	"TestSuite": {"name", "tests"},
+	"TestCase":  {"name"},
+	"Assertion": {"type"},
+	"Webhook":   {"url"},
+}
+
+// GenerateSchema builds a JSON Schema (draft-07) for suite files from the Go types,
+// including the accepted methods, assertion types and per-type operators
+func GenerateSchema() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	root := schemaForType(reflect.TypeOf(types.TestSuite{}), definitions)
+
+	// Enumerations the Go types cannot express on their own
+	testCase := definitions["TestCase"].(map[string]interface{})
+	// Validation accepts any case and warns about lower case, so the enum only drives completion
+	testCase["properties"].(map[string]interface{})["method"] = map[string]interface{}{
+		"type": "string",
+		"anyOf": []interface{}{
+			map[string]interface{}{"enum": ValidMethods},
+			map[string]interface{}{"pattern": caseInsensitivePattern(ValidMethods)},
+		},
+		"description": fieldDescriptions["TestCase.method"],
+	}
+
+	webhookSchema := definitions["Webhook"].(map[string]interface{})
+	webhookSchema["properties"].(map[string]interface{})["on"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{webhook.OnAlways, webhook.OnFailure},
+		"description": fieldDescriptions["Webhook.on"],
+	}
+
+	builtinOperators := assertions.Builtin()
+	assertionTypes := make([]string, 0, len(builtinOperators))
+	var allOperators []string
+	for assertionType, operators := range builtinOperators {
+		assertionTypes = append(assertionTypes, assertionType)
+		for _, operator := range operators {
+			if !contains(allOperators, operator) {
+				allOperators = append(allOperators, operator)
+			}
+		}
+	}
+	// Custom types registered by embedding programs
+	customOperators := make(map[string][]string)
+	for _, name := range assertions.Registered() {
+		assertionTypes = append(assertionTypes, name)
+		custom, _ := assertions.Lookup(name)
+		if lister, ok := custom.(assertions.OperatorLister); ok {
+			customOperators[name] = lister.Operators()
+		}
+		for _, operator := range customOperators[name] {
+			if !contains(allOperators, operator) {
+				allOperators = append(allOperators, operator)
+			}
+		}
+	}
+	sort.Strings(assertionTypes)
+	sort.Strings(allOperators)
+
+	assertion := definitions["Assertion"].(map[string]interface{})
+	properties := assertion["properties"].(map[string]interface{})
+	properties["type"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        assertionTypes,
+		"description": fieldDescriptions["Assertion.type"],
+	}
+	properties["operator"] = map[string]interface{}{
+		"type":        "string",
+		"description": fieldDescriptions["Assertion.operator"],
+	}
+	if len(customOperators) == len(assertions.Registered()) {
+		// Every type declares its operators, so the full list is known
+		properties["operator"].(map[string]interface{})["enum"] = allOperators
+	}
+
+	// Narrow the operator list for each assertion type
+	var conditions []interface{}
+	for _, assertionType := range assertionTypes {
+		operators, ok := builtinOperators[assertionType]
+		if !ok {
+			if operators, ok = customOperators[assertionType]; !ok {
+				continue
+			}
+		}
+		then := map[string]interface{}{
+			"properties": map[string]interface{}{"operator": map[string]interface{}{"enum": operators}},
+		}
+		if len(operators) == 0 {
+			then = map[string]interface{}{"not": map[string]interface{}{"required": []string{"operator"}}}
+		}
+		conditions = append(conditions, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"type": map[string]interface{}{"const": assertionType}},
+			},
+			"then": then,
+		})
+	}
+	assertion["allOf"] = conditions
+
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["$id"] = SchemaID
+	root["title"] = "Comapi test suite"
+	root["definitions"] = definitions
+	return root
+}
+
+// schemaForType converts a Go type into a JSON Schema fragment. Named structs
+// are placed in definitions and referenced.
+func schemaForType(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), definitions)}
+	case reflect.Struct:
+		return structSchema(t, definitions)
+	default:
+		// interface{} accepts any value
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct by its YAML keys
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	name := t.Name()
+	ref := map[string]interface{}{"$ref": "#/definitions/" + name}
+	if _, ok := definitions[name]; ok {
+		return ref
+	}
+
+	properties := make(map[string]interface{})
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+		"patternProperties": map[string]interface{}{
+			// Keys starting with x- hold anchors and extensions
+			"^x-": map[string]interface{}{},
+			// YAML merge keys pull in one or more anchored mappings
+			"^<<$": map[string]interface{}{"type": []string{"object", "array"}},
+		},
+	}
+	if required, ok := requiredFields[name]; ok {
+		schema["required"] = required
+	}
+	// Register before descending so recursive types (templates are TestCases) terminate
+	definitions[name] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "-" || !field.IsExported() {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+
+		property := schemaForType(field.Type, definitions)
+		if description, ok := fieldDescriptions[name+"."+key]; ok {
+			if _, isRef := property["$ref"]; isRef {
+				property = map[string]interface{}{"allOf": []interface{}{property}, "description": description}
+			} else {
+				property["description"] = description
+			}
+		}
+		properties[key] = property
+	}
+
+	if name == "TestSuite" {
+		// The root is returned inline rather than as a reference
+		delete(definitions, name)
+		return schema
+	}
+	return ref
+}
+
+// caseInsensitivePattern matches any of the words regardless of case. JSON Schema
+// patterns are ECMA regular expressions, which have no (?i) flag.
+func caseInsensitivePattern(words []string) string {
+	alternatives := make([]string, len(words))
+	for i, word := range words {
+		var b strings.Builder
+		for _, r := range word {
+			lower, upper := strings.ToLower(string(r)), strings.ToUpper(string(r))
+			if lower == upper {
+				b.WriteString(regexp.QuoteMeta(lower))
+				continue
+			}
+			b.WriteString("[" + upper + lower + "]")
+		}
+		alternatives[i] = b.String()
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCaseInsensitivePattern(t *testing.T) {
+	pattern := regexp.MustCompile(caseInsensitivePattern(ValidMethods))
+
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"GET", true},
+		{"get", true},
+		{"Patch", true},
+		{"oPtIoNs", true},
+		{"FETCH", false},
+		{"GETS", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := pattern.MatchString(tt.method); got != tt.want {
+			t.Errorf("pattern matches %q = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaAllowsMergeKeys(t *testing.T) {
+	definitions := GenerateSchema()["definitions"].(map[string]interface{})
+	for _, name := range []string{"TestCase", "Assertion", "Webhook"} {
+		patterns := definitions[name].(map[string]interface{})["patternProperties"].(map[string]interface{})
+		if _, ok := patterns["^<<$"]; !ok {
+			t.Errorf("%s does not allow the << merge key", name)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// ResolveSecrets reads the value of every secret that does not have one yet.
+// Relative files are resolved against the directory of the suite file that
+// defined the secret, or baseDir when it is not known. Values are stored in the
+// suite's Secrets, which are never serialized.
+func ResolveSecrets(suite *types.TestSuite, baseDir string) error {
+	for _, name := range sortedKeys(suite.Secrets) {
+		secret := suite.Secrets[name]
+		if secret.Value != "" {
+			continue
+		}
+
+		switch {
+		case secret.Env != "":
+			value, ok := os.LookupEnv(secret.Env)
+			if !ok || value == "" {
+				return fmt.Errorf("secret '%s': environment variable %s is not set", name, secret.Env)
+			}
+			secret.Value = value
+		case secret.File != "":
+			path := secret.File
+			if !filepath.IsAbs(path) {
+				dir := baseDir
+				if secret.Dir != "" {
+					dir = secret.Dir
+				}
+				path = filepath.Join(dir, path)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("secret '%s': %w", name, err)
+			}
+			secret.Value = strings.TrimRight(string(content), "\r\n")
+			if secret.Value == "" {
+				return fmt.Errorf("secret '%s': %s is empty", name, secret.File)
+			}
+		default:
+			return fmt.Errorf("secret '%s': env or file is required", name)
+		}
+
+		suite.Secrets[name] = secret
+	}
+	return nil
+}
@@ -0,0 +1,554 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	checker "github.com/Asadus16/comapi/internal/assertion"
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/internal/webhook"
+	"github.com/Asadus16/comapi/pkg/assertions"
+	"github.com/Asadus16/comapi/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity levels for diagnostics
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidMethods lists the HTTP methods a test may use
+var ValidMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// Diagnostic is a single problem found while validating a suite
+type Diagnostic struct {
+	types.Position
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic as file:line:column: severity: message
+func (d Diagnostic) String() string {
+	if location := d.Position.String(); location != "" {
+		return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+// Diagnostics is a list of validation problems. It implements error so a
+// failed load can return every problem at once.
+type Diagnostics []Diagnostic
+
+// Error joins all diagnostics, one per line
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HasErrors reports whether any diagnostic is an error rather than a warning
+func (d Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Messages returns the diagnostics as plain strings
+func (d Diagnostics) Messages() []string {
+	messages := make([]string, len(d))
+	for i, diagnostic := range d {
+		messages[i] = diagnostic.String()
+	}
+	return messages
+}
+
+// validator collects diagnostics and knows where each definition came from
+type validator struct {
+	nodes       map[types.Position]*yaml.Node
+	diagnostics Diagnostics
+	noFiles     bool            // Set by WithoutFiles
+	files       map[string]bool // Suite files read from disk
+
+	// Set by WithSchemaFetch
+	schemaClient  *http.Client
+	schemaTimeout time.Duration
+}
+
+func newValidator() *validator {
+	return &validator{nodes: make(map[types.Position]*yaml.Node), files: make(map[string]bool)}
+}
+
+// fileDir returns the directory relative paths of a definition are resolved
+// against: that of the suite file it was defined in, so included files can
+// name files next to themselves, or baseDir when it does not come from a file
+func (v *validator) fileDir(pos types.Position, baseDir string) string {
+	if v.files[pos.File] {
+		return filepath.Dir(pos.File)
+	}
+	return baseDir
+}
+
+func (v *validator) errorf(pos types.Position, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{Position: pos, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(pos types.Position, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{Position: pos, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// fieldPosition returns the position of a field's value inside the mapping
+// defined at pos, falling back to pos itself when the field is absent
+func (v *validator) fieldPosition(pos types.Position, field string) types.Position {
+	if value := mappingValue(v.nodes[pos], field); value != nil {
+		return nodePosition(pos.File, value)
+	}
+	return pos
+}
+
+// ValidateSuite checks a decoded suite and returns every problem found. Suites
+// that did not come from a file have no positions in their diagnostics.
+func ValidateSuite(suite *types.TestSuite) Diagnostics {
+	v := newValidator()
+	v.validateSuite(suite, types.Position{})
+	return v.diagnostics
+}
+
+// validateSuite runs the semantic checks on a fully resolved suite
+func (v *validator) validateSuite(suite *types.TestSuite, root types.Position) {
+	if suite.Name == "" {
+		v.errorf(root, "test suite name is required")
+	}
+
+	allFullURLs := len(suite.Tests) > 0
+	for _, test := range suite.Tests {
+		if test.URL == "" {
+			allFullURLs = false
+		}
+	}
+	if suite.BaseURL == "" {
+		if !allFullURLs {
+			v.errorf(root, "base_url is required")
+		}
+	} else if err := checkURL(suite.BaseURL); err != nil {
+		v.errorf(v.fieldPosition(root, "base_url"), "base_url: %v", err)
+	}
+
+	if len(suite.Tests) == 0 {
+		v.errorf(root, "at least one test is required")
+	}
+
+	for i, hook := range suite.Webhooks {
+		v.validateWebhook(i, hook, v.fieldPosition(root, "webhooks"))
+	}
+	v.validateSecrets(suite, root)
+
+	seen := make(map[string]types.Position)
+	for i, test := range suite.Tests {
+		v.validateTest(i, test)
+		if test.Name == "" {
+			continue
+		}
+		if first, ok := seen[test.Name]; ok {
+			v.errorf(v.fieldPosition(test.Source, "name"), "test '%s': duplicate test name (first defined at %s)", test.Name, first)
+		} else {
+			seen[test.Name] = test.Source
+		}
+	}
+}
+
+// validateWebhook checks one entry of the suite's webhooks
+func (v *validator) validateWebhook(index int, hook types.Webhook, pos types.Position) {
+	label := fmt.Sprintf("webhook %d", index+1)
+	if hook.URL == "" {
+		v.errorf(pos, "%s: url is required", label)
+	} else if err := checkURL(hook.URL); err != nil {
+		v.errorf(pos, "%s: url: %v", label, err)
+	}
+	if hook.On != "" && hook.On != webhook.OnAlways && hook.On != webhook.OnFailure {
+		v.errorf(pos, "%s: invalid on '%s' (expected %s or %s)", label, hook.On, webhook.OnAlways, webhook.OnFailure)
+	}
+	if hook.MaxAttempts < 0 {
+		v.errorf(pos, "%s: max_attempts cannot be negative", label)
+	}
+	if hook.Template != "" {
+		if _, err := webhook.ParseTemplate(hook.Template); err != nil {
+			v.errorf(pos, "%s: template: %v", label, err)
+		}
+	}
+}
+
+// secretNamePattern matches names usable as {{NAME}} placeholders
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// validateSecrets checks the secret sources and redaction rules
+func (v *validator) validateSecrets(suite *types.TestSuite, root types.Position) {
+	pos := v.fieldPosition(root, "secrets")
+	for _, name := range sortedKeys(suite.Secrets) {
+		secret := suite.Secrets[name]
+		switch {
+		case !secretNamePattern.MatchString(name):
+			v.errorf(pos, "secret '%s': name may only contain letters, digits, '_', '.' and '-'", name)
+		case secret.Env == "" && secret.File == "":
+			v.errorf(pos, "secret '%s': env or file is required", name)
+		case secret.Env != "" && secret.File != "":
+			v.errorf(pos, "secret '%s': use either env or file, not both", name)
+		}
+	}
+
+	if suite.Redact == nil {
+		return
+	}
+	pos = v.fieldPosition(root, "redact")
+	for _, header := range suite.Redact.Headers {
+		if strings.TrimSpace(header) == "" {
+			v.errorf(pos, "redact: header names cannot be empty")
+		}
+	}
+	for _, path := range suite.Redact.JSONPaths {
+		if strings.TrimSpace(path) == "" {
+			v.errorf(pos, "redact: json_paths cannot be empty")
+		}
+	}
+}
+
+// validateTest checks a single test case
+func (v *validator) validateTest(index int, test types.TestCase) {
+	label := fmt.Sprintf("test '%s'", test.Name)
+	if test.Name == "" {
+		label = fmt.Sprintf("test %d", index+1)
+		v.errorf(test.Source, "%s: name is required", label)
+	}
+
+	method := test.Method
+	switch {
+	case method == "":
+		v.errorf(test.Source, "%s: method is required", label)
+	case !contains(ValidMethods, strings.ToUpper(method)):
+		v.errorf(v.fieldPosition(test.Source, "method"), "%s: invalid method '%s' (expected one of %s)", label, method, strings.Join(ValidMethods, ", "))
+	case method != strings.ToUpper(method):
+		v.warnf(v.fieldPosition(test.Source, "method"), "%s: method '%s' should be upper case", label, method)
+	}
+
+	if test.GraphQL != nil {
+		pos := v.fieldPosition(test.Source, "graphql")
+		if strings.TrimSpace(test.GraphQL.Query) == "" {
+			v.errorf(pos, "%s: graphql query is required", label)
+		}
+		if test.Body != "" {
+			v.errorf(v.fieldPosition(test.Source, "body"), "%s: body cannot be combined with graphql", label)
+		}
+	}
+	if test.GRPC != nil {
+		v.validateGRPCRequest(label, test)
+	}
+
+	switch {
+	case test.Path == "" && test.URL == "":
+		v.errorf(test.Source, "%s: path is required", label)
+	case test.URL != "":
+		if err := checkURL(test.URL); err != nil {
+			v.errorf(v.fieldPosition(test.Source, "url"), "%s: url: %v", label, err)
+		}
+	case !strings.HasPrefix(test.Path, "/") && !strings.HasPrefix(test.Path, "{{"):
+		v.warnf(v.fieldPosition(test.Source, "path"), "%s: path '%s' should start with '/'", label, test.Path)
+	}
+
+	if len(test.Assertions) == 0 {
+		v.errorf(test.Source, "%s: at least one assertion is required", label)
+	}
+	for j, assertion := range test.Assertions {
+		pos := assertion.Source
+		if pos.File == "" {
+			pos = test.Source
+		}
+		if err := ValidateAssertion(assertion); err != nil {
+			v.errorf(pos, "%s: assertion %d: %v", label, j+1, err)
+		}
+	}
+}
+
+// ValidateAssertion checks if an assertion is properly formatted
+func ValidateAssertion(assertion types.Assertion) error {
+	operators, known := assertions.BuiltinOperators(assertion.Type)
+	if !known {
+		return validateCustomAssertion(assertion)
+	}
+
+	switch assertion.Type {
+	case "status":
+		if assertion.Expected == nil {
+			return fmt.Errorf("status assertion requires 'expected' field")
+		}
+		if !isPlaceholder(assertion.Expected) && !isInteger(assertion.Expected) {
+			return fmt.Errorf("status assertion 'expected' must be an integer, got %v", assertion.Expected)
+		}
+	case "json_path":
+		if assertion.Target == "" {
+			return fmt.Errorf("json_path assertion requires 'target' field")
+		}
+		if assertion.Expected == nil {
+			return fmt.Errorf("json_path assertion requires 'expected' field")
+		}
+	case "header":
+		if assertion.Target == "" {
+			return fmt.Errorf("header assertion requires 'target' field (header name)")
+		}
+		if assertion.Expected == nil {
+			return fmt.Errorf("header assertion requires 'expected' field")
+		}
+	case "response_time":
+		if assertion.Expected == nil {
+			return fmt.Errorf("response_time assertion requires 'expected' field")
+		}
+		if _, ok := toNumber(assertion.Expected); !ok && !isPlaceholder(assertion.Expected) {
+			return fmt.Errorf("response_time assertion 'expected' must be a number of milliseconds, got %v", assertion.Expected)
+		}
+		if assertion.Target != "" && !contains(types.TimingPhases, assertion.Target) {
+			return fmt.Errorf("response_time assertion target '%s' is not a timing phase (expected one of %s)", assertion.Target, strings.Join(types.TimingPhases, ", "))
+		}
+	case "expr":
+		if assertion.Expr == "" {
+			return fmt.Errorf("expr assertion requires 'expr' field")
+		}
+		if err := checker.CompileExpression(assertion.Expr); err != nil {
+			return fmt.Errorf("invalid expression: %v", err)
+		}
+		if assertion.Operator != "" {
+			return fmt.Errorf("expr assertion does not take an operator")
+		}
+	case "graphql_errors":
+		switch assertion.Operator {
+		case "", "absent", "present":
+			if assertion.Expected != nil {
+				return fmt.Errorf("graphql_errors assertion takes no 'expected' unless operator is contains or equals")
+			}
+		default:
+			if assertion.Expected == nil {
+				return fmt.Errorf("graphql_errors assertion with operator %s requires 'expected' field", assertion.Operator)
+			}
+		}
+	}
+
+	if assertion.Operator != "" && !contains(operators, assertion.Operator) {
+		return fmt.Errorf("invalid operator '%s' for %s assertion (expected one of %s)", assertion.Operator, assertion.Type, strings.Join(operators, ", "))
+	}
+
+	return nil
+}
+
+// validateCustomAssertion checks an assertion whose type was registered through pkg/assertions
+func validateCustomAssertion(assertion types.Assertion) error {
+	custom, ok := assertions.Lookup(assertion.Type)
+	if !ok {
+		return fmt.Errorf("unsupported assertion type: %s", assertion.Type)
+	}
+
+	if lister, ok := custom.(assertions.OperatorLister); ok && assertion.Operator != "" {
+		if operators := lister.Operators(); !contains(operators, assertion.Operator) {
+			return fmt.Errorf("invalid operator '%s' for %s assertion (expected one of %s)", assertion.Operator, assertion.Type, strings.Join(operators, ", "))
+		}
+	}
+
+	if validator, ok := custom.(assertions.Validator); ok {
+		return validateSafely(validator, assertion)
+	}
+	return nil
+}
+
+// validateSafely turns a panic in an embedder's validator into a load error
+func validateSafely(validator assertions.Validator, assertion types.Assertion) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s assertion validator panicked: %v", assertion.Type, r)
+		}
+	}()
+	return validator.Validate(assertion)
+}
+
+// checkURL verifies that raw is an absolute http(s) URL. Templated URLs are not checked.
+func checkURL(raw string) error {
+	if vars.HasPlaceholders(raw) {
+		return nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("malformed URL '%s': %v", raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("malformed URL '%s': scheme must be http or https", raw)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("malformed URL '%s': missing host", raw)
+	}
+	return nil
+}
+
+// checkUnknownFields walks a YAML node against the Go type it decodes into and
+// reports keys that the type does not declare. Keys starting with "x-" are
+// reserved for anchors and extensions and are always allowed.
+func (v *validator) checkUnknownFields(filename string, node *yaml.Node, t reflect.Type, path string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode || node.Kind == yaml.DocumentNode {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" || strings.HasPrefix(key.Value, "x-") {
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				v.errorf(nodePosition(filename, key), "%sunknown field '%s'%s", pathPrefix(path), key.Value, suggestField(key.Value, fields))
+				continue
+			}
+			v.checkUnknownFields(filename, value, field, joinPath(path, key.Value))
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkUnknownFields(filename, node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			v.checkUnknownFields(filename, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// suggestField proposes the closest known key for a likely typo
+func suggestField(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+		}
+		prev = current
+	}
+	return prev[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathPrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + ": "
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlaceholder(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && vars.HasPlaceholders(s)
+}
+
+func isInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int64, uint64:
+		return true
+	case float64:
+		return v == float64(int64(v))
+	default:
+		return false
+	}
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,404 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultIgnoredHeaders change on every response and are skipped unless IncludeAllHeaders is set
+var DefaultIgnoredHeaders = []string{
+	"Age",
+	"Content-Length",
+	"Date",
+	"Expires",
+	"Set-Cookie",
+	"X-Request-Id",
+}
+
+// Kind classifies a difference
+type Kind string
+
+const (
+	KindMissing Kind = "missing" // Test only ran on one side
+	KindError   Kind = "error"   // Request error on one or both sides
+	KindStatus  Kind = "status"
+	KindHeader  Kind = "header"
+	KindBody    Kind = "body"
+)
+
+// Exchange is one test's response on one side of the comparison
+type Exchange struct {
+	Name       string
+	StatusCode int
+	Headers    map[string]string
+	Body       string
+	Error      string
+}
+
+// Options control what counts as a difference
+type Options struct {
+	// IgnorePaths skips JSON body fields. A path is either a JSON path such as
+	// "$.items[*].updated_at" (* matches any key or index) or a bare field name
+	// such as "request_id", which matches that key at any depth.
+	IgnorePaths []string
+	// IgnoreHeaders skips response headers, in addition to DefaultIgnoredHeaders
+	IgnoreHeaders []string
+	// IncludeAllHeaders compares the default volatile headers too
+	IncludeAllHeaders bool
+	// StructureOnly compares JSON keys and value types but not the values themselves
+	StructureOnly bool
+}
+
+// Difference is one mismatch between the two sides
+type Difference struct {
+	Kind    Kind        `json:"kind"`
+	Path    string      `json:"path,omitempty"`
+	Left    interface{} `json:"left"`
+	Right   interface{} `json:"right"`
+	Message string      `json:"message"`
+}
+
+// TestDiff holds the differences found for one test
+type TestDiff struct {
+	Name        string       `json:"name"`
+	Differences []Difference `json:"differences"`
+}
+
+// Report is the outcome of comparing two sides
+type Report struct {
+	Left      string     `json:"left"`
+	Right     string     `json:"right"`
+	Compared  int        `json:"compared"`
+	Identical int        `json:"identical"`
+	Tests     []TestDiff `json:"tests"`
+}
+
+// HasDifferences reports whether any test differed
+func (r Report) HasDifferences() bool {
+	return len(r.Tests) > 0
+}
+
+// Compare matches exchanges by test name and reports the tests that differ,
+// in the order they appear on the left
+func Compare(leftName string, left []Exchange, rightName string, right []Exchange, options Options) Report {
+	report := Report{Left: leftName, Right: rightName}
+	comparer := newComparer(options)
+
+	rightByName := make(map[string]Exchange, len(right))
+	for _, exchange := range right {
+		rightByName[exchange.Name] = exchange
+	}
+	seen := make(map[string]bool, len(left))
+
+	for _, l := range left {
+		seen[l.Name] = true
+		report.Compared++
+
+		r, ok := rightByName[l.Name]
+		if !ok {
+			report.Tests = append(report.Tests, TestDiff{Name: l.Name, Differences: []Difference{{
+				Kind: KindMissing, Left: "present", Right: "missing",
+				Message: fmt.Sprintf("test only ran on %s", leftName),
+			}}})
+			continue
+		}
+
+		differences := comparer.exchange(l, r)
+		if len(differences) == 0 {
+			report.Identical++
+			continue
+		}
+		report.Tests = append(report.Tests, TestDiff{Name: l.Name, Differences: differences})
+	}
+
+	for _, r := range right {
+		if seen[r.Name] {
+			continue
+		}
+		report.Compared++
+		report.Tests = append(report.Tests, TestDiff{Name: r.Name, Differences: []Difference{{
+			Kind: KindMissing, Left: "missing", Right: "present",
+			Message: fmt.Sprintf("test only ran on %s", rightName),
+		}}})
+	}
+
+	return report
+}
+
+// comparer holds the parsed ignore rules
+type comparer struct {
+	options       Options
+	ignoreHeaders map[string]bool
+	ignoreKeys    map[string]bool
+	ignorePaths   [][]string
+}
+
+func newComparer(options Options) *comparer {
+	c := &comparer{
+		options:       options,
+		ignoreHeaders: make(map[string]bool),
+		ignoreKeys:    make(map[string]bool),
+	}
+
+	if !options.IncludeAllHeaders {
+		for _, name := range DefaultIgnoredHeaders {
+			c.ignoreHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	for _, name := range options.IgnoreHeaders {
+		c.ignoreHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, path := range options.IgnorePaths {
+		if strings.HasPrefix(path, "$") {
+			c.ignorePaths = append(c.ignorePaths, splitPath(path))
+		} else {
+			c.ignoreKeys[path] = true
+		}
+	}
+
+	return c
+}
+
+func (c *comparer) exchange(left, right Exchange) []Difference {
+	var differences []Difference
+
+	if left.Error != "" || right.Error != "" {
+		if left.Error != right.Error {
+			differences = append(differences, Difference{
+				Kind: KindError, Left: left.Error, Right: right.Error,
+				Message: "request errors differ",
+			})
+		}
+		// Without a response on both sides there is nothing else to compare
+		if left.StatusCode == 0 || right.StatusCode == 0 {
+			return differences
+		}
+	}
+
+	if left.StatusCode != right.StatusCode {
+		differences = append(differences, Difference{
+			Kind: KindStatus, Left: left.StatusCode, Right: right.StatusCode,
+			Message: fmt.Sprintf("status %d vs %d", left.StatusCode, right.StatusCode),
+		})
+	}
+
+	differences = append(differences, c.headers(left.Headers, right.Headers)...)
+	differences = append(differences, c.body(left.Body, right.Body)...)
+	return differences
+}
+
+func (c *comparer) headers(left, right map[string]string) []Difference {
+	leftHeaders, rightHeaders := canonical(left), canonical(right)
+
+	names := make(map[string]bool)
+	for name := range leftHeaders {
+		names[name] = true
+	}
+	for name := range rightHeaders {
+		names[name] = true
+	}
+
+	var differences []Difference
+	for _, name := range sortedKeys(names) {
+		if c.ignoreHeaders[name] {
+			continue
+		}
+		l, inLeft := leftHeaders[name]
+		r, inRight := rightHeaders[name]
+		switch {
+		case !inLeft:
+			differences = append(differences, Difference{Kind: KindHeader, Path: name, Left: nil, Right: r, Message: "header only on right"})
+		case !inRight:
+			differences = append(differences, Difference{Kind: KindHeader, Path: name, Left: l, Right: nil, Message: "header only on left"})
+		case l != r && !c.options.StructureOnly:
+			differences = append(differences, Difference{Kind: KindHeader, Path: name, Left: l, Right: r, Message: "header values differ"})
+		}
+	}
+	return differences
+}
+
+func (c *comparer) body(left, right string) []Difference {
+	var leftJSON, rightJSON interface{}
+	leftErr := json.Unmarshal([]byte(left), &leftJSON)
+	rightErr := json.Unmarshal([]byte(right), &rightJSON)
+
+	// Fall back to a plain comparison when either side is not JSON
+	if leftErr != nil || rightErr != nil {
+		if left != right && !c.options.StructureOnly {
+			return []Difference{{Kind: KindBody, Path: "$", Left: preview(left), Right: preview(right), Message: "bodies differ"}}
+		}
+		return nil
+	}
+
+	var differences []Difference
+	c.compareJSON([]string{"$"}, leftJSON, rightJSON, &differences)
+	return differences
+}
+
+// compareJSON walks both documents together, recording differences at each path
+func (c *comparer) compareJSON(path []string, left, right interface{}, differences *[]Difference) {
+	if c.ignored(path) {
+		return
+	}
+
+	leftType, rightType := jsonType(left), jsonType(right)
+	if leftType != rightType {
+		*differences = append(*differences, Difference{
+			Kind: KindBody, Path: formatPath(path), Left: left, Right: right,
+			Message: fmt.Sprintf("type %s vs %s", leftType, rightType),
+		})
+		return
+	}
+
+	switch l := left.(type) {
+	case map[string]interface{}:
+		r := right.(map[string]interface{})
+		keys := make(map[string]bool)
+		for key := range l {
+			keys[key] = true
+		}
+		for key := range r {
+			keys[key] = true
+		}
+		for _, key := range sortedKeys(keys) {
+			childPath := append(append([]string{}, path...), key)
+			if c.ignored(childPath) {
+				continue
+			}
+			lv, inLeft := l[key]
+			rv, inRight := r[key]
+			switch {
+			case !inLeft:
+				*differences = append(*differences, Difference{Kind: KindBody, Path: formatPath(childPath), Left: nil, Right: rv, Message: "field only on right"})
+			case !inRight:
+				*differences = append(*differences, Difference{Kind: KindBody, Path: formatPath(childPath), Left: lv, Right: nil, Message: "field only on left"})
+			default:
+				c.compareJSON(childPath, lv, rv, differences)
+			}
+		}
+
+	case []interface{}:
+		r := right.([]interface{})
+		if len(l) != len(r) && !c.options.StructureOnly {
+			*differences = append(*differences, Difference{
+				Kind: KindBody, Path: formatPath(path), Left: len(l), Right: len(r),
+				Message: fmt.Sprintf("array length %d vs %d", len(l), len(r)),
+			})
+		}
+		for i := 0; i < len(l) && i < len(r); i++ {
+			c.compareJSON(append(append([]string{}, path...), strconv.Itoa(i)), l[i], r[i], differences)
+		}
+
+	default:
+		if !c.options.StructureOnly && !reflect.DeepEqual(left, right) {
+			*differences = append(*differences, Difference{Kind: KindBody, Path: formatPath(path), Left: left, Right: right, Message: "values differ"})
+		}
+	}
+}
+
+// ignored reports whether a body path matches an ignore rule
+func (c *comparer) ignored(path []string) bool {
+	if len(path) > 1 && c.ignoreKeys[path[len(path)-1]] {
+		return true
+	}
+	for _, pattern := range c.ignorePaths {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches path segments against a pattern where * matches any single segment
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPath turns "$.items[*].id" into ["$", "items", "*", "id"]
+func splitPath(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segments []string
+	for _, segment := range strings.Split(path, ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// formatPath renders segments as a JSON path, with numeric segments as indexes
+func formatPath(path []string) string {
+	var b strings.Builder
+	for i, segment := range path {
+		switch {
+		case i == 0:
+			b.WriteString(segment)
+		case isIndex(segment):
+			b.WriteString("[" + segment + "]")
+		default:
+			b.WriteString("." + segment)
+		}
+	}
+	return b.String()
+}
+
+func isIndex(segment string) bool {
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func canonical(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		result[http.CanonicalHeaderKey(name)] = value
+	}
+	return result
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func preview(body string) string {
+	if len(body) > 200 {
+		return body[:200] + "..."
+	}
+	return body
+}
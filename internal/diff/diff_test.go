@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		left    Exchange
+		right   Exchange
+		options Options
+		want    []string // kind and path of each difference
+	}{
+		{
+			name:  "identical",
+			left:  Exchange{StatusCode: 200, Body: `{"id": 1}`},
+			right: Exchange{StatusCode: 200, Body: `{"id": 1}`},
+		},
+		{
+			name:  "status, header and body",
+			left:  Exchange{StatusCode: 200, Headers: map[string]string{"content-type": "application/json"}, Body: `{"id": 1, "tags": ["a"]}`},
+			right: Exchange{StatusCode: 201, Headers: map[string]string{"Content-Type": "text/plain"}, Body: `{"id": "1", "tags": ["a", "b"]}`},
+			want:  []string{"status ", "header Content-Type", "body $.id", "body $.tags"},
+		},
+		{
+			name:  "volatile headers are ignored by default",
+			left:  Exchange{StatusCode: 200, Headers: map[string]string{"Date": "Mon", "X-Request-Id": "a", "Set-Cookie": "s=1"}},
+			right: Exchange{StatusCode: 200, Headers: map[string]string{"Date": "Tue", "X-Request-Id": "b"}},
+		},
+		{
+			name:    "volatile headers are compared with IncludeAllHeaders",
+			left:    Exchange{StatusCode: 200, Headers: map[string]string{"Date": "Mon", "Set-Cookie": "s=1"}},
+			right:   Exchange{StatusCode: 200, Headers: map[string]string{"Date": "Tue"}},
+			options: Options{IncludeAllHeaders: true},
+			want:    []string{"header Date", "header Set-Cookie"},
+		},
+		{
+			name:    "ignored headers match any case",
+			left:    Exchange{StatusCode: 200, Headers: map[string]string{"X-Trace": "1", "ETag": "a"}},
+			right:   Exchange{StatusCode: 200, Headers: map[string]string{"x-trace": "2", "ETag": "b"}},
+			options: Options{IgnoreHeaders: []string{"x-TRACE"}},
+			want:    []string{"header Etag"},
+		},
+		{
+			name:    "bare field names match at any depth",
+			left:    Exchange{StatusCode: 200, Body: `{"request_id": "a", "user": {"request_id": "b", "name": "ann"}, "items": [{"request_id": "c"}]}`},
+			right:   Exchange{StatusCode: 200, Body: `{"request_id": "x", "user": {"request_id": "y", "name": "bob"}, "items": [{"request_id": "z"}]}`},
+			options: Options{IgnorePaths: []string{"request_id"}},
+			want:    []string{"body $.user.name"},
+		},
+		{
+			name:    "json paths match exactly",
+			left:    Exchange{StatusCode: 200, Body: `{"updated_at": "1", "user": {"updated_at": "2"}}`},
+			right:   Exchange{StatusCode: 200, Body: `{"updated_at": "3", "user": {"updated_at": "4"}}`},
+			options: Options{IgnorePaths: []string{"$.updated_at"}},
+			want:    []string{"body $.user.updated_at"},
+		},
+		{
+			name:    "wildcards match any key or index",
+			left:    Exchange{StatusCode: 200, Body: `{"items": [{"id": 1, "at": "a"}, {"id": 2, "at": "b"}], "meta": {"x": {"at": "c"}}}`},
+			right:   Exchange{StatusCode: 200, Body: `{"items": [{"id": 1, "at": "d"}, {"id": 3, "at": "e"}], "meta": {"x": {"at": "f"}}}`},
+			options: Options{IgnorePaths: []string{"$.items[*].at", "$.meta.*.at"}},
+			want:    []string{"body $.items[1].id"},
+		},
+		{
+			name:    "ignoring a field skips it when only one side has it",
+			left:    Exchange{StatusCode: 200, Body: `{"id": 1, "debug": {"trace": true}}`},
+			right:   Exchange{StatusCode: 200, Body: `{"id": 1}`},
+			options: Options{IgnorePaths: []string{"$.debug"}},
+		},
+		{
+			name:    "structure only compares keys and types",
+			left:    Exchange{StatusCode: 200, Headers: map[string]string{"ETag": "a"}, Body: `{"id": 1, "tags": ["a"], "name": "ann"}`},
+			right:   Exchange{StatusCode: 200, Headers: map[string]string{"ETag": "b"}, Body: `{"id": 2, "tags": ["b", "c"], "name": null, "extra": 1}`},
+			options: Options{StructureOnly: true},
+			want:    []string{"body $.extra", "body $.name"},
+		},
+		{
+			name:  "bodies that are not JSON are compared as text",
+			left:  Exchange{StatusCode: 200, Body: "ok"},
+			right: Exchange{StatusCode: 200, Body: "OK"},
+			want:  []string{"body $"},
+		},
+		{
+			name:  "request errors stop the comparison",
+			left:  Exchange{Error: "connection refused"},
+			right: Exchange{StatusCode: 200, Body: `{"id": 1}`},
+			want:  []string{"error "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.left.Name, tt.right.Name = "test", "test"
+			report := Compare("left", []Exchange{tt.left}, "right", []Exchange{tt.right}, tt.options)
+
+			var got []string
+			for _, test := range report.Tests {
+				for _, difference := range test.Differences {
+					got = append(got, string(difference.Kind)+" "+difference.Path)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("differences = %q, want %q", got, tt.want)
+			}
+			if identical := report.Identical == 1; identical != (len(tt.want) == 0) {
+				t.Errorf("Identical = %d with differences %q", report.Identical, got)
+			}
+		})
+	}
+}
+
+func TestCompareMatchesTestsByName(t *testing.T) {
+	left := []Exchange{{Name: "a", StatusCode: 200}, {Name: "b", StatusCode: 200}, {Name: "only left", StatusCode: 200}}
+	right := []Exchange{{Name: "only right", StatusCode: 200}, {Name: "b", StatusCode: 500}, {Name: "a", StatusCode: 200}}
+
+	report := Compare("prod", left, "staging", right, Options{})
+	if report.Compared != 4 || report.Identical != 1 {
+		t.Errorf("Compared = %d, Identical = %d, want 4 and 1", report.Compared, report.Identical)
+	}
+
+	var got []string
+	for _, test := range report.Tests {
+		got = append(got, test.Name+": "+test.Differences[0].Message)
+	}
+	want := []string{"b: status 200 vs 500", "only left: test only ran on prod", "only right: test only ran on staging"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tests = %q, want %q", got, want)
+	}
+}
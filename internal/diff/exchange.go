@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"github.com/Asadus16/comapi/internal/history"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// FromResults converts the executed tests of a suite run, leaving out skipped tests
+func FromResults(results []types.TestResult) []Exchange {
+	var exchanges []Exchange
+	for _, result := range results {
+		if result.Status == types.StatusSkip {
+			continue
+		}
+		exchanges = append(exchanges, Exchange{
+			Name:       result.TestName,
+			StatusCode: result.Response.StatusCode,
+			Headers:    result.Response.Headers,
+			Body:       result.Response.Body,
+			Error:      result.Error,
+		})
+	}
+	return exchanges
+}
+
+// FromRun converts the executed tests of a stored run, leaving out skipped tests
+func FromRun(run history.Run) []Exchange {
+	var exchanges []Exchange
+	for _, test := range run.Tests {
+		if test.Status == types.StatusSkip {
+			continue
+		}
+		exchanges = append(exchanges, Exchange{
+			Name:       test.Name,
+			StatusCode: test.StatusCode,
+			Headers:    test.Headers,
+			Body:       test.Body,
+			Error:      test.Error,
+		})
+	}
+	return exchanges
+}
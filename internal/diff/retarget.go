@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Retarget returns a copy of suite that sends every request to baseURL. The
+// suite's base URL is replaced, and tests with an absolute url keep their path
+// and query but take the scheme and host of baseURL, so both sides of a diff
+// really reach different environments. Tests whose url cannot be rewritten,
+// e.g. because its host is a {{placeholder}}, are reported by name.
+func Retarget(suite *types.TestSuite, baseURL string) (*types.TestSuite, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL '%s'", baseURL)
+	}
+
+	target := *suite
+	target.BaseURL = baseURL
+	target.Tests = make([]types.TestCase, len(suite.Tests))
+
+	var failed []string
+	for i, test := range suite.Tests {
+		if test.URL != "" {
+			retargeted, ok := retargetURL(test.URL, base)
+			if !ok {
+				failed = append(failed, test.Name)
+			}
+			test.URL = retargeted
+		}
+		target.Tests[i] = test
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("cannot point the url of these tests at %s: %s", baseURL, strings.Join(failed, ", "))
+	}
+	return &target, nil
+}
+
+// retargetURL swaps the scheme and host of raw for those of base. The rest is
+// kept as written so {{placeholders}} in the path still expand.
+func retargetURL(raw string, base *url.URL) (string, bool) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok || vars.HasPlaceholders(scheme) {
+		return raw, false
+	}
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	userinfo, host, hasUser := strings.Cut(authority, "@")
+	if !hasUser {
+		userinfo, host = "", authority
+	}
+	if host == "" || vars.HasPlaceholders(authority) {
+		return raw, false
+	}
+	if hasUser {
+		userinfo += "@"
+	}
+	return base.Scheme + "://" + userinfo + base.Host + path, true
+}
@@ -0,0 +1,77 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestRetarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		urls    []string
+		want    []string
+		error   string
+	}{
+		{
+			name:    "absolute urls take the scheme and host",
+			baseURL: "http://localhost:8081",
+			urls:    []string{"https://api.example.com/v1/users?page=2#top", "", "https://other.example.com:8443", "http://user@old.example.com/me"},
+			want:    []string{"http://localhost:8081/v1/users?page=2#top", "", "http://localhost:8081", "http://user@localhost:8081/me"},
+		},
+		{
+			name:    "the base URL path is not added and placeholders are kept",
+			baseURL: "https://staging.example.com/api",
+			urls:    []string{"https://api.example.com/users/{{id}}"},
+			want:    []string{"https://staging.example.com/users/{{id}}"},
+		},
+		{
+			name:    "placeholder hosts cannot be rewritten",
+			baseURL: "http://localhost:8081",
+			urls:    []string{"{{API}}/users", "https://api.example.com/ok", "/relative", "https://{{HOST}}/x"},
+			error:   "cannot point the url of these tests at http://localhost:8081: test 0, test 2, test 3",
+		},
+		{
+			name:    "invalid base URL",
+			baseURL: "localhost:8081",
+			error:   "invalid base URL 'localhost:8081'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := &types.TestSuite{Name: "s", BaseURL: "https://api.example.com"}
+			for i, u := range tt.urls {
+				suite.Tests = append(suite.Tests, types.TestCase{Name: fmt.Sprintf("test %d", i), Path: "/p", URL: u})
+			}
+
+			got, err := Retarget(suite, tt.baseURL)
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Fatalf("Retarget() error = %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Retarget() error = %v", err)
+			}
+
+			if got.BaseURL != tt.baseURL {
+				t.Errorf("base URL = %s, want %s", got.BaseURL, tt.baseURL)
+			}
+			var urls []string
+			for _, test := range got.Tests {
+				urls = append(urls, test.URL)
+			}
+			if !reflect.DeepEqual(urls, tt.want) {
+				t.Errorf("urls = %v, want %v", urls, tt.want)
+			}
+			if suite.BaseURL != "https://api.example.com" || suite.Tests[0].URL != tt.urls[0] {
+				t.Errorf("Retarget() modified the suite passed in")
+			}
+		})
+	}
+}
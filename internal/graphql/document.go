@@ -0,0 +1,647 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a parsed GraphQL query document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription
+type Operation struct {
+	Type       string // "query", "mutation" or "subscription"
+	Name       string
+	Variables  []VariableDefinition
+	Selections []Selection
+	Line       int
+}
+
+// VariableDefinition declares an operation variable such as $id: ID!
+type VariableDefinition struct {
+	Name       string
+	Type       TypeRef
+	HasDefault bool
+}
+
+// TypeRef is a variable type as written in the query
+type TypeRef struct {
+	Name    string   // Set for named types
+	OfType  *TypeRef // Set for lists and non-null types
+	List    bool
+	NonNull bool
+}
+
+// String formats the type as written in GraphQL, e.g. [ID!]!
+func (t TypeRef) String() string {
+	switch {
+	case t.NonNull:
+		return t.OfType.String() + "!"
+	case t.List:
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// NamedType returns the innermost type name
+func (t TypeRef) NamedType() string {
+	if t.OfType != nil {
+		return t.OfType.NamedType()
+	}
+	return t.Name
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Selections    []Selection
+	Line          int
+}
+
+// Selection is a field, a fragment spread or an inline fragment
+type Selection struct {
+	Field          *Field
+	FragmentSpread string
+	Inline         *InlineFragment
+	Line           int
+}
+
+// Field selects a field, optionally under an alias
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  []Argument
+	Selections []Selection
+	// DirectiveVariables are the variables used by the field's directives
+	DirectiveVariables []string
+}
+
+// InlineFragment is ... on Type { ... }; TypeCondition may be empty
+type InlineFragment struct {
+	TypeCondition string
+	Selections    []Selection
+}
+
+// Argument is a field or directive argument
+type Argument struct {
+	Name      string
+	Variables []string // Variables referenced by the value
+}
+
+// Parse parses a GraphQL query document
+func Parse(query string) (*Document, error) {
+	p := &parser{lexer: lexer{input: query, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	document := &Document{Fragments: make(map[string]*Fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.token.is(tokenPunct, "{"):
+			line := p.token.line
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			document.Operations = append(document.Operations, &Operation{Type: "query", Selections: selections, Line: line})
+		case p.token.is(tokenName, "query"), p.token.is(tokenName, "mutation"), p.token.is(tokenName, "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			document.Operations = append(document.Operations, operation)
+		case p.token.is(tokenName, "fragment"):
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := document.Fragments[fragment.Name]; exists {
+				return nil, fmt.Errorf("line %d: fragment '%s' is defined twice", fragment.Line, fragment.Name)
+			}
+			document.Fragments[fragment.Name] = fragment
+		default:
+			return nil, p.unexpected("an operation or fragment")
+		}
+	}
+
+	if len(document.Operations) == 0 {
+		return nil, fmt.Errorf("query has no operation")
+	}
+	return document, nil
+}
+
+// Operation returns the operation to execute: the one named name, or the only
+// one when name is empty
+func (d *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) > 1 {
+			return nil, fmt.Errorf("query has %d operations, operation_name is required", len(d.Operations))
+		}
+		return d.Operations[0], nil
+	}
+	for _, operation := range d.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("query has no operation named '%s'", name)
+}
+
+type parser struct {
+	lexer lexer
+	token token
+}
+
+func (p *parser) advance() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+// expect consumes a punctuator
+func (p *parser) expect(punct string) error {
+	if !p.token.is(tokenPunct, punct) {
+		return p.unexpected("'" + punct + "'")
+	}
+	return p.advance()
+}
+
+// name consumes a name token and returns it
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.unexpected("a name")
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) unexpected(want string) error {
+	if p.token.kind == tokenEOF {
+		return fmt.Errorf("line %d: expected %s, got end of query", p.token.line, want)
+	}
+	return fmt.Errorf("line %d: expected %s, got '%s'", p.token.line, want, p.token.value)
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{Type: p.token.value, Line: p.token.line}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenName {
+		operation.Name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.token.is(tokenPunct, "(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.token.is(tokenPunct, ")") {
+			definition, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			operation.Variables = append(operation.Variables, definition)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.Selections = selections
+	return operation, nil
+}
+
+func (p *parser) variableDefinition() (VariableDefinition, error) {
+	var definition VariableDefinition
+	if err := p.expect("$"); err != nil {
+		return definition, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return definition, err
+	}
+	definition.Name = name
+	if err := p.expect(":"); err != nil {
+		return definition, err
+	}
+	if definition.Type, err = p.typeRef(); err != nil {
+		return definition, err
+	}
+	if p.token.is(tokenPunct, "=") {
+		if err := p.advance(); err != nil {
+			return definition, err
+		}
+		if _, err := p.value(); err != nil {
+			return definition, err
+		}
+		definition.HasDefault = true
+	}
+	_, err = p.directives()
+	return definition, err
+}
+
+func (p *parser) typeRef() (TypeRef, error) {
+	var ref TypeRef
+	if p.token.is(tokenPunct, "[") {
+		if err := p.advance(); err != nil {
+			return ref, err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return ref, err
+		}
+		if err := p.expect("]"); err != nil {
+			return ref, err
+		}
+		ref = TypeRef{List: true, OfType: &inner}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return ref, err
+		}
+		ref = TypeRef{Name: name}
+	}
+
+	if p.token.is(tokenPunct, "!") {
+		if err := p.advance(); err != nil {
+			return ref, err
+		}
+		inner := ref
+		ref = TypeRef{NonNull: true, OfType: &inner}
+	}
+	return ref, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	fragment := &Fragment{Line: p.token.line}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("line %d: fragment cannot be named 'on'", fragment.Line)
+	}
+	fragment.Name = name
+	if !p.token.is(tokenName, "on") {
+		return nil, p.unexpected("'on'")
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if fragment.Selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []Selection
+	for !p.token.is(tokenPunct, "}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("line %d: selection set cannot be empty", p.token.line)
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (Selection, error) {
+	selection := Selection{Line: p.token.line}
+	if p.token.is(tokenPunct, "...") {
+		if err := p.advance(); err != nil {
+			return selection, err
+		}
+
+		// ... Name is a spread, ... on Type / ... @dir / ... { is inline
+		if p.token.kind == tokenName && p.token.value != "on" {
+			selection.FragmentSpread = p.token.value
+			if err := p.advance(); err != nil {
+				return selection, err
+			}
+			_, err := p.directives()
+			return selection, err
+		}
+
+		inline := &InlineFragment{}
+		if p.token.is(tokenName, "on") {
+			if err := p.advance(); err != nil {
+				return selection, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return selection, err
+			}
+			inline.TypeCondition = name
+		}
+		if _, err := p.directives(); err != nil {
+			return selection, err
+		}
+		selections, err := p.selectionSet()
+		if err != nil {
+			return selection, err
+		}
+		inline.Selections = selections
+		selection.Inline = inline
+		return selection, nil
+	}
+
+	field := &Field{}
+	name, err := p.name()
+	if err != nil {
+		return selection, err
+	}
+	if p.token.is(tokenPunct, ":") {
+		if err := p.advance(); err != nil {
+			return selection, err
+		}
+		field.Alias = name
+		if name, err = p.name(); err != nil {
+			return selection, err
+		}
+	}
+	field.Name = name
+
+	if field.Arguments, err = p.arguments(); err != nil {
+		return selection, err
+	}
+	directiveArgs, err := p.directives()
+	if err != nil {
+		return selection, err
+	}
+	for _, argument := range directiveArgs {
+		field.DirectiveVariables = append(field.DirectiveVariables, argument.Variables...)
+	}
+
+	if p.token.is(tokenPunct, "{") {
+		if field.Selections, err = p.selectionSet(); err != nil {
+			return selection, err
+		}
+	}
+	selection.Field = field
+	return selection, nil
+}
+
+func (p *parser) arguments() ([]Argument, error) {
+	if !p.token.is(tokenPunct, "(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var arguments []Argument
+	for !p.token.is(tokenPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		variables, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, Argument{Name: name, Variables: variables})
+	}
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("line %d: argument list cannot be empty", p.token.line)
+	}
+	return arguments, p.advance()
+}
+
+// directives skips @name(args) directives and returns their arguments
+func (p *parser) directives() ([]Argument, error) {
+	var arguments []Argument
+	for p.token.is(tokenPunct, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, args...)
+	}
+	return arguments, nil
+}
+
+// value skips a value and returns the variables it references
+func (p *parser) value() ([]string, error) {
+	switch {
+	case p.token.is(tokenPunct, "$"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	case p.token.is(tokenPunct, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var variables []string
+		for !p.token.is(tokenPunct, "]") {
+			inner, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			variables = append(variables, inner...)
+		}
+		return variables, p.advance()
+	case p.token.is(tokenPunct, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var variables []string
+		for !p.token.is(tokenPunct, "}") {
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			inner, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			variables = append(variables, inner...)
+		}
+		return variables, p.advance()
+	case p.token.kind == tokenName, p.token.kind == tokenNumber, p.token.kind == tokenString:
+		return nil, p.advance()
+	default:
+		return nil, p.unexpected("a value")
+	}
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenNumber
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+func (t token) is(kind tokenKind, value string) bool {
+	return t.kind == kind && t.value == value
+}
+
+type lexer struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+
+	start := l.pos
+	c := l.input[l.pos]
+	switch {
+	case strings.HasPrefix(l.input[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", line: l.line}, nil
+	case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunct, value: string(c), line: l.line}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || isLetter(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.input[start:l.pos], line: l.line}, nil
+	case c == '-' || isDigit(c):
+		l.pos++
+		for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || strings.IndexByte(".eE+-", l.input[l.pos]) >= 0) {
+			l.pos++
+		}
+		return token{kind: tokenNumber, value: l.input[start:l.pos], line: l.line}, nil
+	case c == '"':
+		return l.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+		return token{}, fmt.Errorf("line %d: unexpected character '%c'", l.line, r)
+	}
+}
+
+// string reads a "string" or a """block string"""
+func (l *lexer) string() (token, error) {
+	line := l.line
+	if strings.HasPrefix(l.input[l.pos:], `"""`) {
+		end := strings.Index(l.input[l.pos+3:], `"""`)
+		for end >= 0 && strings.HasSuffix(l.input[l.pos+3:l.pos+3+end], `\`) {
+			next := strings.Index(l.input[l.pos+3+end+3:], `"""`)
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += 3 + next
+		}
+		if end < 0 {
+			return token{}, fmt.Errorf("line %d: unterminated block string", line)
+		}
+		value := l.input[l.pos+3 : l.pos+3+end]
+		l.line += strings.Count(value, "\n")
+		l.pos += 3 + end + 3
+		return token{kind: tokenString, value: value, line: line}, nil
+	}
+
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return token{}, fmt.Errorf("line %d: unterminated string", line)
+		case '"':
+			l.pos++
+			return token{kind: tokenString, value: l.input[start:l.pos], line: line}, nil
+		}
+		l.pos++
+	}
+	return token{}, fmt.Errorf("line %d: unterminated string", line)
+}
+
+// skipIgnored skips white space, commas, byte order marks and comments
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.input) {
+		switch c := l.input[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
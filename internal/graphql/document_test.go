@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		operations int
+		fragments  int
+		err        string
+	}{
+		{name: "shorthand query", query: "{ users { id } }", operations: 1},
+		{name: "named query with variables", query: "query Get($id: ID!, $n: [Int!] = [1]) { user(id: $id) { id } }", operations: 1},
+		{name: "mutation", query: "mutation { createUser(input: {name: \"a\"}) { id } }", operations: 1},
+		{name: "fragments", query: "query { ...Top } fragment Top on Query { users { ...U } } fragment U on User { id }", operations: 1, fragments: 2},
+		{name: "inline fragment and directive", query: "{ node { ... on User @include(if: $all) { name } } }", operations: 1},
+		{name: "comments and commas", query: "# list\n{ users(first: 10,) { id, name } }", operations: 1},
+		{name: "block string argument", query: "{ search(text: \"\"\"a \\\"\"\" b\"\"\") { id } }", operations: 1},
+		{name: "several operations", query: "query A { a } query B { b }", operations: 2},
+		{name: "empty", query: "", err: "query has no operation"},
+		{name: "only a fragment", query: "fragment F on User { id }", err: "query has no operation"},
+		{name: "empty selection", query: "{ users { } }", err: "line 1: selection set cannot be empty"},
+		{name: "unclosed selection", query: "{ users { id }", err: "line 1: expected a name, got end of query"},
+		{name: "empty arguments", query: "{ user() { id } }", err: "line 1: argument list cannot be empty"},
+		{name: "fragment defined twice", query: "{ a } fragment F on Q { a } fragment F on Q { b }", err: "line 1: fragment 'F' is defined twice"},
+		{name: "fragment named on", query: "{ a } fragment on on Q { a }", err: "line 1: fragment cannot be named 'on'"},
+		{name: "unterminated string", query: "{ user(id: \"1) { id } }", err: "line 1: unterminated string"},
+		{name: "unexpected character", query: "{ users { id } }\n%", err: "line 2: unexpected character '%'"},
+		{name: "missing colon in variable", query: "query ($id ID) { a }", err: "line 1: expected ':', got 'ID'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Parse(tt.query)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(document.Operations) != tt.operations {
+				t.Errorf("operations = %d, want %d", len(document.Operations), tt.operations)
+			}
+			if len(document.Fragments) != tt.fragments {
+				t.Errorf("fragments = %d, want %d", len(document.Fragments), tt.fragments)
+			}
+		})
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	document, err := Parse("query Get($id: ID!, $tags: [String!]!, $first: Int = 10) { user(id: $id) { id } }")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	operation := document.Operations[0]
+	if operation.Type != "query" || operation.Name != "Get" {
+		t.Fatalf("operation = %s %s, want query Get", operation.Type, operation.Name)
+	}
+
+	want := []struct {
+		name       string
+		typ        string
+		named      string
+		hasDefault bool
+	}{
+		{"id", "ID!", "ID", false},
+		{"tags", "[String!]!", "String", false},
+		{"first", "Int", "Int", true},
+	}
+	if len(operation.Variables) != len(want) {
+		t.Fatalf("variables = %d, want %d", len(operation.Variables), len(want))
+	}
+	for i, w := range want {
+		got := operation.Variables[i]
+		if got.Name != w.name || got.Type.String() != w.typ || got.Type.NamedType() != w.named || got.HasDefault != w.hasDefault {
+			t.Errorf("variable %d = $%s: %s (%s, default %v), want $%s: %s (%s, default %v)",
+				i, got.Name, got.Type, got.Type.NamedType(), got.HasDefault, w.name, w.typ, w.named, w.hasDefault)
+		}
+	}
+}
+
+func TestDocumentOperation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		pick  string
+		want  string
+		err   string
+	}{
+		{name: "only operation", query: "query A { a }", want: "A"},
+		{name: "named", query: "query A { a } query B { b }", pick: "B", want: "B"},
+		{name: "name required", query: "query A { a } query B { b }", err: "query has 2 operations, operation_name is required"},
+		{name: "unknown name", query: "query A { a }", pick: "C", err: "query has no operation named 'C'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			operation, err := document.Operation(tt.pick)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Operation() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Operation() error = %v", err)
+			}
+			if operation.Name != tt.want {
+				t.Errorf("Operation() = %s, want %s", operation.Name, tt.want)
+			}
+		})
+	}
+}
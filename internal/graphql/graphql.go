@@ -0,0 +1,52 @@
+// Package graphql encodes GraphQL requests and checks queries, on their own or
+// against a schema obtained by introspection.
+package graphql
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// ContentType is sent with GraphQL POST requests
+const ContentType = "application/json"
+
+// Accept asks for the GraphQL over HTTP media type, falling back to JSON
+const Accept = "application/graphql-response+json, application/json"
+
+// payload is the request body defined by GraphQL over HTTP
+type payload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// Body encodes the request as the JSON body of a POST
+func Body(request types.GraphQLRequest) (string, error) {
+	data, err := json.Marshal(payload{
+		Query:         request.Query,
+		Variables:     request.Variables,
+		OperationName: request.OperationName,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Values encodes the request as the query parameters of a GET
+func Values(request types.GraphQLRequest) (url.Values, error) {
+	values := url.Values{"query": {request.Query}}
+	if len(request.Variables) > 0 {
+		data, err := json.Marshal(request.Variables)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("variables", string(data))
+	}
+	if request.OperationName != "" {
+		values.Set("operationName", request.OperationName)
+	}
+	return values, nil
+}
@@ -0,0 +1,222 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// IntrospectionQuery fetches everything Validate needs from a server
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name defaultValue type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      inputFields { name defaultValue type { ...TypeRef } }
+      possibleTypes { name }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+// Schema is the result of an introspection query
+type Schema struct {
+	QueryType        *namedRef  `json:"queryType"`
+	MutationType     *namedRef  `json:"mutationType"`
+	SubscriptionType *namedRef  `json:"subscriptionType"`
+	Types            []FullType `json:"types"`
+
+	byName map[string]*FullType
+}
+
+type namedRef struct {
+	Name string `json:"name"`
+}
+
+// FullType is a named type of the schema
+type FullType struct {
+	Kind          string       `json:"kind"` // OBJECT, INTERFACE, UNION, SCALAR, ENUM or INPUT_OBJECT
+	Name          string       `json:"name"`
+	Fields        []FieldDef   `json:"fields"`
+	InputFields   []InputValue `json:"inputFields"`
+	PossibleTypes []namedRef   `json:"possibleTypes"`
+}
+
+// FieldDef is a field of an object or interface type
+type FieldDef struct {
+	Name string       `json:"name"`
+	Args []InputValue `json:"args"`
+	Type SchemaType   `json:"type"`
+}
+
+// InputValue is an argument or input object field
+type InputValue struct {
+	Name         string     `json:"name"`
+	DefaultValue *string    `json:"defaultValue"`
+	Type         SchemaType `json:"type"`
+}
+
+// SchemaType references a type, wrapped in LIST and NON_NULL as needed
+type SchemaType struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *SchemaType `json:"ofType"`
+}
+
+// NamedType returns the innermost type name
+func (t SchemaType) NamedType() string {
+	if t.OfType != nil {
+		return t.OfType.NamedType()
+	}
+	return t.Name
+}
+
+// String formats the type as written in GraphQL, e.g. [ID!]!
+func (t SchemaType) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// LoadSchema reads an introspection result saved to a file, e.g. by
+// `comapi graphql introspect`
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema decodes an introspection result. Both the full response
+// ({"data": {"__schema": ...}}) and its data ({"__schema": ...}) are accepted.
+func ParseSchema(data []byte) (*Schema, error) {
+	var document struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Schema *Schema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %w", err)
+	}
+
+	schema := document.Schema
+	if document.Data != nil && document.Data.Schema != nil {
+		schema = document.Data.Schema
+	}
+	if schema == nil || schema.QueryType == nil {
+		return nil, fmt.Errorf("invalid introspection result: no __schema with a queryType")
+	}
+
+	schema.byName = make(map[string]*FullType, len(schema.Types))
+	for i := range schema.Types {
+		schema.byName[schema.Types[i].Name] = &schema.Types[i]
+	}
+	return schema, nil
+}
+
+// Type returns the named type, or nil if the schema has none
+func (s *Schema) Type(name string) *FullType {
+	return s.byName[name]
+}
+
+// rootType returns the type operations of the given kind start from
+func (s *Schema) rootType(operation string) *FullType {
+	var ref *namedRef
+	switch operation {
+	case "query":
+		ref = s.QueryType
+	case "mutation":
+		ref = s.MutationType
+	case "subscription":
+		ref = s.SubscriptionType
+	}
+	if ref == nil {
+		return nil
+	}
+	return s.Type(ref.Name)
+}
+
+// field returns the named field of an object or interface type
+func (t *FullType) field(name string) *FieldDef {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// isComposite reports whether selections can be made on the type
+func (t *FullType) isComposite() bool {
+	return t.Kind == "OBJECT" || t.Kind == "INTERFACE" || t.Kind == "UNION"
+}
+
+// Introspect runs IntrospectionQuery against endpoint and returns the response
+// body once it is known to hold a usable schema
+func Introspect(ctx context.Context, client *http.Client, endpoint string, headers map[string]string) ([]byte, error) {
+	body, err := Body(types.GraphQLRequest{Query: IntrospectionQuery, OperationName: "IntrospectionQuery"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", Accept)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("introspection failed with HTTP %d", resp.StatusCode)
+	}
+	if _, err := ParseSchema(data); err != nil {
+		if message := gjson.GetBytes(data, "errors.0.message"); message.Exists() {
+			return nil, fmt.Errorf("introspection failed: %s", message.String())
+		}
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,220 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks the operation that will run against the supplied variables
+// and, when schema is not nil, against the schema. Every problem found is returned.
+func Validate(document *Document, operationName string, variables map[string]interface{}, schema *Schema) []error {
+	operation, err := document.Operation(operationName)
+	if err != nil {
+		return []error{err}
+	}
+
+	v := &validation{document: document, schema: schema}
+	declared := make(map[string]VariableDefinition, len(operation.Variables))
+	for _, definition := range operation.Variables {
+		if _, exists := declared[definition.Name]; exists {
+			v.errorf(operation.Line, "variable $%s is declared twice", definition.Name)
+		}
+		declared[definition.Name] = definition
+	}
+
+	var root *FullType
+	if schema != nil {
+		if root = schema.rootType(operation.Type); root == nil {
+			v.errorf(operation.Line, "schema does not support %s operations", operation.Type)
+		}
+		for _, definition := range operation.Variables {
+			v.checkVariableType(operation.Line, definition)
+		}
+	}
+
+	v.selections(operation.Selections, root, map[string]bool{})
+
+	// Every variable used must be declared, and required ones must be given
+	for _, name := range sortedNames(v.used) {
+		if _, ok := declared[name]; !ok {
+			v.errorf(v.used[name], "variable $%s is not declared by the operation", name)
+		}
+	}
+	for _, definition := range operation.Variables {
+		if !definition.Type.NonNull || definition.HasDefault {
+			continue
+		}
+		if value, ok := variables[definition.Name]; !ok || value == nil {
+			v.errorf(operation.Line, "variable $%s of type %s is required but not in variables", definition.Name, definition.Type)
+		}
+	}
+
+	return v.errs
+}
+
+type validation struct {
+	document *Document
+	schema   *Schema
+	used     map[string]int // Variable name to the line it is first used on
+	errs     []error
+}
+
+func (v *validation) errorf(line int, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...)))
+}
+
+func (v *validation) use(line int, names []string) {
+	if v.used == nil {
+		v.used = make(map[string]int)
+	}
+	for _, name := range names {
+		if _, seen := v.used[name]; !seen {
+			v.used[name] = line
+		}
+	}
+}
+
+// checkVariableType makes sure a variable's type exists and can be used as input
+func (v *validation) checkVariableType(line int, definition VariableDefinition) {
+	name := definition.Type.NamedType()
+	t := v.schema.Type(name)
+	switch {
+	case t == nil:
+		v.errorf(line, "variable $%s has unknown type %s", definition.Name, name)
+	case t.Kind != "SCALAR" && t.Kind != "ENUM" && t.Kind != "INPUT_OBJECT":
+		v.errorf(line, "variable $%s cannot be of %s type %s", definition.Name, t.Kind, name)
+	}
+}
+
+// selections walks a selection set made on parent; parent is nil when there is
+// no schema or the parent type is unknown. fragments holds the fragments being
+// expanded so cycles are reported instead of followed.
+func (v *validation) selections(selections []Selection, parent *FullType, fragments map[string]bool) {
+	for _, selection := range selections {
+		switch {
+		case selection.Field != nil:
+			v.field(selection.Line, selection.Field, parent, fragments)
+
+		case selection.Inline != nil:
+			target := parent
+			if condition := selection.Inline.TypeCondition; condition != "" && v.schema != nil {
+				target = v.typeCondition(selection.Line, condition)
+			}
+			v.selections(selection.Inline.Selections, target, fragments)
+
+		default:
+			name := selection.FragmentSpread
+			fragment, ok := v.document.Fragments[name]
+			if !ok {
+				v.errorf(selection.Line, "fragment '%s' is not defined", name)
+				continue
+			}
+			if fragments[name] {
+				v.errorf(selection.Line, "fragment '%s' spreads itself", name)
+				continue
+			}
+			var target *FullType
+			if v.schema != nil {
+				target = v.typeCondition(fragment.Line, fragment.TypeCondition)
+			}
+			fragments[name] = true
+			v.selections(fragment.Selections, target, fragments)
+			delete(fragments, name)
+		}
+	}
+}
+
+// typeCondition returns the type named by "on Type", reporting it when unusable
+func (v *validation) typeCondition(line int, name string) *FullType {
+	t := v.schema.Type(name)
+	switch {
+	case t == nil:
+		v.errorf(line, "fragment type %s does not exist", name)
+		return nil
+	case !t.isComposite():
+		v.errorf(line, "fragment type %s is a %s, not an object, interface or union", name, t.Kind)
+		return nil
+	}
+	return t
+}
+
+// field checks a field selected on parent and walks its own selections
+func (v *validation) field(line int, field *Field, parent *FullType, fragments map[string]bool) {
+	for _, argument := range field.Arguments {
+		v.use(line, argument.Variables)
+	}
+	v.use(line, field.DirectiveVariables)
+
+	if parent == nil {
+		v.selections(field.Selections, nil, fragments)
+		return
+	}
+
+	switch {
+	case field.Name == "__typename":
+		if len(field.Selections) > 0 {
+			v.errorf(line, "field __typename cannot have a selection")
+		}
+		return
+	case parent == v.schema.rootType("query") && (field.Name == "__schema" || field.Name == "__type"):
+		// Introspection fields are not listed on the query type
+		typeName := "__Schema"
+		if field.Name == "__type" {
+			typeName = "__Type"
+		}
+		v.selections(field.Selections, v.schema.Type(typeName), fragments)
+		return
+	}
+
+	definition := parent.field(field.Name)
+	if definition == nil {
+		v.errorf(line, "type %s has no field '%s'", parent.Name, field.Name)
+		v.selections(field.Selections, nil, fragments)
+		return
+	}
+
+	// Arguments must exist, and required ones must be given
+	given := make(map[string]bool, len(field.Arguments))
+	for _, argument := range field.Arguments {
+		given[argument.Name] = true
+		if !hasArgument(definition.Args, argument.Name) {
+			v.errorf(line, "field %s.%s has no argument '%s'", parent.Name, field.Name, argument.Name)
+		}
+	}
+	for _, arg := range definition.Args {
+		if arg.Type.Kind == "NON_NULL" && arg.DefaultValue == nil && !given[arg.Name] {
+			v.errorf(line, "field %s.%s requires argument '%s' of type %s", parent.Name, field.Name, arg.Name, arg.Type)
+		}
+	}
+
+	// Leaf fields take no selection, composite ones need one
+	fieldType := v.schema.Type(definition.Type.NamedType())
+	switch {
+	case fieldType == nil:
+		v.selections(field.Selections, nil, fragments)
+	case fieldType.isComposite() && len(field.Selections) == 0:
+		v.errorf(line, "field %s.%s of type %s needs a selection of subfields", parent.Name, field.Name, definition.Type)
+	case !fieldType.isComposite() && len(field.Selections) > 0:
+		v.errorf(line, "field %s.%s of type %s cannot have a selection", parent.Name, field.Name, definition.Type)
+	default:
+		v.selections(field.Selections, fieldType, fragments)
+	}
+}
+
+func hasArgument(args []InputValue, name string) bool {
+	for _, arg := range args {
+		if arg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(values map[string]int) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,216 @@
+package graphql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testSchema is a trimmed introspection result:
+//
+//	type Query { user(id: ID!): User, users(first: Int): [User!]!, node(id: ID!): Node }
+//	type Mutation { createUser(input: UserInput!): User }
+//	interface Node { id: ID! }
+//	type User implements Node { id: ID!, name: String, friends: [User] }
+//	input UserInput { name: String! }
+const testSchema = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": null,
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "user", "args": [{"name": "id", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}}],
+       "type": {"kind": "OBJECT", "name": "User", "ofType": null}},
+      {"name": "users", "args": [{"name": "first", "defaultValue": null, "type": {"kind": "SCALAR", "name": "Int", "ofType": null}}],
+       "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "LIST", "name": null, "ofType": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "OBJECT", "name": "User", "ofType": null}}}}},
+      {"name": "node", "args": [{"name": "id", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}}],
+       "type": {"kind": "INTERFACE", "name": "Node", "ofType": null}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "createUser", "args": [{"name": "input", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "INPUT_OBJECT", "name": "UserInput", "ofType": null}}}],
+       "type": {"kind": "OBJECT", "name": "User", "ofType": null}}
+    ]},
+    {"kind": "INTERFACE", "name": "Node", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}}
+    ], "possibleTypes": [{"name": "User"}]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}},
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}},
+      {"name": "friends", "args": [], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User", "ofType": null}}}
+    ]},
+    {"kind": "INPUT_OBJECT", "name": "UserInput", "inputFields": [
+      {"name": "name", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "String", "ofType": null}}}
+    ]},
+    {"kind": "SCALAR", "name": "ID"},
+    {"kind": "SCALAR", "name": "Int"},
+    {"kind": "SCALAR", "name": "String"},
+    {"kind": "SCALAR", "name": "Boolean"},
+    {"kind": "OBJECT", "name": "__Schema", "fields": [
+      {"name": "queryType", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "OBJECT", "name": "__Type", "ofType": null}}}
+    ]},
+    {"kind": "OBJECT", "name": "__Type", "fields": [
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}}
+    ]}
+  ]
+}}}`
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		noSchema  bool
+		want      []string
+	}{
+		{
+			name:      "valid query",
+			query:     "query Get($id: ID!) { user(id: $id) { id name friends { name } } }",
+			variables: map[string]interface{}{"id": "1"},
+		},
+		{
+			name:  "fragments and typename",
+			query: "{ users(first: 2) { ...Fields __typename } } fragment Fields on User { id ... on Node { id } }",
+		},
+		{
+			name:  "introspection fields",
+			query: "{ __schema { queryType { name } } __type { name } }",
+		},
+		{
+			name:      "mutation with input variable",
+			query:     "mutation Create($input: UserInput!) { createUser(input: $input) { id } }",
+			variables: map[string]interface{}{"input": map[string]interface{}{"name": "a"}},
+		},
+		{
+			name:  "unknown field",
+			query: "{ users { id email } }",
+			want:  []string{"line 1: type User has no field 'email'"},
+		},
+		{
+			name:  "unknown and missing arguments",
+			query: "{ user(name: \"a\") { id } }",
+			want: []string{
+				"line 1: field Query.user has no argument 'name'",
+				"line 1: field Query.user requires argument 'id' of type ID!",
+			},
+		},
+		{
+			name:  "composite field without selection",
+			query: "{ users }",
+			want:  []string{"line 1: field Query.users of type [User!]! needs a selection of subfields"},
+		},
+		{
+			name:  "leaf field with selection",
+			query: "{ users {\n  name { first }\n} }",
+			want:  []string{"line 2: field User.name of type String cannot have a selection"},
+		},
+		{
+			name:  "required variable missing",
+			query: "query ($id: ID!) { user(id: $id) { id } }",
+			want:  []string{"line 1: variable $id of type ID! is required but not in variables"},
+		},
+		{
+			name:  "undeclared variable",
+			query: "{ users(first: $n) { id } }",
+			want:  []string{"line 1: variable $n is not declared by the operation"},
+		},
+		{
+			name:      "variable of output type",
+			query:     "query ($u: User) { users { id } }",
+			variables: map[string]interface{}{},
+			want:      []string{"line 1: variable $u cannot be of OBJECT type User"},
+		},
+		{
+			name:  "variable of unknown type",
+			query: "query ($n: Long) { users(first: $n) { id } }",
+			want:  []string{"line 1: variable $n has unknown type Long"},
+		},
+		{
+			name:  "unsupported operation",
+			query: "subscription { users { id } }",
+			want:  []string{"line 1: schema does not support subscription operations"},
+		},
+		{
+			name:  "unknown fragment type",
+			query: "{ users { ... on Admin { id } } }",
+			want:  []string{"line 1: fragment type Admin does not exist"},
+		},
+		{
+			name:  "fragment on scalar",
+			query: "{ users { ...F } } fragment F on String { id }",
+			want:  []string{"line 1: fragment type String is a SCALAR, not an object, interface or union"},
+		},
+		{
+			name:  "undefined and cyclic fragments",
+			query: "{ users { ...Missing ...A } } fragment A on User { ...A }",
+			want: []string{
+				"line 1: fragment 'Missing' is not defined",
+				"line 1: fragment 'A' spreads itself",
+			},
+		},
+		{
+			name:      "operation must be chosen",
+			query:     "query A { users { id } } query B { users { id } }",
+			operation: "C",
+			want:      []string{"query has no operation named 'C'"},
+		},
+		{
+			name:     "without a schema only variables are checked",
+			query:    "query ($id: ID!) { anything(id: $id, other: $x) { goes } }",
+			noSchema: true,
+			want: []string{
+				"line 1: variable $x is not declared by the operation",
+				"line 1: variable $id of type ID! is required but not in variables",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			s := schema
+			if tt.noSchema {
+				s = nil
+			}
+			var got []string
+			for _, err := range Validate(document, tt.operation, tt.variables, s) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "full response", data: `{"data": {"__schema": {"queryType": {"name": "Query"}, "types": []}}}`},
+		{name: "data only", data: `{"__schema": {"queryType": {"name": "Query"}, "types": []}}`},
+		{name: "no query type", data: `{"__schema": {"types": []}}`, err: "invalid introspection result: no __schema with a queryType"},
+		{name: "not JSON", data: `type Query { a: Int }`, err: "invalid introspection result: invalid character 'y' in literal true (expecting 'r')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema([]byte(tt.data))
+			if tt.err == "" && err != nil {
+				t.Fatalf("ParseSchema() error = %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("ParseSchema() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+// Package grpc invokes unary gRPC methods without generated code. Message
+// types come from server reflection or from .proto files, and messages are
+// converted to and from JSON with proto field names.
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ContentType is sent with every call
+const ContentType = "application/grpc"
+
+// maxMessageSize bounds a single response message, like gRPC's own default
+const maxMessageSize = 4 << 20
+
+// Client calls unary methods over HTTP/2, caching the descriptors it resolves
+type Client struct {
+	http *http.Client
+
+	mu          sync.Mutex
+	descriptors map[string]*protoregistry.Files
+}
+
+// NewClient derives an HTTP/2 client from client, keeping its timeout and
+// dialer. Plain http:// targets use HTTP/2 without TLS (h2c).
+func NewClient(client *http.Client) *Client {
+	derived := *client
+	switch transport := client.Transport.(type) {
+	case nil:
+		derived.Transport = http2Transport(http.DefaultTransport.(*http.Transport))
+	case *http.Transport:
+		derived.Transport = http2Transport(transport)
+	}
+	return &Client{http: &derived, descriptors: make(map[string]*protoregistry.Files)}
+}
+
+func http2Transport(transport *http.Transport) *http.Transport {
+	clone := transport.Clone()
+	clone.Protocols = new(http.Protocols)
+	clone.Protocols.SetHTTP2(true)
+	clone.Protocols.SetUnencryptedHTTP2(true)
+	return clone
+}
+
+// Response is the outcome of a call that reached the server
+type Response struct {
+	Code     int               // gRPC status code, 0 for OK
+	Message  string            // grpc-message, set when the call failed
+	Metadata map[string]string // Response headers and trailers
+	Body     string            // The response message, or the status as JSON when the call failed
+	Size     int64             // Bytes of the response message on the wire
+	Proto    string
+}
+
+// Invoke calls request.Method of request.Service on target, e.g.
+// http://localhost:50051, sending headers as metadata
+func (c *Client) Invoke(ctx context.Context, target string, request types.GRPCRequest, headers map[string]string) (*Response, error) {
+	method, files, err := c.Resolve(ctx, target, request)
+	if err != nil {
+		return nil, err
+	}
+	input, err := NewMessage(method, files, request.Message)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := MethodURL(target, request.Service, request.Method)
+	result, err := c.call(ctx, endpoint, headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		Code:     result.code,
+		Message:  result.message,
+		Metadata: result.metadata,
+		Proto:    result.proto,
+	}
+	if result.code != 0 {
+		response.Body = errorBody(result.code, result.message)
+		return response, nil
+	}
+	if len(result.messages) != 1 {
+		return nil, fmt.Errorf("expected one response message, got %d", len(result.messages))
+	}
+
+	output := dynamicpb.NewMessage(method.Output())
+	if err := proto.Unmarshal(result.messages[0], output); err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", method.Output().FullName(), err)
+	}
+	body, err := protojson.MarshalOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+		Resolver:        dynamicpb.NewTypes(files),
+	}.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	// protojson varies its spacing on purpose; keep bodies stable for diffs and history
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err != nil {
+		return nil, err
+	}
+	response.Body = compact.String()
+	response.Size = int64(len(result.messages[0]))
+	return response, nil
+}
+
+// MethodURL returns the URL a method is called at
+func MethodURL(target, service, method string) string {
+	return strings.TrimRight(target, "/") + "/" + service + "/" + method
+}
+
+// errorBody describes a failed call so json_path assertions can check it
+func errorBody(code int, message string) string {
+	body, _ := json.Marshal(map[string]interface{}{
+		"code":    code,
+		"status":  CodeName(code),
+		"message": message,
+	})
+	return string(body)
+}
+
+// Resolve finds the method's descriptor in the request's .proto files, or
+// through server reflection when it has none. Descriptors are cached.
+func (c *Client) Resolve(ctx context.Context, target string, request types.GRPCRequest) (protoreflect.MethodDescriptor, *protoregistry.Files, error) {
+	var key string
+	if len(request.ProtoFiles) > 0 {
+		key = "files:" + strings.Join(request.ProtoFiles, "\x00") + "|" + strings.Join(request.ImportPaths, "\x00")
+	} else {
+		key = "reflection:" + target + "|" + request.Service
+	}
+
+	c.mu.Lock()
+	files, ok := c.descriptors[key]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		if len(request.ProtoFiles) > 0 {
+			files, err = ParseProtoFiles(request.ProtoFiles, request.ImportPaths)
+		} else {
+			files, err = c.reflect(ctx, target, request.Service)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		c.mu.Lock()
+		c.descriptors[key] = files
+		c.mu.Unlock()
+	}
+
+	method, err := FindMethod(files, request.Service, request.Method)
+	if err != nil {
+		return nil, nil, err
+	}
+	return method, files, nil
+}
+
+// FindMethod looks up a unary method of a fully qualified service
+func FindMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found", service)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("service %s has no method %s", service, method)
+	}
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return nil, fmt.Errorf("%s.%s is a streaming method; only unary methods are supported", service, method)
+	}
+	return methodDescriptor, nil
+}
+
+// NewMessage builds the method's input from a mapping or JSON text
+func NewMessage(method protoreflect.MethodDescriptor, files *protoregistry.Files, message interface{}) (proto.Message, error) {
+	var data []byte
+	switch value := message.(type) {
+	case nil:
+		data = []byte("{}")
+	case string:
+		data = []byte(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		data = encoded
+	}
+
+	input := dynamicpb.NewMessage(method.Input())
+	options := protojson.UnmarshalOptions{Resolver: dynamicpb.NewTypes(files)}
+	if err := options.Unmarshal(data, input); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", method.Input().FullName(), err)
+	}
+	return input, nil
+}
+
+// callResult is what came back from a call, before decoding
+type callResult struct {
+	code     int
+	message  string
+	metadata map[string]string
+	messages [][]byte
+	proto    string
+}
+
+// call sends one framed message to endpoint and reads every message and the status back
+func (c *Client) call(ctx context.Context, endpoint string, headers map[string]string, payload []byte) (*callResult, error) {
+	frame := make([]byte, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Grpc-Accept-Encoding", "gzip")
+	req.Header.Del("Content-Length")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &callResult{proto: resp.Proto}
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(contentType, ContentType) {
+		// Not a gRPC response, unless the server sent a status anyway
+		if resp.Header.Get("Grpc-Status") == "" {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxMessageSize))
+			result.code = codeFromHTTP(resp.StatusCode)
+			result.message = fmt.Sprintf("unexpected HTTP %d response with content type %q", resp.StatusCode, contentType)
+			result.metadata = metadata(resp.Header, nil)
+			return result, nil
+		}
+	}
+
+	compressed := resp.Header.Get("Grpc-Encoding")
+	for {
+		message, err := readMessage(resp.Body, compressed)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.messages = append(result.messages, message)
+	}
+
+	// Trailers are only known once the body is drained; a trailers-only
+	// response carries the status in its headers
+	status := resp.Trailer.Get("Grpc-Status")
+	result.message = resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status = resp.Header.Get("Grpc-Status")
+		result.message = resp.Header.Get("Grpc-Message")
+	}
+	if status == "" {
+		return nil, fmt.Errorf("response has no grpc-status")
+	}
+	if result.code, err = strconv.Atoi(status); err != nil {
+		return nil, fmt.Errorf("invalid grpc-status %q", status)
+	}
+	if decoded, err := url.PathUnescape(result.message); err == nil {
+		result.message = decoded
+	}
+	result.metadata = metadata(resp.Header, resp.Trailer)
+	return result, nil
+}
+
+// readMessage reads one length-prefixed message
+func readMessage(body io.Reader, encoding string) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated response message")
+		}
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("response message of %d bytes is too large", length)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, fmt.Errorf("truncated response message")
+	}
+	if prefix[0] == 0 {
+		return message, nil
+	}
+
+	if encoding != "gzip" {
+		return nil, fmt.Errorf("response message compressed with unsupported encoding %q", encoding)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(reader, maxMessageSize))
+}
+
+// metadata merges response headers and trailers, trailers winning
+func metadata(headers, trailers http.Header) map[string]string {
+	result := make(map[string]string, len(headers)+len(trailers))
+	for _, source := range []http.Header{headers, trailers} {
+		for name, values := range source {
+			if len(values) > 0 {
+				result[name] = values[0]
+			}
+		}
+	}
+	return result
+}
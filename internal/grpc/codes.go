@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// codeNames are the canonical gRPC status code names, indexed by code
+var codeNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// Status codes the client itself reports
+const (
+	codeUnknown       = 2
+	codeUnimplemented = 12
+	codeInternal      = 13
+	codeUnavailable   = 14
+)
+
+// CodeName returns the name of a status code, e.g. NOT_FOUND for 5
+func CodeName(code int) string {
+	if code >= 0 && code < len(codeNames) {
+		return codeNames[code]
+	}
+	return "CODE_" + strconv.Itoa(code)
+}
+
+// ParseCode accepts a status code name in any case, e.g. "not_found"
+func ParseCode(name string) (int, bool) {
+	for code, codeName := range codeNames {
+		if strings.EqualFold(codeName, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// codeFromHTTP maps the HTTP status of a response without a grpc-status, as
+// the gRPC spec describes for proxies and servers that are not gRPC
+func codeFromHTTP(status int) int {
+	switch status {
+	case http.StatusBadRequest:
+		return codeInternal
+	case http.StatusUnauthorized:
+		return 16
+	case http.StatusForbidden:
+		return 7
+	case http.StatusNotFound:
+		return codeUnimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codeUnavailable
+	default:
+		return codeUnknown
+	}
+}
@@ -0,0 +1,876 @@
+package grpc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ParseProtoFiles parses .proto files and everything they import. Imports are
+// searched for in importPaths, then next to the file that imports them, and
+// the well-known google/protobuf types are built in. Options, extensions and
+// default values are accepted but ignored.
+func ParseProtoFiles(files []string, importPaths []string) (*protoregistry.Files, error) {
+	loader := &protoLoader{importPaths: importPaths, loaded: make(map[string]bool), loading: make(map[string]bool)}
+	for _, file := range files {
+		if err := loader.load(filepath.Base(file), file); err != nil {
+			return nil, err
+		}
+	}
+
+	registry, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: loader.files})
+	if err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// protoLoader collects parsed files in dependency order
+type protoLoader struct {
+	importPaths []string
+	files       []*descriptorpb.FileDescriptorProto
+	loaded      map[string]bool
+	loading     map[string]bool
+}
+
+// load parses the file at path, registered under name as imports refer to it
+func (l *protoLoader) load(name, path string) error {
+	if l.loaded[name] {
+		return nil
+	}
+	if l.loading[name] {
+		return fmt.Errorf("%s: import cycle", path)
+	}
+	l.loading[name] = true
+	defer delete(l.loading, name)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file, err := parseProto(name, string(content))
+	if err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+
+	for _, dependency := range file.Dependency {
+		if err := l.loadImport(dependency, filepath.Dir(path)); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	l.loaded[name] = true
+	l.files = append(l.files, file)
+	return nil
+}
+
+// loadImport finds an imported file on disk, or among the built-in well-known types
+func (l *protoLoader) loadImport(name, importerDir string) error {
+	if l.loaded[name] {
+		return nil
+	}
+	for _, dir := range append(append([]string{}, l.importPaths...), importerDir) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(path); err == nil {
+			return l.load(name, path)
+		}
+	}
+
+	builtin, err := protoregistry.GlobalFiles.FindFileByPath(name)
+	if err != nil {
+		return fmt.Errorf("import %q not found", name)
+	}
+	l.loaded[name] = true
+	l.files = append(l.files, protodesc.ToFileDescriptorProto(builtin))
+	return nil
+}
+
+// scalarTypes maps .proto scalar type names to descriptor types
+var scalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"double":   descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"int64":    descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint64":   descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"int32":    descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"fixed64":  descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+	"fixed32":  descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+	"bool":     descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	"uint32":   descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"sfixed32": descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+	"sint32":   descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+}
+
+// parseProto parses the text of one .proto file
+func parseProto(name, content string) (*descriptorpb.FileDescriptorProto, error) {
+	p := &protoParser{lexer: protoLexer{input: content, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	file := &descriptorpb.FileDescriptorProto{Name: proto.String(name)}
+	syntax := "proto2"
+	if p.is("syntax") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if value != "proto2" && value != "proto3" {
+			return nil, fmt.Errorf("%d: unsupported syntax %q", p.token.line, value)
+		}
+		syntax = value
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+	}
+	if syntax == "proto3" {
+		file.Syntax = proto.String(syntax)
+	}
+	p.proto3 = syntax == "proto3"
+
+	for p.token.kind != protoEOF {
+		switch {
+		case p.is(";"):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case p.is("package"):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.fullIdent()
+			if err != nil {
+				return nil, err
+			}
+			file.Package = proto.String(name)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.is("import"):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.is("public") || p.is("weak") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+			path, err := p.stringLiteral()
+			if err != nil {
+				return nil, err
+			}
+			file.Dependency = append(file.Dependency, path)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.is("option"):
+			if err := p.skipOption(); err != nil {
+				return nil, err
+			}
+		case p.is("message"):
+			message, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			file.MessageType = append(file.MessageType, message)
+		case p.is("enum"):
+			enum, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			file.EnumType = append(file.EnumType, enum)
+		case p.is("service"):
+			service, err := p.service()
+			if err != nil {
+				return nil, err
+			}
+			file.Service = append(file.Service, service)
+		case p.is("extend"):
+			if err := p.skipDeclaration(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.unexpected("a top-level declaration")
+		}
+	}
+	return file, nil
+}
+
+type protoParser struct {
+	lexer  protoLexer
+	token  protoToken
+	proto3 bool
+}
+
+func (p *protoParser) advance() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+// is reports whether the current token is the identifier or punctuation value
+func (p *protoParser) is(value string) bool {
+	return (p.token.kind == protoIdent || p.token.kind == protoPunct) && p.token.value == value
+}
+
+func (p *protoParser) expect(value string) error {
+	if !p.is(value) {
+		return p.unexpected("'" + value + "'")
+	}
+	return p.advance()
+}
+
+func (p *protoParser) unexpected(want string) error {
+	if p.token.kind == protoEOF {
+		return fmt.Errorf("%d: expected %s, got end of file", p.token.line, want)
+	}
+	return fmt.Errorf("%d: expected %s, got '%s'", p.token.line, want, p.token.value)
+}
+
+func (p *protoParser) ident() (string, error) {
+	if p.token.kind != protoIdent {
+		return "", p.unexpected("a name")
+	}
+	value := p.token.value
+	return value, p.advance()
+}
+
+// fullIdent reads a possibly dotted, possibly fully qualified name
+func (p *protoParser) fullIdent() (string, error) {
+	var name strings.Builder
+	if p.is(".") {
+		name.WriteString(".")
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+	}
+	for {
+		part, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		name.WriteString(part)
+		if !p.is(".") {
+			return name.String(), nil
+		}
+		name.WriteString(".")
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *protoParser) stringLiteral() (string, error) {
+	if p.token.kind != protoString {
+		return "", p.unexpected("a string")
+	}
+	value := p.token.value
+	if err := p.advance(); err != nil {
+		return "", err
+	}
+	// Adjacent strings are concatenated
+	for p.token.kind == protoString {
+		value += p.token.value
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+func (p *protoParser) number() (int32, error) {
+	if p.token.kind != protoNumber {
+		return 0, p.unexpected("a number")
+	}
+	value, err := strconv.ParseInt(p.token.value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%d: invalid number %s", p.token.line, p.token.value)
+	}
+	return int32(value), p.advance()
+}
+
+// signedNumber reads an enum value, which may be negative
+func (p *protoParser) signedNumber() (int32, error) {
+	negative := p.is("-")
+	if negative {
+		if err := p.advance(); err != nil {
+			return 0, err
+		}
+	}
+	value, err := p.number()
+	if negative {
+		value = -value
+	}
+	return value, err
+}
+
+// skipOption skips "option name = value;"
+func (p *protoParser) skipOption() error {
+	return p.skipDeclaration()
+}
+
+// skipDeclaration skips tokens up to the end of a statement or a balanced block
+func (p *protoParser) skipDeclaration() error {
+	depth := 0
+	for p.token.kind != protoEOF {
+		switch {
+		case p.is("{"):
+			depth++
+		case p.is("}"):
+			depth--
+			if depth == 0 {
+				return p.advance()
+			}
+		case p.is(";") && depth == 0:
+			return p.advance()
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	return p.unexpected("';'")
+}
+
+// skipFieldOptions skips a [name = value, ...] list
+func (p *protoParser) skipFieldOptions() error {
+	if !p.is("[") {
+		return nil
+	}
+	depth := 0
+	for p.token.kind != protoEOF {
+		switch {
+		case p.is("[") || p.is("{"):
+			depth++
+		case p.is("]") || p.is("}"):
+			depth--
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+	return p.unexpected("']'")
+}
+
+// skipReserved skips reserved and extensions ranges
+func (p *protoParser) skipReserved() error {
+	return p.skipDeclaration()
+}
+
+func (p *protoParser) message() (*descriptorpb.DescriptorProto, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	message := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var syntheticOneofs []*descriptorpb.OneofDescriptorProto
+	for !p.is("}") {
+		switch {
+		case p.token.kind == protoEOF:
+			return nil, p.unexpected("'}'")
+		case p.is(";"):
+			err = p.advance()
+		case p.is("option"):
+			err = p.skipOption()
+		case p.is("reserved"), p.is("extensions"), p.is("extend"):
+			err = p.skipReserved()
+		case p.is("message"):
+			var nested *descriptorpb.DescriptorProto
+			if nested, err = p.message(); err == nil {
+				message.NestedType = append(message.NestedType, nested)
+			}
+		case p.is("enum"):
+			var enum *descriptorpb.EnumDescriptorProto
+			if enum, err = p.enum(); err == nil {
+				message.EnumType = append(message.EnumType, enum)
+			}
+		case p.is("oneof"):
+			err = p.oneof(message)
+		case p.is("map"):
+			err = p.mapField(message)
+		default:
+			var field *descriptorpb.FieldDescriptorProto
+			if field, err = p.field(true); err == nil {
+				// proto3 optional fields live in a oneof of their own
+				if field.GetProto3Optional() {
+					field.OneofIndex = proto.Int32(int32(len(syntheticOneofs)))
+					syntheticOneofs = append(syntheticOneofs, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + field.GetName())})
+				}
+				message.Field = append(message.Field, field)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Synthetic oneofs must follow the declared ones
+	for _, field := range message.Field {
+		if field.GetProto3Optional() {
+			field.OneofIndex = proto.Int32(field.GetOneofIndex() + int32(len(message.OneofDecl)))
+		}
+	}
+	message.OneofDecl = append(message.OneofDecl, syntheticOneofs...)
+	return message, p.advance()
+}
+
+// field parses "[label] type name = number [options];"
+func (p *protoParser) field(allowLabel bool) (*descriptorpb.FieldDescriptorProto, error) {
+	field := &descriptorpb.FieldDescriptorProto{Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()}
+	if allowLabel {
+		switch {
+		case p.is("repeated"):
+			field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		case p.is("required"):
+			field.Label = descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum()
+		case p.is("optional"):
+			if p.proto3 {
+				field.Proto3Optional = proto.Bool(true)
+			}
+		}
+		if p.is("repeated") || p.is("required") || p.is("optional") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.is("group") {
+		return nil, fmt.Errorf("%d: groups are not supported", p.token.line)
+	}
+
+	typeName, err := p.fullIdent()
+	if err != nil {
+		return nil, err
+	}
+	if scalar, ok := scalarTypes[typeName]; ok {
+		field.Type = scalar.Enum()
+	} else {
+		// Resolved to a message or enum when the descriptors are built
+		field.TypeName = proto.String(typeName)
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	field.Name = proto.String(name)
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	field.Number = proto.Int32(number)
+	if err := p.skipFieldOptions(); err != nil {
+		return nil, err
+	}
+	return field, p.expect(";")
+}
+
+// mapField parses "map<K, V> name = number;" into a repeated field of a nested entry message
+func (p *protoParser) mapField(message *descriptorpb.DescriptorProto) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.expect("<"); err != nil {
+		return err
+	}
+	keyType, err := p.ident()
+	if err != nil {
+		return err
+	}
+	keyScalar, ok := scalarTypes[keyType]
+	if !ok {
+		return fmt.Errorf("%d: invalid map key type %s", p.token.line, keyType)
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	valueType, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(">"); err != nil {
+		return err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	number, err := p.number()
+	if err != nil {
+		return err
+	}
+	if err := p.skipFieldOptions(); err != nil {
+		return err
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	entryName := mapEntryName(name)
+	value := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String("value"),
+		Number: proto.Int32(2),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if scalar, ok := scalarTypes[valueType]; ok {
+		value.Type = scalar.Enum()
+	} else {
+		value.TypeName = proto.String(valueType)
+	}
+	message.NestedType = append(message.NestedType, &descriptorpb.DescriptorProto{
+		Name: proto.String(entryName),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("key"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: keyScalar.Enum()},
+			value,
+		},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	})
+	message.Field = append(message.Field, &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(entryName),
+	})
+	return nil
+}
+
+// mapEntryName follows protoc: my_field becomes MyFieldEntry
+func mapEntryName(field string) string {
+	var name strings.Builder
+	upper := true
+	for _, r := range field {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			name.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			name.WriteRune(r)
+		}
+	}
+	return name.String() + "Entry"
+}
+
+func (p *protoParser) oneof(message *descriptorpb.DescriptorProto) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	index := int32(len(message.OneofDecl))
+	message.OneofDecl = append(message.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(name)})
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.is("}") {
+		switch {
+		case p.token.kind == protoEOF:
+			return p.unexpected("'}'")
+		case p.is(";"):
+			err = p.advance()
+		case p.is("option"):
+			err = p.skipOption()
+		default:
+			var field *descriptorpb.FieldDescriptorProto
+			if field, err = p.field(false); err == nil {
+				field.OneofIndex = proto.Int32(index)
+				message.Field = append(message.Field, field)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return p.advance()
+}
+
+func (p *protoParser) enum() (*descriptorpb.EnumDescriptorProto, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	enum := &descriptorpb.EnumDescriptorProto{Name: proto.String(name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		switch {
+		case p.token.kind == protoEOF:
+			return nil, p.unexpected("'}'")
+		case p.is(";"):
+			err = p.advance()
+		case p.is("option"), p.is("reserved"):
+			err = p.skipDeclaration()
+		default:
+			var valueName string
+			var number int32
+			if valueName, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err = p.expect("="); err != nil {
+				return nil, err
+			}
+			if number, err = p.signedNumber(); err != nil {
+				return nil, err
+			}
+			if err = p.skipFieldOptions(); err != nil {
+				return nil, err
+			}
+			enum.Value = append(enum.Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(valueName), Number: proto.Int32(number)})
+			err = p.expect(";")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return enum, p.advance()
+}
+
+func (p *protoParser) service() (*descriptorpb.ServiceDescriptorProto, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String(name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		switch {
+		case p.token.kind == protoEOF:
+			return nil, p.unexpected("'}'")
+		case p.is(";"):
+			err = p.advance()
+		case p.is("option"):
+			err = p.skipOption()
+		case p.is("rpc"):
+			var method *descriptorpb.MethodDescriptorProto
+			if method, err = p.rpc(); err == nil {
+				service.Method = append(service.Method, method)
+			}
+		default:
+			return nil, p.unexpected("'rpc'")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return service, p.advance()
+}
+
+// rpc parses "rpc Name (stream? Request) returns (stream? Response) { options } or ;"
+func (p *protoParser) rpc() (*descriptorpb.MethodDescriptorProto, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	method := &descriptorpb.MethodDescriptorProto{Name: proto.String(name)}
+
+	messageType := func() (string, bool, error) {
+		if err := p.expect("("); err != nil {
+			return "", false, err
+		}
+		stream := false
+		if p.is("stream") {
+			stream = true
+			if err := p.advance(); err != nil {
+				return "", false, err
+			}
+		}
+		typeName, err := p.fullIdent()
+		if err != nil {
+			return "", false, err
+		}
+		return typeName, stream, p.expect(")")
+	}
+
+	input, clientStreaming, err := messageType()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("returns"); err != nil {
+		return nil, err
+	}
+	output, serverStreaming, err := messageType()
+	if err != nil {
+		return nil, err
+	}
+	method.InputType = proto.String(input)
+	method.OutputType = proto.String(output)
+	if clientStreaming {
+		method.ClientStreaming = proto.Bool(true)
+	}
+	if serverStreaming {
+		method.ServerStreaming = proto.Bool(true)
+	}
+
+	if p.is("{") {
+		return method, p.skipDeclaration()
+	}
+	return method, p.expect(";")
+}
+
+// Lexer
+
+type protoTokenKind int
+
+const (
+	protoEOF protoTokenKind = iota
+	protoIdent
+	protoNumber
+	protoString
+	protoPunct
+)
+
+type protoToken struct {
+	kind  protoTokenKind
+	value string
+	line  int
+}
+
+type protoLexer struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (l *protoLexer) next() (protoToken, error) {
+	if err := l.skipIgnored(); err != nil {
+		return protoToken{}, err
+	}
+	if l.pos >= len(l.input) {
+		return protoToken{kind: protoEOF, line: l.line}, nil
+	}
+
+	start := l.pos
+	c := l.input[l.pos]
+	switch {
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || isLetter(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			l.pos++
+		}
+		return protoToken{kind: protoIdent, value: l.input[start:l.pos], line: l.line}, nil
+	case isDigit(c):
+		for l.pos < len(l.input) && (isLetter(l.input[l.pos]) || isDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+			l.pos++
+		}
+		return protoToken{kind: protoNumber, value: l.input[start:l.pos], line: l.line}, nil
+	case c == '"' || c == '\'':
+		return l.string(c)
+	default:
+		l.pos++
+		return protoToken{kind: protoPunct, value: string(c), line: l.line}, nil
+	}
+}
+
+// string reads a quoted string, decoding escapes
+func (l *protoLexer) string(quote byte) (protoToken, error) {
+	line := l.line
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return protoToken{}, fmt.Errorf("%d: unterminated string", line)
+		case quote:
+			l.pos++
+			raw := l.input[start:l.pos]
+			if quote == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(raw)
+			if err != nil {
+				return protoToken{}, fmt.Errorf("%d: invalid string %s", line, l.input[start:l.pos])
+			}
+			return protoToken{kind: protoString, value: value, line: line}, nil
+		}
+		l.pos++
+	}
+	return protoToken{}, fmt.Errorf("%d: unterminated string", line)
+}
+
+// skipIgnored skips white space and comments
+func (l *protoLexer) skipIgnored() error {
+	for l.pos < len(l.input) {
+		switch {
+		case l.input[l.pos] == '\n':
+			l.line++
+			l.pos++
+		case l.input[l.pos] == ' ' || l.input[l.pos] == '\t' || l.input[l.pos] == '\r':
+			l.pos++
+		case strings.HasPrefix(l.input[l.pos:], "//"):
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "/*"):
+			end := strings.Index(l.input[l.pos+2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("%d: unterminated comment", l.line)
+			}
+			l.line += strings.Count(l.input[l.pos:l.pos+2+end], "\n")
+			l.pos += 2 + end + 2
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
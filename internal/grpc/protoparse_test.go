@@ -0,0 +1,305 @@
+package grpc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const ordersProto = `syntax = "proto3";
+
+package shop.v1;
+
+import "google/protobuf/timestamp.proto";
+import "common/money.proto";
+
+option java_multiple_files = true;
+
+service Orders {
+  option deprecated = false;
+  // GetOrder returns one order
+  rpc GetOrder (GetOrderRequest) returns (Order) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc ListOrders(ListOrdersRequest) returns (stream Order);
+}
+
+message GetOrderRequest {
+  string order_id = 1 [json_name = "orderId"];
+}
+
+message ListOrdersRequest { int32 page_size = 1; }
+
+/* An order, with
+   a block comment */
+message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    PENDING = 1;
+    SHIPPED = 2 [deprecated = true];
+  }
+  message Item {
+    string sku = 1;
+    int32 qty = 2;
+  }
+  reserved 9, 10 to 12;
+  reserved "legacy";
+  string id = 1;
+  repeated Item items = 2;
+  Status status = 3;
+  double total = 4;
+  map<string, string> tags = 5;
+  google.protobuf.Timestamp created = 6;
+  optional string note = 7;
+  oneof payment {
+    string card = 8;
+    common.Money credit = 13;
+  }
+  bytes raw = 14;
+}
+`
+
+const moneyProto = `syntax = "proto3";
+package common;
+option go_package = "example.com/common";
+
+// Money in minor units
+message Money {
+  string currency = 1;
+  int64 amount = 2;
+}
+`
+
+// writeProtos writes files, keyed by slash-separated path, under a temporary directory
+func writeProtos(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseProtoFiles(t *testing.T) {
+	dir := writeProtos(t, map[string]string{"orders.proto": ordersProto, "common/money.proto": moneyProto})
+	files, err := ParseProtoFiles([]string{filepath.Join(dir, "orders.proto")}, nil)
+	if err != nil {
+		t.Fatalf("ParseProtoFiles() error = %v", err)
+	}
+
+	method, err := FindMethod(files, "shop.v1.Orders", "GetOrder")
+	if err != nil {
+		t.Fatalf("FindMethod() error = %v", err)
+	}
+	if method.Input().FullName() != "shop.v1.GetOrderRequest" || method.Output().FullName() != "shop.v1.Order" {
+		t.Fatalf("GetOrder is %s -> %s", method.Input().FullName(), method.Output().FullName())
+	}
+
+	order := method.Output().Fields()
+	tests := []struct {
+		field    protoreflect.Name
+		kind     protoreflect.Kind
+		list     bool
+		isMap    bool
+		message  protoreflect.FullName
+		oneof    protoreflect.Name
+		optional bool
+	}{
+		{field: "id", kind: protoreflect.StringKind},
+		{field: "items", kind: protoreflect.MessageKind, list: true, message: "shop.v1.Order.Item"},
+		{field: "status", kind: protoreflect.EnumKind},
+		{field: "total", kind: protoreflect.DoubleKind},
+		{field: "tags", kind: protoreflect.MessageKind, isMap: true, message: "shop.v1.Order.TagsEntry"},
+		{field: "created", kind: protoreflect.MessageKind, message: "google.protobuf.Timestamp"},
+		{field: "note", kind: protoreflect.StringKind, oneof: "_note", optional: true},
+		{field: "card", kind: protoreflect.StringKind, oneof: "payment"},
+		{field: "credit", kind: protoreflect.MessageKind, message: "common.Money", oneof: "payment"},
+		{field: "raw", kind: protoreflect.BytesKind},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			field := order.ByName(tt.field)
+			if field == nil {
+				t.Fatalf("Order has no field %s", tt.field)
+			}
+			if field.Kind() != tt.kind || field.IsList() != tt.list || field.IsMap() != tt.isMap || field.HasOptionalKeyword() != tt.optional {
+				t.Errorf("%s: kind %v list %v map %v optional %v", tt.field, field.Kind(), field.IsList(), field.IsMap(), field.HasOptionalKeyword())
+			}
+			if field.Message() != nil && field.Message().FullName() != tt.message {
+				t.Errorf("%s: message %s, want %s", tt.field, field.Message().FullName(), tt.message)
+			}
+			var oneof protoreflect.Name
+			if field.ContainingOneof() != nil {
+				oneof = field.ContainingOneof().Name()
+			}
+			if oneof != tt.oneof {
+				t.Errorf("%s: oneof %q, want %q", tt.field, oneof, tt.oneof)
+			}
+		})
+	}
+
+	if name := method.Input().Fields().ByName("order_id").JSONName(); name != "orderId" {
+		t.Errorf("order_id json_name = %q, want orderId", name)
+	}
+	if value := method.Output().Enums().ByName("Status").Values().ByName("SHIPPED"); value == nil || value.Number() != 2 {
+		t.Errorf("Status.SHIPPED is missing or misnumbered")
+	}
+}
+
+func TestParseProtoFilesImportPaths(t *testing.T) {
+	// The import is only found through the import path, not next to the importer
+	dir := writeProtos(t, map[string]string{
+		"api/orders.proto":          ordersProto,
+		"vendor/common/money.proto": moneyProto,
+	})
+	_, err := ParseProtoFiles([]string{filepath.Join(dir, "api", "orders.proto")}, nil)
+	if err == nil || !strings.HasSuffix(err.Error(), `import "common/money.proto" not found`) {
+		t.Fatalf("ParseProtoFiles() without import path error = %v", err)
+	}
+	if _, err := ParseProtoFiles([]string{filepath.Join(dir, "api", "orders.proto")}, []string{filepath.Join(dir, "vendor")}); err != nil {
+		t.Fatalf("ParseProtoFiles() error = %v", err)
+	}
+}
+
+func TestParseProtoFilesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name:  "missing field number",
+			files: map[string]string{"a.proto": "syntax = \"proto3\";\nmessage A {\n  string x = ;\n}\n"},
+			err:   "a.proto:3: expected a number, got ';'",
+		},
+		{
+			name:  "unsupported syntax",
+			files: map[string]string{"a.proto": "syntax = \"editions\";\n"},
+			err:   "a.proto:1: unsupported syntax \"editions\"",
+		},
+		{
+			name:  "unknown type",
+			files: map[string]string{"a.proto": "syntax = \"proto3\";\nmessage A { Missing x = 1; }\n"},
+			err:   "Missing",
+		},
+		{
+			name:  "unterminated string",
+			files: map[string]string{"a.proto": "syntax = \"proto3\";\npackage \"a;\n"},
+			err:   "unterminated string",
+		},
+		{
+			name: "import cycle",
+			files: map[string]string{
+				"a.proto": "syntax = \"proto3\";\nimport \"b.proto\";\n",
+				"b.proto": "syntax = \"proto3\";\nimport \"a.proto\";\n",
+			},
+			err: "import cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProtos(t, tt.files)
+			_, err := ParseProtoFiles([]string{filepath.Join(dir, "a.proto")}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("ParseProtoFiles() error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFindMethodAndNewMessage(t *testing.T) {
+	dir := writeProtos(t, map[string]string{"orders.proto": ordersProto, "common/money.proto": moneyProto})
+	files, err := ParseProtoFiles([]string{filepath.Join(dir, "orders.proto")}, nil)
+	if err != nil {
+		t.Fatalf("ParseProtoFiles() error = %v", err)
+	}
+
+	methods := []struct {
+		service string
+		method  string
+		err     string
+	}{
+		{service: "shop.v1.Orders", method: "GetOrder"},
+		{service: "shop.v1.Missing", method: "GetOrder", err: "service shop.v1.Missing not found"},
+		{service: "shop.v1.Order", method: "GetOrder", err: "shop.v1.Order is not a service"},
+		{service: "shop.v1.Orders", method: "Delete", err: "service shop.v1.Orders has no method Delete"},
+		{service: "shop.v1.Orders", method: "ListOrders", err: "shop.v1.Orders.ListOrders is a streaming method; only unary methods are supported"},
+	}
+	for _, tt := range methods {
+		t.Run(tt.service+"/"+tt.method, func(t *testing.T) {
+			_, err := FindMethod(files, tt.service, tt.method)
+			if tt.err == "" && err != nil {
+				t.Fatalf("FindMethod() error = %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("FindMethod() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	method, _ := FindMethod(files, "shop.v1.Orders", "GetOrder")
+	messages := []struct {
+		name    string
+		message interface{}
+		want    string
+		err     string
+	}{
+		{name: "nil", message: nil, want: `{}`},
+		{name: "mapping", message: map[string]interface{}{"order_id": "o-1"}, want: `{"orderId":"o-1"}`},
+		{name: "JSON name", message: `{"orderId": "o-2"}`, want: `{"orderId":"o-2"}`},
+		{name: "unknown field", message: map[string]interface{}{"id": "o-1"}, err: "invalid shop.v1.GetOrderRequest message"},
+		{name: "wrong type", message: map[string]interface{}{"order_id": 1}, err: "invalid shop.v1.GetOrderRequest message"},
+	}
+	for _, tt := range messages {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := NewMessage(method, files, tt.message)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("NewMessage() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewMessage() error = %v", err)
+			}
+			got, _ := protojson.MarshalOptions{}.Marshal(message)
+			if strings.ReplaceAll(string(got), " ", "") != tt.want {
+				t.Errorf("NewMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		ok   bool
+	}{
+		{"OK", 0, true},
+		{"NOT_FOUND", 5, true},
+		{"not_found", 5, true},
+		{"UNAUTHENTICATED", 16, true},
+		{"NOPE", 0, false},
+	}
+	for _, tt := range tests {
+		code, ok := ParseCode(tt.name)
+		if code != tt.code || ok != tt.ok {
+			t.Errorf("ParseCode(%q) = %d, %v, want %d, %v", tt.name, code, ok, tt.code, tt.ok)
+		}
+		if tt.ok && CodeName(code) != strings.ToUpper(tt.name) {
+			t.Errorf("CodeName(%d) = %s, want %s", code, CodeName(code), strings.ToUpper(tt.name))
+		}
+	}
+}
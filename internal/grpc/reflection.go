@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Well-known types servers may leave out of reflection responses
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/apipb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// reflectionServices are tried in order; older servers only offer v1alpha
+var reflectionServices = []string{
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// ServerReflectionRequest and ServerReflectionResponse field numbers. Both
+// versions of the reflection service share them.
+const (
+	requestFileByFilename       = 3
+	requestFileContainingSymbol = 4
+
+	responseFileDescriptor = 4
+	responseError          = 7
+)
+
+// reflect asks the server for the file defining service and everything it imports
+func (c *Client) reflect(ctx context.Context, target, service string) (*protoregistry.Files, error) {
+	received := make(map[string]*descriptorpb.FileDescriptorProto)
+	var reflectionService string
+	var err error
+	for _, candidate := range reflectionServices {
+		reflectionService = candidate
+		err = c.fetchFiles(ctx, target, candidate, requestFileContainingSymbol, service, received)
+		if code, ok := err.(*statusError); !ok || code.code != codeUnimplemented {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+
+	// Ask for any imports the server did not send along
+	for pending := true; pending; {
+		pending = false
+		for _, file := range received {
+			for _, dependency := range file.GetDependency() {
+				if _, ok := received[dependency]; ok {
+					continue
+				}
+				if builtin, err := protoregistry.GlobalFiles.FindFileByPath(dependency); err == nil {
+					received[dependency] = protodesc.ToFileDescriptorProto(builtin)
+				} else if err := c.fetchFiles(ctx, target, reflectionService, requestFileByFilename, dependency, received); err != nil {
+					return nil, fmt.Errorf("server reflection: %w", err)
+				}
+				pending = true
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range received {
+		set.File = append(set.File, file)
+	}
+	return protodesc.NewFiles(set)
+}
+
+// fetchFiles sends one file request and adds the files in the response to received
+func (c *Client) fetchFiles(ctx context.Context, target, reflectionService string, field protowire.Number, name string, received map[string]*descriptorpb.FileDescriptorProto) error {
+	var request []byte
+	request = protowire.AppendTag(request, field, protowire.BytesType)
+	request = protowire.AppendString(request, name)
+
+	response, err := c.reflectionCall(ctx, target, reflectionService, request)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	err = eachField(response, func(number protowire.Number, value []byte) error {
+		switch number {
+		case responseError:
+			return reflectionError(value)
+		case responseFileDescriptor:
+			return eachField(value, func(number protowire.Number, value []byte) error {
+				if number != 1 {
+					return nil
+				}
+				file := &descriptorpb.FileDescriptorProto{}
+				if err := proto.Unmarshal(value, file); err != nil {
+					return fmt.Errorf("invalid file descriptor: %w", err)
+				}
+				found = true
+				if _, ok := received[file.GetName()]; !ok {
+					received[file.GetName()] = file
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no file descriptors for %s", name)
+	}
+	return err
+}
+
+// reflectionCall sends a single request on the ServerReflectionInfo stream and
+// returns the first response
+func (c *Client) reflectionCall(ctx context.Context, target, reflectionService string, request []byte) ([]byte, error) {
+	result, err := c.call(ctx, MethodURL(target, reflectionService, "ServerReflectionInfo"), nil, request)
+	if err != nil {
+		return nil, err
+	}
+	if result.code != 0 {
+		return nil, &statusError{code: result.code, message: result.message}
+	}
+	if len(result.messages) == 0 {
+		return nil, fmt.Errorf("no response from %s", reflectionService)
+	}
+	return result.messages[0], nil
+}
+
+// statusError is a call that failed with a gRPC status
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	if e.message == "" {
+		return CodeName(e.code)
+	}
+	return CodeName(e.code) + ": " + e.message
+}
+
+// reflectionError decodes an ErrorResponse
+func reflectionError(value []byte) error {
+	status := &statusError{code: codeUnknown}
+	for len(value) > 0 {
+		number, kind, n := protowire.ConsumeTag(value)
+		if n < 0 {
+			break
+		}
+		value = value[n:]
+		switch {
+		case number == 1 && kind == protowire.VarintType:
+			code, m := protowire.ConsumeVarint(value)
+			status.code, n = int(code), m
+		case number == 2 && kind == protowire.BytesType:
+			message, m := protowire.ConsumeBytes(value)
+			status.message, n = string(message), m
+		default:
+			n = protowire.ConsumeFieldValue(number, kind, value)
+		}
+		if n < 0 {
+			break
+		}
+		value = value[n:]
+	}
+	return status
+}
+
+// eachField calls fn with every length-delimited field of a message, skipping the rest
+func eachField(message []byte, fn func(protowire.Number, []byte) error) error {
+	for len(message) > 0 {
+		number, kind, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return fmt.Errorf("invalid reflection response")
+		}
+		message = message[n:]
+		if kind != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(number, kind, message)
+			if n < 0 {
+				return fmt.Errorf("invalid reflection response")
+			}
+			message = message[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(message)
+		if n < 0 {
+			return fmt.Errorf("invalid reflection response")
+		}
+		message = message[n:]
+		if err := fn(number, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
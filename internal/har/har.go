@@ -0,0 +1,271 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/grpc"
+	"github.com/Asadus16/comapi/internal/redact"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// HAR is an HTTP Archive 1.2 document, readable by browser dev tools and HAR viewers
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the recorded exchanges
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Pages   []Page  `json:"pages,omitempty"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the tool that wrote the archive
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Page groups the entries of one suite run
+type Page struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	ID              string      `json:"id"`
+	Title           string      `json:"title"`
+	PageTimings     PageTimings `json:"pageTimings"`
+}
+
+// PageTimings is required by the format; comapi has no page load to measure
+type PageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// Entry is one request and its response
+type Entry struct {
+	Pageref         string    `json:"pageref,omitempty"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+// Request is the request half of an entry
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is the response half of an entry
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header, cookie or query parameter
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is a request body
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is a response body
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Timings are the phases of an entry in milliseconds; -1 means not applicable
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// FromResult builds an archive of the exchanges in a suite result. Tests that
+// never sent a request are left out, and secret headers are masked by redactor.
+func FromResult(result types.SuiteResult, redactor *redact.Redactor) *HAR {
+	archive := &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "comapi", Version: "1.0.0"},
+		Entries: []Entry{},
+	}}
+
+	for _, test := range result.Results {
+		if test.Timings == nil {
+			continue
+		}
+		if len(archive.Log.Pages) == 0 {
+			archive.Log.Pages = []Page{{
+				StartedDateTime: test.Timings.StartedAt,
+				ID:              "suite",
+				Title:           result.SuiteName,
+				PageTimings:     PageTimings{OnContentLoad: -1, OnLoad: -1},
+			}}
+		}
+		archive.Log.Entries = append(archive.Log.Entries, newEntry(test, redactor))
+	}
+	return archive
+}
+
+// WriteFile saves the archive as indented JSON
+func WriteFile(path string, archive *HAR) error {
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+	return nil
+}
+
+func newEntry(test types.TestResult, redactor *redact.Redactor) Entry {
+	timings := *test.Timings
+	httpVersion := test.Response.Proto
+	if httpVersion == "" {
+		httpVersion = "HTTP/1.1"
+	}
+
+	request := Request{
+		Method:      test.Request.Method,
+		URL:         test.Request.URL,
+		HTTPVersion: httpVersion,
+		Cookies:     []NameValue{},
+		Headers:     nameValues(redactor.Headers(test.Request.Headers)),
+		QueryString: queryString(test.Request.URL),
+		HeadersSize: -1,
+		BodySize:    len(test.Request.Body),
+	}
+	if test.Request.Body != "" {
+		request.PostData = &PostData{
+			MimeType: headerValue(test.Request.Headers, "Content-Type"),
+			Text:     test.Request.Body,
+		}
+	}
+
+	response := Response{
+		Status:      test.Response.StatusCode,
+		StatusText:  http.StatusText(test.Response.StatusCode),
+		HTTPVersion: httpVersion,
+		Cookies:     []NameValue{},
+		Headers:     nameValues(redactor.Headers(test.Response.Headers)),
+		Content: Content{
+			Size:     test.Response.Size,
+			MimeType: headerValue(test.Response.Headers, "Content-Type"),
+			Text:     test.Response.Body,
+		},
+		RedirectURL: headerValue(test.Response.Headers, "Location"),
+		HeadersSize: -1,
+		BodySize:    int(test.Response.Size),
+	}
+	grpcCall := headerValue(test.Request.Headers, "Content-Type") == grpc.ContentType
+	switch {
+	case grpcCall && test.Response.Proto != "":
+		// The status of a gRPC call travels in its grpc-status trailer
+		response.Status = http.StatusOK
+		response.StatusText = http.StatusText(http.StatusOK)
+	case grpcCall, test.Response.StatusCode == 0:
+		// The request failed before a response arrived
+		response.BodySize = -1
+	}
+
+	entry := Entry{
+		Pageref:         "suite",
+		StartedDateTime: timings.StartedAt,
+		Time:            timings.Total,
+		Request:         request,
+		Response:        response,
+		Timings:         entryTimings(timings),
+		Comment:         test.TestName,
+	}
+	if test.Error != "" {
+		entry.Comment = test.TestName + ": " + test.Error
+	}
+	return entry
+}
+
+// entryTimings maps the trace onto HAR phases: connect includes the TLS
+// handshake, and send is what remains of the time to first byte
+func entryTimings(t types.Timings) Timings {
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: t.Wait, Receive: t.Transfer}
+	if !t.Reused {
+		timings.DNS = t.DNS
+		timings.Connect = t.Connect + t.TLS
+		if t.TLS > 0 {
+			timings.SSL = t.TLS
+		}
+	}
+	send := t.TTFB - t.Wait - math.Max(timings.DNS, 0) - math.Max(timings.Connect, 0)
+	timings.Send = math.Max(math.Round(send*1000)/1000, 0)
+	return timings
+}
+
+// nameValues turns a header map into a list sorted by name
+func nameValues(values map[string]string) []NameValue {
+	list := make([]NameValue, 0, len(values))
+	for name, value := range values {
+		list = append(list, NameValue{Name: name, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func queryString(rawURL string) []NameValue {
+	list := []NameValue{}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return list
+	}
+	for name, values := range parsed.Query() {
+		for _, value := range values {
+			list = append(list, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
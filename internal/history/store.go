@@ -0,0 +1,215 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// DefaultPath is where runs are stored when no file is configured
+const DefaultPath = ".comapi/history.jsonl"
+
+// maxStoredBody caps the response body kept per test so the history stays small
+const maxStoredBody = 64 * 1024
+
+// Run is one persisted suite execution
+type Run struct {
+	ID          string    `json:"id"`
+	Suite       string    `json:"suite"`
+	File        string    `json:"file,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMs  int64     `json:"duration_ms"`
+	Commit      string    `json:"commit,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	BaseURL     string    `json:"base_url,omitempty"`
+	Total       int       `json:"total"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	Skipped     int       `json:"skipped"`
+	Tests       []TestRun `json:"tests"`
+}
+
+// TestRun is the stored outcome of one test within a run
+type TestRun struct {
+	Name       string           `json:"name"`
+	Status     types.TestStatus `json:"status"`
+	DurationMs int64            `json:"duration_ms"`
+	StatusCode int              `json:"status_code,omitempty"`
+	Error      string           `json:"error,omitempty"`
+
+	// The response is kept so stored runs can be compared with "comapi diff"
+	Headers       map[string]string `json:"headers,omitempty"`
+	Body          string            `json:"body,omitempty"`
+	BodyTruncated bool              `json:"body_truncated,omitempty"`
+}
+
+// Filter selects runs when loading the store
+type Filter struct {
+	Suite string // Exact suite name, empty for all
+	Limit int    // Most recent N runs, 0 for all
+}
+
+// Store is an append-only JSON-lines file of runs
+type Store struct {
+	path string
+}
+
+// NewStore returns a store backed by path, or DefaultPath when empty
+func NewStore(path string) *Store {
+	if path == "" {
+		path = DefaultPath
+	}
+	return &Store{path: path}
+}
+
+// Path returns the file backing the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// NewRun builds a Run from a suite result, stamping it with the current git commit
+func NewRun(result types.SuiteResult, file, environment, baseURL string, startedAt time.Time) Run {
+	run := Run{
+		ID:          strconv.FormatInt(startedAt.UnixNano(), 36),
+		Suite:       result.SuiteName,
+		File:        file,
+		StartedAt:   startedAt.UTC(),
+		DurationMs:  result.Duration.Milliseconds(),
+		Environment: environment,
+		BaseURL:     baseURL,
+		Total:       result.TotalTests,
+		Passed:      result.PassedTests,
+		Failed:      result.FailedTests,
+		Skipped:     result.SkippedTests,
+	}
+	run.Commit = git("rev-parse", "--short", "HEAD")
+	run.Branch = git("rev-parse", "--abbrev-ref", "HEAD")
+
+	for _, test := range result.Results {
+		testRun := TestRun{
+			Name:       test.TestName,
+			Status:     test.Status,
+			DurationMs: test.Duration.Milliseconds(),
+			StatusCode: test.Response.StatusCode,
+			Error:      test.Error,
+			Headers:    test.Response.Headers,
+			Body:       test.Response.Body,
+		}
+		if len(testRun.Body) > maxStoredBody {
+			testRun.Body = testRun.Body[:maxStoredBody]
+			testRun.BodyTruncated = true
+		}
+		run.Tests = append(run.Tests, testRun)
+	}
+	return run
+}
+
+// Append adds a run to the end of the store, creating the file if needed
+func (s *Store) Append(run Run) error {
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create history directory: %w", err)
+		}
+	}
+
+	line, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load returns the runs matching filter, oldest first. A missing file is an empty history.
+func (s *Store) Load(filter Filter) ([]Run, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal([]byte(line), &run); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid history entry: %w", s.path, lineNumber, err)
+		}
+		if filter.Suite != "" && run.Suite != filter.Suite {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	if filter.Limit > 0 && len(runs) > filter.Limit {
+		runs = runs[len(runs)-filter.Limit:]
+	}
+	return runs, nil
+}
+
+// Find returns the run with the given ID, accepting any unique prefix
+func (s *Store) Find(id string) (*Run, error) {
+	runs, err := s.Load(Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Run
+	for i := range runs {
+		if runs[i].ID == id {
+			return &runs[i], nil
+		}
+		if strings.HasPrefix(runs[i].ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("run ID '%s' is ambiguous", id)
+			}
+			found = &runs[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("run '%s' not found in %s", id, s.path)
+	}
+	return found, nil
+}
+
+// git runs a git command and returns its trimmed output, or "" outside a repository
+func git(args ...string) string {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
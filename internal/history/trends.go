@@ -0,0 +1,129 @@
+package history
+
+import (
+	"sort"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// minFlakyRuns is the number of executed runs needed before a test can be called flaky
+const minFlakyRuns = 3
+
+// TestStats summarises one test across a series of runs
+type TestStats struct {
+	Name          string             `json:"name"`
+	Runs          int                `json:"runs"`
+	Passed        int                `json:"passed"`
+	Failed        int                `json:"failed"`
+	Skipped       int                `json:"skipped"`
+	PassRate      float64            `json:"pass_rate"`
+	AvgMs         float64            `json:"avg_ms"`
+	LastMs        int64              `json:"last_ms"`
+	LatencyChange float64            `json:"latency_change"` // Recent half vs older half, as a fraction
+	Flips         int                `json:"flips"`          // Pass/fail transitions, ignoring skips
+	Flaky         bool               `json:"flaky"`
+	LastStatus    types.TestStatus   `json:"last_status"`
+	Statuses      []types.TestStatus `json:"statuses"` // Oldest first
+	FailingSince  string             `json:"failing_since,omitempty"`
+}
+
+// Stats computes per-test trends over runs, which must be ordered oldest first.
+// Tests are returned in the order they first appear.
+func Stats(runs []Run) []TestStats {
+	var order []string
+	series := make(map[string][]TestRun)
+	runIDs := make(map[string][]string)
+
+	for _, run := range runs {
+		for _, test := range run.Tests {
+			if _, ok := series[test.Name]; !ok {
+				order = append(order, test.Name)
+			}
+			series[test.Name] = append(series[test.Name], test)
+			runIDs[test.Name] = append(runIDs[test.Name], run.ID)
+		}
+	}
+
+	stats := make([]TestStats, len(order))
+	for i, name := range order {
+		stats[i] = testStats(name, series[name], runIDs[name])
+	}
+	return stats
+}
+
+// Flaky returns the tests that alternate between passing and failing, most flips first
+func Flaky(stats []TestStats) []TestStats {
+	var flaky []TestStats
+	for _, s := range stats {
+		if s.Flaky {
+			flaky = append(flaky, s)
+		}
+	}
+	sort.SliceStable(flaky, func(i, j int) bool {
+		return flaky[i].Flips > flaky[j].Flips
+	})
+	return flaky
+}
+
+func testStats(name string, tests []TestRun, runIDs []string) TestStats {
+	stats := TestStats{Name: name, Runs: len(tests)}
+
+	var durations []int64
+	var previous types.TestStatus
+	for i, test := range tests {
+		stats.Statuses = append(stats.Statuses, test.Status)
+		switch test.Status {
+		case types.StatusPass:
+			stats.Passed++
+		case types.StatusFail:
+			stats.Failed++
+		default:
+			stats.Skipped++
+			continue
+		}
+
+		durations = append(durations, test.DurationMs)
+		if previous != "" && previous != test.Status {
+			stats.Flips++
+		}
+		previous = test.Status
+
+		// Remember where the current streak of failures began
+		if test.Status == types.StatusFail {
+			if stats.FailingSince == "" {
+				stats.FailingSince = runIDs[i]
+			}
+		} else {
+			stats.FailingSince = ""
+		}
+	}
+
+	stats.LastStatus = tests[len(tests)-1].Status
+	executed := stats.Passed + stats.Failed
+	if executed > 0 {
+		stats.PassRate = float64(stats.Passed) / float64(executed)
+	}
+	stats.Flaky = executed >= minFlakyRuns && stats.Passed > 0 && stats.Failed > 0 && stats.Flips >= 2
+
+	if len(durations) > 0 {
+		stats.AvgMs = average(durations)
+		stats.LastMs = durations[len(durations)-1]
+	}
+	if len(durations) >= 4 {
+		half := len(durations) / 2
+		older, recent := average(durations[:half]), average(durations[len(durations)-half:])
+		if older > 0 {
+			stats.LatencyChange = (recent - older) / older
+		}
+	}
+
+	return stats
+}
+
+func average(values []int64) float64 {
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
@@ -0,0 +1,469 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/metrics"
+	"github.com/Asadus16/comapi/internal/runner"
+	"github.com/Asadus16/comapi/internal/webhook"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusCancelled Status = "cancelled"
+)
+
+// webhookTimeout bounds the delivery of a run's webhooks, retries included
+const webhookTimeout = time.Minute
+
+// Event types sent to subscribers
+const (
+	EventTestStart = "test_start"
+	EventResult    = "result"
+	EventDone      = "done"
+)
+
+// ErrNotFound is returned for unknown job IDs
+var ErrNotFound = errors.New("run not found")
+
+// ErrFinished is returned when cancelling a job that has already finished
+var ErrFinished = errors.New("run already finished")
+
+// ErrShuttingDown is returned by Submit once Shutdown has been called
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Event is one progress update of a job
+type Event struct {
+	Type   string            `json:"type"`
+	Index  int               `json:"index"`
+	Total  int               `json:"total"`
+	Test   string            `json:"test,omitempty"`
+	Result *types.TestResult `json:"result,omitempty"`
+	Job    *Snapshot         `json:"job,omitempty"`
+}
+
+// Snapshot is the JSON view of a job at one point in time
+type Snapshot struct {
+	ID             string             `json:"id"`
+	SuiteName      string             `json:"suite_name"`
+	Status         Status             `json:"status"`
+	CreatedAt      time.Time          `json:"created_at"`
+	StartedAt      *time.Time         `json:"started_at,omitempty"`
+	FinishedAt     *time.Time         `json:"finished_at,omitempty"`
+	TotalTests     int                `json:"total_tests"`
+	CompletedTests int                `json:"completed_tests"`
+	Results        []types.TestResult `json:"results,omitempty"`
+	Summary        *types.SuiteResult `json:"summary,omitempty"`
+}
+
+// Job is one background suite execution
+type Job struct {
+	mu       sync.Mutex
+	snapshot Snapshot
+	suite    *types.TestSuite
+	cancel   context.CancelFunc
+	events   []Event
+	changed  chan struct{} // Closed and replaced whenever events grow or the job finishes
+	done     chan struct{}
+}
+
+// Snapshot returns a copy of the job's current state
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshotLocked()
+}
+
+func (j *Job) snapshotLocked() Snapshot {
+	snapshot := j.snapshot
+	snapshot.Results = append([]types.TestResult{}, j.snapshot.Results...)
+	return snapshot
+}
+
+// Done is closed when the job has finished
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Follow sends every event of the job to send, starting from the first one, until
+// the job finishes, ctx is cancelled or send returns an error
+func (j *Job) Follow(ctx context.Context, send func(Event) error) error {
+	next := 0
+	for {
+		j.mu.Lock()
+		pending := j.events[next:]
+		changed := j.changed
+		finished := j.isFinishedLocked()
+		j.mu.Unlock()
+
+		for _, event := range pending {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		next += len(pending)
+
+		if finished && len(pending) == 0 {
+			return nil
+		}
+		if finished {
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (j *Job) isFinishedLocked() bool {
+	return j.snapshot.Status == StatusCompleted || j.snapshot.Status == StatusCancelled
+}
+
+// publishLocked records an event and wakes up followers; callers must hold j.mu
+func (j *Job) publishLocked(event Event) {
+	j.events = append(j.events, event)
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Manager runs jobs in the background and keeps recently finished ones around
+type Manager struct {
+	mu         sync.Mutex
+	jobs       map[string]*Job
+	order      []string
+	slots      chan struct{}
+	retain     int
+	httpClient *http.Client
+	logger     *slog.Logger
+	closed     bool
+	waitGroup  sync.WaitGroup
+}
+
+// NewManager creates a manager that runs at most concurrency jobs at once and keeps the
+// last retain finished jobs for inspection
+func NewManager(concurrency, retain int) *Manager {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Manager{
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, concurrency),
+		retain: retain,
+		logger: slog.Default(),
+	}
+}
+
+// SetLogger sets where run lifecycle events are logged
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// SetHTTPClient makes jobs send their requests through client, e.g. one that
+// enforces an outbound host policy
+func (m *Manager) SetHTTPClient(client *http.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.httpClient = client
+}
+
+// Submit queues a prepared suite for execution and returns immediately
+func (m *Manager) Submit(suite *types.TestSuite) (*Job, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		snapshot: Snapshot{
+			ID:         newID(),
+			SuiteName:  suite.Name,
+			Status:     StatusQueued,
+			CreatedAt:  time.Now().UTC(),
+			TotalTests: len(suite.Tests),
+			Results:    []types.TestResult{},
+		},
+		suite:   suite,
+		cancel:  cancel,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// Add to the wait group under the lock so Shutdown, which sets closed under
+	// the same lock, never starts waiting before a job it let through is counted
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		cancel()
+		return nil, ErrShuttingDown
+	}
+	m.jobs[job.snapshot.ID] = job
+	m.order = append(m.order, job.snapshot.ID)
+	m.pruneLocked()
+	m.waitGroup.Add(1)
+	m.mu.Unlock()
+	metrics.RunsActive.Add(1)
+
+	go m.run(ctx, job)
+	return job, nil
+}
+
+// Get returns the job with the given ID
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+// List returns snapshots of all known jobs, newest first
+func (m *Manager) List() []Snapshot {
+	m.mu.Lock()
+	jobs := make([]*Job, 0, len(m.order))
+	for _, id := range m.order {
+		jobs = append(jobs, m.jobs[id])
+	}
+	m.mu.Unlock()
+
+	snapshots := make([]Snapshot, len(jobs))
+	for i, job := range jobs {
+		snapshots[i] = job.Snapshot()
+		snapshots[i].Results = nil
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots
+}
+
+// Cancel stops a queued or running job. Tests already in flight are aborted.
+func (m *Manager) Cancel(id string) (*Job, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	finished := job.isFinishedLocked()
+	job.mu.Unlock()
+	if finished {
+		return job, ErrFinished
+	}
+
+	job.cancel()
+	<-job.done
+	return job, nil
+}
+
+// Wait blocks until every submitted job has finished
+func (m *Manager) Wait() {
+	m.waitGroup.Wait()
+}
+
+// Shutdown stops accepting jobs and waits for running and queued ones to finish.
+// When ctx expires first, the remaining jobs are cancelled and ctx's error is returned
+// once they have stopped.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		m.waitGroup.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.mu.Lock()
+	for _, job := range m.jobs {
+		job.cancel()
+	}
+	m.mu.Unlock()
+	<-drained
+	return ctx.Err()
+}
+
+// Active returns the number of queued and running jobs
+func (m *Manager) Active() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := 0
+	for _, job := range m.jobs {
+		job.mu.Lock()
+		if !job.isFinishedLocked() {
+			active++
+		}
+		job.mu.Unlock()
+	}
+	return active
+}
+
+func (m *Manager) run(ctx context.Context, job *Job) {
+	defer m.waitGroup.Done()
+	defer close(job.done)
+	defer job.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.finish(job, nil, StatusCancelled)
+		return
+	}
+
+	job.mu.Lock()
+	startedAt := time.Now().UTC()
+	job.snapshot.Status = StatusRunning
+	job.snapshot.StartedAt = &startedAt
+	id, suiteName, total := job.snapshot.ID, job.snapshot.SuiteName, job.snapshot.TotalTests
+	suite := job.suite
+	job.mu.Unlock()
+
+	// Jobs run suites sent over the API, which must not probe the server's environment
+	suiteRunner := runner.NewSuiteRunner(suite)
+	suiteRunner.SetProcessEnv(false)
+	m.mu.Lock()
+	if m.httpClient != nil {
+		suiteRunner.SetHTTPClient(m.httpClient)
+	}
+	logger := m.logger
+	m.mu.Unlock()
+	logger.Info("run started", "run_id", id, "suite", suiteName, "tests", total)
+	suiteRunner.OnTestStart = func(index, total int, test types.TestCase) {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.publishLocked(Event{Type: EventTestStart, Index: index, Total: total, Test: test.Name})
+	}
+	suiteRunner.OnTestComplete = func(index, total int, result types.TestResult) {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.snapshot.Results = append(job.snapshot.Results, result)
+		job.snapshot.CompletedTests++
+		job.publishLocked(Event{Type: EventResult, Index: index, Total: total, Test: result.TestName, Result: &result})
+		metrics.ObserveResult(suiteName, result)
+	}
+
+	result := suiteRunner.RunContext(ctx)
+
+	status := StatusCompleted
+	if ctx.Err() != nil {
+		status = StatusCancelled
+	}
+	if status == StatusCompleted && len(suite.Webhooks) > 0 {
+		m.waitGroup.Add(1)
+		go m.notify(id, suite, result)
+	}
+	m.finish(job, &result, status)
+}
+
+// notify delivers the suite's webhooks in the background so the run finishes without waiting on receivers
+func (m *Manager) notify(id string, suite *types.TestSuite, result types.SuiteResult) {
+	defer m.waitGroup.Done()
+
+	m.mu.Lock()
+	sender := webhook.NewSender(m.httpClient)
+	logger := m.logger
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	for _, delivery := range sender.Notify(ctx, suite, result) {
+		if delivery.OK() {
+			logger.Info("webhook delivered", "run_id", id, "target", delivery.Target(), "status", delivery.StatusCode, "attempts", delivery.Attempts)
+		} else {
+			logger.Warn("webhook failed", "run_id", id, "target", delivery.Target(), "attempts", delivery.Attempts, "error", delivery.Error)
+		}
+	}
+}
+
+// finish records the final state and sends the done event
+func (m *Manager) finish(job *Job, result *types.SuiteResult, status Status) {
+	job.mu.Lock()
+	finishedAt := time.Now().UTC()
+	job.snapshot.Status = status
+	job.snapshot.FinishedAt = &finishedAt
+	if result != nil {
+		summary := *result
+		summary.Results = nil
+		job.snapshot.Summary = &summary
+	}
+	job.suite = nil
+
+	snapshot := job.snapshotLocked()
+	snapshot.Results = nil
+	job.publishLocked(Event{Type: EventDone, Index: job.snapshot.CompletedTests, Total: job.snapshot.TotalTests, Job: &snapshot})
+	job.mu.Unlock()
+
+	var summary types.SuiteResult
+	if result != nil {
+		summary = *result
+	}
+	metrics.RunsActive.Add(-1)
+	metrics.ObserveRun(snapshot.SuiteName, summary, status == StatusCancelled)
+
+	m.mu.Lock()
+	logger := m.logger
+	m.mu.Unlock()
+	logger.Info("run finished",
+		"run_id", snapshot.ID,
+		"suite", snapshot.SuiteName,
+		"status", status,
+		"passed", summary.PassedTests,
+		"failed", summary.FailedTests,
+		"skipped", summary.SkippedTests,
+		"duration_ms", summary.Duration.Milliseconds())
+}
+
+// pruneLocked forgets the oldest finished jobs beyond the retention limit; callers must hold m.mu
+func (m *Manager) pruneLocked() {
+	excess := len(m.order) - m.retain
+	if m.retain <= 0 || excess <= 0 {
+		return
+	}
+
+	kept := m.order[:0]
+	for _, id := range m.order {
+		job := m.jobs[id]
+		job.mu.Lock()
+		finished := job.isFinishedLocked()
+		job.mu.Unlock()
+
+		if excess > 0 && finished {
+			delete(m.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.order = kept
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// testServer answers /block only once the request is cancelled and reports
+// every request that reaches it on arrived
+func testServer(t *testing.T) (server *httptest.Server, arrived chan string) {
+	arrived = make(chan string, 16)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- r.URL.Path
+		if r.URL.Path == "/block" {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, arrived
+}
+
+func testSuite(baseURL string, paths ...string) *types.TestSuite {
+	suite := &types.TestSuite{Name: "jobs", BaseURL: baseURL}
+	for i, path := range paths {
+		suite.Tests = append(suite.Tests, types.TestCase{
+			Name:       fmt.Sprintf("test %d", i),
+			Method:     "GET",
+			Path:       path,
+			Assertions: []types.Assertion{{Type: "status", Expected: 200}},
+		})
+	}
+	return suite
+}
+
+// newTestManager runs one job at a time and does not log
+func newTestManager() *Manager {
+	manager := NewManager(1, 10)
+	manager.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return manager
+}
+
+// eventLog follows a job to the end and describes each event as "type index"
+func eventLog(t *testing.T, job *Job) []string {
+	var events []string
+	err := job.Follow(context.Background(), func(event Event) error {
+		events = append(events, fmt.Sprintf("%s %d", event.Type, event.Index))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Follow() error = %v", err)
+	}
+	return events
+}
+
+func TestFollowOrder(t *testing.T) {
+	server, _ := testServer(t)
+	manager := newTestManager()
+	want := []string{"test_start 0", "result 0", "test_start 1", "result 1", "test_start 2", "result 2", "done 3"}
+
+	job, err := manager.Submit(testSuite(server.URL, "/a", "/b", "/c"))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	// A follower that joins while the job runs and one that joins after it
+	// finished both see every event once, in order
+	live := make(chan []string)
+	go func() { live <- eventLog(t, job) }()
+	<-job.Done()
+	late := eventLog(t, job)
+
+	for name, got := range map[string][]string{"live": <-live, "late": late} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s follower got %q, want %q", name, got, want)
+		}
+	}
+
+	snapshot := job.Snapshot()
+	if snapshot.Status != StatusCompleted || snapshot.CompletedTests != 3 || len(snapshot.Results) != 3 {
+		t.Errorf("snapshot = %s with %d/%d results, want completed with 3", snapshot.Status, snapshot.CompletedTests, len(snapshot.Results))
+	}
+	if snapshot.Summary == nil || snapshot.Summary.PassedTests != 3 {
+		t.Errorf("summary = %+v, want 3 passed tests", snapshot.Summary)
+	}
+}
+
+func TestFollowStopsWithContext(t *testing.T) {
+	server, arrived := testServer(t)
+	manager := newTestManager()
+	job, err := manager.Submit(testSuite(server.URL, "/block"))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-arrived
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []string
+	err = job.Follow(ctx, func(event Event) error {
+		events = append(events, event.Type)
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || !reflect.DeepEqual(events, []string{EventTestStart}) {
+		t.Errorf("Follow() = %v after %q, want context.Canceled after the first event", err, events)
+	}
+
+	// Following is independent of the job, which keeps running
+	if snapshot := job.Snapshot(); snapshot.Status != StatusRunning {
+		t.Errorf("job status = %s, want running", snapshot.Status)
+	}
+	manager.Cancel(job.Snapshot().ID)
+	manager.Wait()
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   Status
+		error   error
+		status  Status
+		started bool
+		events  []string
+	}{
+		{
+			// Tests the cancelled run did not finish are reported as skipped
+			name: "running job stops its request", state: StatusRunning,
+			status: StatusCancelled, started: true,
+			events: []string{"test_start 0", "result 0", "test_start 1", "result 1", "done 2"},
+		},
+		{
+			name: "queued job never starts", state: StatusQueued,
+			status: StatusCancelled, events: []string{"done 0"},
+		},
+		{
+			name: "finished job is left alone", state: StatusCompleted, error: ErrFinished,
+			status: StatusCompleted, started: true, events: []string{"test_start 0", "result 0", "done 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, arrived := testServer(t)
+			manager := newTestManager()
+
+			var job, blocker *Job
+			var err error
+			switch tt.state {
+			case StatusRunning:
+				job, err = manager.Submit(testSuite(server.URL, "/block", "/after"))
+				<-arrived
+			case StatusQueued:
+				// The only slot is taken until the blocker is cancelled
+				if blocker, err = manager.Submit(testSuite(server.URL, "/block")); err != nil {
+					t.Fatalf("Submit() error = %v", err)
+				}
+				<-arrived
+				job, err = manager.Submit(testSuite(server.URL, "/queued"))
+			case StatusCompleted:
+				job, err = manager.Submit(testSuite(server.URL, "/done"))
+				<-job.Done()
+			}
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+
+			if _, err := manager.Cancel(job.Snapshot().ID); !errors.Is(err, tt.error) {
+				t.Fatalf("Cancel() error = %v, want %v", err, tt.error)
+			}
+			if blocker != nil {
+				manager.Cancel(blocker.Snapshot().ID)
+			}
+			manager.Wait()
+
+			snapshot := job.Snapshot()
+			if snapshot.Status != tt.status || (snapshot.StartedAt != nil) != tt.started || snapshot.FinishedAt == nil {
+				t.Errorf("snapshot = %s, started %v, finished %v; want %s, started %v, finished", snapshot.Status, snapshot.StartedAt != nil, snapshot.FinishedAt != nil, tt.status, tt.started)
+			}
+			if got := eventLog(t, job); !reflect.DeepEqual(got, tt.events) {
+				t.Errorf("events = %q, want %q", got, tt.events)
+			}
+			for _, result := range snapshot.Results {
+				if tt.status == StatusCancelled && result.Status != types.StatusSkip {
+					t.Errorf("%s of the cancelled job is %s, want skipped", result.TestName, result.Status)
+				}
+			}
+			close(arrived)
+			for path := range arrived {
+				if path != "/done" {
+					t.Errorf("request to %s was sent after the job was cancelled", path)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown job", func(t *testing.T) {
+		if _, err := newTestManager().Cancel("missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Cancel() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	server, arrived := testServer(t)
+	manager := newTestManager()
+	job, err := manager.Submit(testSuite(server.URL, "/block"))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-arrived
+
+	// The job outlives the deadline, so it is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Shutdown() error = %v, want context.Canceled", err)
+	}
+	if status := job.Snapshot().Status; status != StatusCancelled {
+		t.Errorf("job status = %s, want cancelled", status)
+	}
+	if _, err := manager.Submit(testSuite(server.URL, "/late")); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Submit() after Shutdown error = %v, want ErrShuttingDown", err)
+	}
+	if active := manager.Active(); active != 0 {
+		t.Errorf("Active() = %d after Shutdown", active)
+	}
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats lists the supported log formats
+var Formats = []string{"text", "json"}
+
+// New creates a structured logger. "text" writes logfmt (key=value) lines and
+// "json" writes one JSON object per line.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level '%s' (expected debug, info, warn or error)", level)
+	}
+	options := &slog.HandlerOptions{Level: minLevel}
+
+	switch strings.ToLower(format) {
+	case "text", "logfmt":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format '%s' (expected %s)", format, strings.Join(Formats, " or "))
+	}
+}
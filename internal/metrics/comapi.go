@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+var (
+	// RunsTotal counts finished suite runs by outcome: passed, failed or cancelled
+	RunsTotal = Default.NewCounterVec("comapi_runs_total", "Finished suite runs by outcome.", "suite", "outcome")
+	// RunsActive is the number of queued or running suite runs
+	RunsActive = Default.NewGaugeVec("comapi_runs_active", "Suite runs queued or in progress.")
+	// RunDuration observes how long whole suite runs take
+	RunDuration = Default.NewHistogramVec("comapi_run_duration_seconds", "Duration of suite runs.", []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}, "suite")
+	// TestsTotal counts test results by status
+	TestsTotal = Default.NewCounterVec("comapi_tests_total", "Test results by suite and status.", "suite", "status")
+	// TargetLatency observes the latency of the API under test
+	TargetLatency = Default.NewHistogramVec("comapi_target_request_duration_seconds", "Latency of requests sent to the API under test.", DefaultBuckets, "suite", "test")
+	// MonitorUp is 1 while a scheduled monitor passes and 0 while it fails
+	MonitorUp = Default.NewGaugeVec("comapi_monitor_up", "Whether a scheduled monitor is passing (1) or failing (0).", "monitor")
+
+	// HTTPRequests counts requests handled by the comapi server itself
+	HTTPRequests = Default.NewCounterVec("comapi_http_requests_total", "Requests handled by the comapi server.", "method", "route", "code")
+	// HTTPDuration observes how long the comapi server takes to answer
+	HTTPDuration = Default.NewHistogramVec("comapi_http_request_duration_seconds", "Time taken by the comapi server to answer requests.", DefaultBuckets, "method", "route")
+)
+
+// ObserveResult records one test result
+func ObserveResult(suite string, result types.TestResult) {
+	TestsTotal.Inc(suite, strings.ToLower(string(result.Status)))
+	if result.Status != types.StatusSkip {
+		TargetLatency.Observe(result.Duration.Seconds(), suite, result.TestName)
+	}
+}
+
+// ObserveRun records a finished suite run
+func ObserveRun(suite string, result types.SuiteResult, cancelled bool) {
+	outcome := "passed"
+	switch {
+	case cancelled:
+		outcome = "cancelled"
+	case result.FailedTests > 0:
+		outcome = "failed"
+	}
+	RunsTotal.Inc(suite, outcome)
+	RunDuration.Observe(result.Duration.Seconds(), suite)
+}
+
+// ObserveHTTP records one request handled by the server
+func ObserveHTTP(method, route, code string, duration time.Duration) {
+	HTTPRequests.Inc(method, route, code)
+	HTTPDuration.Observe(duration.Seconds(), method, route)
+}
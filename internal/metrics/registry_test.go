@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(r *Registry)
+		want   string
+	}{
+		{
+			name: "counter",
+			record: func(r *Registry) {
+				c := r.NewCounterVec("runs_total", "Finished runs.", "suite", "outcome")
+				c.Inc("b", "passed")
+				c.Inc("a", "failed")
+				c.Add(2.5, "a", "failed")
+			},
+			want: `# HELP runs_total Finished runs.
+# TYPE runs_total counter
+runs_total{suite="a",outcome="failed"} 3.5
+runs_total{suite="b",outcome="passed"} 1
+`,
+		},
+		{
+			name: "gauge without labels",
+			record: func(r *Registry) {
+				g := r.NewGaugeVec("active", "Active runs.")
+				g.Set(3)
+				g.Add(-1)
+			},
+			want: `# HELP active Active runs.
+# TYPE active gauge
+active 2
+`,
+		},
+		{
+			name: "family without series",
+			record: func(r *Registry) {
+				r.NewGaugeVec("up", "Whether it is up.", "monitor")
+			},
+			want: `# HELP up Whether it is up.
+# TYPE up gauge
+`,
+		},
+		{
+			name: "histogram buckets are cumulative",
+			record: func(r *Registry) {
+				h := r.NewHistogramVec("duration_seconds", "Durations.", []float64{1, 0.1, 0.5}, "suite")
+				for _, value := range []float64{0.05, 0.1, 0.3, 0.7, 2} {
+					h.Observe(value, "s")
+				}
+			},
+			want: `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{suite="s",le="0.1"} 2
+duration_seconds_bucket{suite="s",le="0.5"} 3
+duration_seconds_bucket{suite="s",le="1"} 4
+duration_seconds_bucket{suite="s",le="+Inf"} 5
+duration_seconds_sum{suite="s"} 3.15
+duration_seconds_count{suite="s"} 5
+`,
+		},
+		{
+			name: "histogram without labels",
+			record: func(r *Registry) {
+				r.NewHistogramVec("latency", "Latency.", []float64{1}).Observe(0.5)
+			},
+			want: `# HELP latency Latency.
+# TYPE latency histogram
+latency_bucket{le="1"} 1
+latency_bucket{le="+Inf"} 1
+latency_sum 0.5
+latency_count 1
+`,
+		},
+		{
+			name: "escaping",
+			record: func(r *Registry) {
+				c := r.NewCounterVec("tests_total", "Tests by \\ name\nand status.", "test")
+				c.Inc("say \"hi\"\n\\o/")
+			},
+			want: `# HELP tests_total Tests by \\ name\nand status.
+# TYPE tests_total counter
+tests_total{test="say \"hi\"\n\\o/"} 1
+`,
+		},
+		{
+			name: "families in registration order",
+			record: func(r *Registry) {
+				r.NewGaugeVec("z", "Last letter.").Set(math.Inf(1))
+				r.NewGaugeVec("a", "First letter.").Set(1e21)
+			},
+			want: `# HELP z Last letter.
+# TYPE z gauge
+z +Inf
+# HELP a First letter.
+# TYPE a gauge
+a 1e+21
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			tt.record(registry)
+			var out strings.Builder
+			if err := registry.Write(&out); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := NewRegistry().NewCounterVec("c", "Counter.", "a", "b")
+	defer func() {
+		if recovered := recover(); recovered != "metric c expects 2 label values, got 1" {
+			t.Errorf("recover() = %v", recovered)
+		}
+	}()
+	c.Inc("only one")
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounterVec("hits_total", "Hits.").Inc()
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if !strings.Contains(recorder.Body.String(), "hits_total 1\n") {
+		t.Errorf("body = %q", recorder.Body.String())
+	}
+}
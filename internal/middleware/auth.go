@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientKey is the context key under which Auth stores the authenticated identity
+const ClientKey = "comapi.client"
+
+// Credentials configure Auth. With no tokens and no basic auth users, every request is allowed.
+type Credentials struct {
+	Tokens    []string          // Accepted as "Authorization: Bearer <token>", X-API-Key or ?access_token=
+	BasicAuth map[string]string // user -> password
+}
+
+// Enabled reports whether any credential is configured
+func (c Credentials) Enabled() bool {
+	return len(c.Tokens) > 0 || len(c.BasicAuth) > 0
+}
+
+// Auth rejects requests without a valid API token or basic auth credentials.
+// The access_token query parameter exists for EventSource, which cannot set headers.
+func Auth(credentials Credentials) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !credentials.Enabled() {
+			c.Next()
+			return
+		}
+
+		if token := requestToken(c); token != "" {
+			for i, accepted := range credentials.Tokens {
+				if secureEqual(token, accepted) {
+					c.Set(ClientKey, tokenIdentity(i))
+					c.Next()
+					return
+				}
+			}
+		}
+
+		if user, password, ok := c.Request.BasicAuth(); ok {
+			if expected, known := credentials.BasicAuth[user]; known && secureEqual(password, expected) {
+				c.Set(ClientKey, "user:"+user)
+				c.Next()
+				return
+			}
+		}
+
+		if len(credentials.BasicAuth) > 0 {
+			c.Header("WWW-Authenticate", `Basic realm="comapi"`)
+		}
+		c.AbortWithStatusJSON(401, gin.H{"error": "Authentication required"})
+	}
+}
+
+func requestToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return c.Query("access_token")
+}
+
+// secureEqual compares secrets in constant time, regardless of their lengths
+func secureEqual(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// tokenIdentity names a client by the position of its token, without exposing the token
+func tokenIdentity(index int) string {
+	return "token:" + strconv.Itoa(index+1)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers preflight requests and allows the listed origins. "*" allows any
+// origin; otherwise the request's Origin is echoed back only when it is listed.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+	allowAny := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin != "" {
+			c.Header("Vary", "Origin")
+			switch {
+			case allowAny:
+				c.Header("Access-Control-Allow-Origin", "*")
+			case allowed[origin]:
+				c.Header("Access-Control-Allow-Origin", origin)
+			}
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		}
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDKey = "comapi.request_id"
+	loggerKey    = "comapi.logger"
+)
+
+// validRequestID limits client-supplied IDs to something safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// quietRoutes are polled often and only logged at debug level
+var quietRoutes = map[string]bool{
+	"/metrics":       true,
+	"/api/v1/health": true,
+}
+
+// RequestLogger assigns every request an ID (reusing a valid incoming X-Request-ID),
+// echoes it in the response, makes a logger carrying it available through Logger,
+// and writes one access log line per request
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Set(requestIDKey, id)
+		c.Set(loggerKey, logger.With("request_id", id))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case quietRoutes[c.FullPath()]:
+			level = slog.LevelDebug
+		}
+
+		attrs := []any{
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if client, ok := c.Get(ClientKey); ok {
+			attrs = append(attrs, "client", client)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.String())
+		}
+		logger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Metrics counts requests and their latency by route
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
+
+// Logger returns the request's logger, which includes its request ID
+func Logger(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerKey); ok {
+		return logger.(*slog.Logger)
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID assigned by RequestLogger
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idleBucketTTL is how long an unused client bucket is kept before being forgotten
+const idleBucketTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimit allows each client perMinute requests on average with bursts of up to
+// burst requests, answering 429 beyond that. Clients are identified by c.ClientIP(),
+// so the engine must only trust forwarding headers from known proxies. The limiter
+// runs before Auth so it also slows down credential guessing.
+// perMinute <= 0 disables it.
+func RateLimit(perMinute float64, burst int) gin.HandlerFunc {
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	rate := perMinute / 60
+	var mu sync.Mutex
+	buckets := make(map[string]*bucket)
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		client := c.ClientIP()
+		now := time.Now()
+
+		mu.Lock()
+		if now.Sub(lastSweep) > idleBucketTTL {
+			for key, b := range buckets {
+				if now.Sub(b.lastSeen) > idleBucketTTL {
+					delete(buckets, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		b, ok := buckets[client]
+		if !ok {
+			b = &bucket{tokens: float64(burst), lastSeen: now}
+			buckets[client] = b
+		}
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.lastSeen).Seconds()*rate)
+		b.lastSeen = now
+
+		allowed := b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+		wait := (1 - b.tokens) / rate
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait))))
+			c.AbortWithStatusJSON(429, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
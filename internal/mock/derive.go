@@ -0,0 +1,152 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// responseFor returns the test's mock_response, filling gaps from its assertions
+func responseFor(test types.TestCase) types.MockResponse {
+	var response types.MockResponse
+	if test.MockResponse != nil {
+		response = *test.MockResponse
+	}
+
+	if response.Status == 0 {
+		response.Status = statusFromAssertions(test)
+	}
+	if response.Body == "" {
+		response.Body = bodyFromAssertions(test.Assertions)
+	}
+
+	headers := headersFromAssertions(test.Assertions)
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	response.Headers = headers
+
+	return response
+}
+
+// statusFromAssertions returns the status the test expects, or 200
+func statusFromAssertions(test types.TestCase) int {
+	for _, assertion := range test.Assertions {
+		if assertion.Type != "status" {
+			continue
+		}
+		switch expected := assertion.Expected.(type) {
+		case int:
+			return expected
+		case float64:
+			return int(expected)
+		case string:
+			if status, err := strconv.Atoi(expected); err == nil {
+				return status
+			}
+		}
+	}
+	return http.StatusOK
+}
+
+// bodyFromAssertions builds a JSON document that satisfies the test's json_path assertions
+func bodyFromAssertions(assertions []types.Assertion) string {
+	var root interface{}
+	for _, assertion := range assertions {
+		if assertion.Type != "json_path" || assertion.Target == "" {
+			continue
+		}
+		root = setPath(root, splitJSONPath(assertion.Target), valueSatisfying(assertion))
+	}
+	if root == nil {
+		return ""
+	}
+
+	encoded, err := json.Marshal(root)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// headersFromAssertions returns headers that satisfy equals/contains header assertions
+func headersFromAssertions(assertions []types.Assertion) map[string]string {
+	headers := make(map[string]string)
+	for _, assertion := range assertions {
+		if assertion.Type == "header" && assertion.Target != "" {
+			headers[assertion.Target] = fmt.Sprintf("%v", assertion.Expected)
+		}
+	}
+	return headers
+}
+
+// valueSatisfying picks a value that passes the assertion's operator
+func valueSatisfying(assertion types.Assertion) interface{} {
+	expected := assertion.Expected
+	number, isNumber := toFloat(expected)
+
+	switch assertion.Operator {
+	case "greater_than":
+		if isNumber {
+			return number + 1
+		}
+	case "less_than":
+		if isNumber {
+			return number - 1
+		}
+	case "not_equals":
+		if isNumber {
+			return number + 1
+		}
+		return fmt.Sprintf("not %v", expected)
+	case "contains":
+		return fmt.Sprintf("%v", expected)
+	}
+	return expected
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// splitJSONPath turns "$.data.items.0.name" into its segments
+func splitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	return strings.Split(path, ".")
+}
+
+// setPath stores value at the given segments, creating objects and arrays as needed
+func setPath(node interface{}, segments []string, value interface{}) interface{} {
+	if len(segments) == 0 {
+		return value
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if index, err := strconv.Atoi(segment); err == nil && index >= 0 {
+		array, _ := node.([]interface{})
+		for len(array) <= index {
+			array = append(array, nil)
+		}
+		array[index] = setPath(array[index], rest, value)
+		return array
+	}
+
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		object = make(map[string]interface{})
+	}
+	object[segment] = setPath(object[segment], rest, value)
+	return object
+}
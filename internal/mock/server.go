@@ -0,0 +1,223 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Options tune the mock server globally; per-test mock_response settings win
+type Options struct {
+	Delay       time.Duration // Added before every response
+	ErrorRate   float64       // Fraction of requests (0-1) answered with ErrorStatus
+	ErrorStatus int           // Defaults to 500
+	Logf        func(format string, args ...interface{})
+}
+
+// Route is one stubbed method + path
+type Route struct {
+	Method   string
+	Path     string
+	TestName string
+	Response types.MockResponse
+	segments []string
+}
+
+// Server answers requests with the stub responses of a suite
+type Server struct {
+	routes  []Route
+	options Options
+	mu      sync.Mutex
+	random  *rand.Rand
+}
+
+// NewServer builds a mock server from a loaded suite. When several tests share
+// a method and path, the first one declared wins.
+func NewServer(suite *types.TestSuite, options Options) *Server {
+	if options.ErrorStatus == 0 {
+		options.ErrorStatus = http.StatusInternalServerError
+	}
+	if options.Logf == nil {
+		options.Logf = func(string, ...interface{}) {}
+	}
+
+	server := &Server{
+		options: options,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	seen := make(map[string]bool)
+	for _, test := range suite.Tests {
+		// gRPC calls cannot be answered by an HTTP/1 mock
+		if test.GRPC != nil {
+			continue
+		}
+		path := routePath(test)
+		method := strings.ToUpper(test.Method)
+		key := method + " " + path
+		if path == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		server.routes = append(server.routes, Route{
+			Method:   method,
+			Path:     path,
+			TestName: test.Name,
+			Response: responseFor(test),
+			segments: splitPath(path),
+		})
+	}
+
+	return server
+}
+
+// Routes returns the stubbed routes in match order
+func (s *Server) Routes() []Route {
+	return s.routes
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, params := s.match(r.Method, r.URL.Path)
+	if route == nil {
+		s.options.Logf("⚠️  Unmatched request: %s %s", r.Method, r.URL.RequestURI())
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"error": fmt.Sprintf("no mock for %s %s", r.Method, r.URL.Path),
+		})
+		return
+	}
+
+	response := route.Response
+	delay := s.options.Delay + time.Duration(response.DelayMs)*time.Millisecond
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	errorRate, errorStatus := s.options.ErrorRate, s.options.ErrorStatus
+	if response.ErrorRate > 0 {
+		errorRate = response.ErrorRate
+	}
+	if response.ErrorStatus != 0 {
+		errorStatus = response.ErrorStatus
+	}
+	if errorRate > 0 && s.roll() < errorRate {
+		s.options.Logf("💥 %s %s -> %d (injected error, %s)", r.Method, r.URL.Path, errorStatus, route.TestName)
+		writeJSON(w, errorStatus, map[string]interface{}{"error": "injected error"})
+		return
+	}
+
+	// Path parameters can be echoed back with {{name}} in the stub body and headers
+	body := substituteParams(response.Body, params)
+	for name, value := range response.Headers {
+		w.Header().Set(name, substituteParams(value, params))
+	}
+	if w.Header().Get("Content-Type") == "" && looksLikeJSON(body) {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	s.options.Logf("✅ %s %s -> %d (%s)", r.Method, r.URL.Path, response.Status, route.TestName)
+	w.WriteHeader(response.Status)
+	if r.Method != http.MethodHead {
+		w.Write([]byte(body))
+	}
+}
+
+// match finds the first route for method and path, returning captured path parameters
+func (s *Server) match(method, path string) (*Route, map[string]string) {
+	segments := splitPath(path)
+	for i := range s.routes {
+		route := &s.routes[i]
+		if route.Method != strings.ToUpper(method) || len(route.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for j, pattern := range route.segments {
+			if name, ok := paramName(pattern); ok {
+				params[name] = segments[j]
+				continue
+			}
+			if pattern != segments[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) roll() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.random.Float64()
+}
+
+// routePath is the path part of a test's request, without query string
+func routePath(test types.TestCase) string {
+	path := test.Path
+	if test.URL != "" {
+		parsed, err := url.Parse(test.URL)
+		if err != nil {
+			return ""
+		}
+		path = parsed.Path
+	}
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		path = "/"
+	}
+	return path
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// paramName recognises path parameters written as :id, {id} or {{id}}
+func paramName(segment string) (string, bool) {
+	switch {
+	case strings.HasPrefix(segment, ":") && len(segment) > 1:
+		return segment[1:], true
+	case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+		return strings.TrimSpace(segment[2 : len(segment)-2]), true
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2:
+		return segment[1 : len(segment)-1], true
+	default:
+		return "", false
+	}
+}
+
+func substituteParams(s string, params map[string]string) string {
+	for name, value := range params {
+		s = strings.ReplaceAll(s, "{{"+name+"}}", value)
+	}
+	return s
+}
+
+func looksLikeJSON(body string) bool {
+	trimmed := strings.TrimSpace(body)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultStateFile is where monitor states are kept between runs
+const DefaultStateFile = ".comapi/monitor-state.json"
+
+// Config is the monitors file:
+//
+//	state_file: .comapi/monitor-state.json
+//	monitors:
+//	  - name: prod-smoke
+//	    suite: suites/smoke.yaml
+//	    schedule: "*/5 * * * *"
+//	    notify: [ops]
+//	notifiers:
+//	  ops:
+//	    type: slack
+//	    url: ${SLACK_WEBHOOK_URL}
+type Config struct {
+	StateFile string                    `yaml:"state_file,omitempty"`
+	Monitors  []Monitor                 `yaml:"monitors"`
+	Notifiers map[string]NotifierConfig `yaml:"notifiers,omitempty"`
+}
+
+// Monitor runs one suite on a schedule
+type Monitor struct {
+	Name     string            `yaml:"name" json:"name"`
+	Suite    string            `yaml:"suite,omitempty" json:"suite,omitempty"`       // Suite file, relative to the monitors file
+	SuiteID  string            `yaml:"suite_id,omitempty" json:"suite_id,omitempty"` // Suite saved on the server
+	Schedule string            `yaml:"schedule" json:"schedule"`
+	BaseURL  string            `yaml:"base_url,omitempty" json:"base_url,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty" json:"-"`
+	// FailureThreshold is how many failed runs in a row turn the monitor failing
+	FailureThreshold int      `yaml:"failure_threshold,omitempty" json:"failure_threshold,omitempty"`
+	Notify           []string `yaml:"notify,omitempty" json:"notify,omitempty"`
+
+	schedule Schedule
+}
+
+// NotifierConfig describes where alerts are sent
+type NotifierConfig struct {
+	Type    string            `yaml:"type"` // webhook, slack or email
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Email settings
+	Host     string   `yaml:"host,omitempty"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       []string `yaml:"to,omitempty"`
+}
+
+// LoadConfig reads a monitors file. ${VAR} references are replaced with
+// environment variables so secrets can stay out of the file, and relative
+// suite and state paths are resolved against the file's directory.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read monitors file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if config.StateFile == "" {
+		config.StateFile = DefaultStateFile
+	} else if !filepath.IsAbs(config.StateFile) {
+		config.StateFile = filepath.Join(dir, config.StateFile)
+	}
+	for i := range config.Monitors {
+		if suite := config.Monitors[i].Suite; suite != "" && !filepath.IsAbs(suite) {
+			config.Monitors[i].Suite = filepath.Join(dir, suite)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks the config and parses every schedule
+func (c *Config) Validate() error {
+	if len(c.Monitors) == 0 {
+		return fmt.Errorf("no monitors defined")
+	}
+
+	for name, notifier := range c.Notifiers {
+		if _, err := NewNotifier(notifier); err != nil {
+			return fmt.Errorf("notifier '%s': %w", name, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Monitors {
+		monitor := &c.Monitors[i]
+		if monitor.Name == "" {
+			return fmt.Errorf("monitor %d has no name", i+1)
+		}
+		if seen[monitor.Name] {
+			return fmt.Errorf("duplicate monitor name '%s'", monitor.Name)
+		}
+		seen[monitor.Name] = true
+
+		if (monitor.Suite == "") == (monitor.SuiteID == "") {
+			return fmt.Errorf("monitor '%s' needs exactly one of suite or suite_id", monitor.Name)
+		}
+		if monitor.Schedule == "" {
+			return fmt.Errorf("monitor '%s' has no schedule", monitor.Name)
+		}
+		schedule, err := ParseSchedule(monitor.Schedule)
+		if err != nil {
+			return fmt.Errorf("monitor '%s': %w", monitor.Name, err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("monitor '%s': schedule '%s' never runs", monitor.Name, monitor.Schedule)
+		}
+		monitor.schedule = schedule
+
+		if monitor.FailureThreshold < 0 {
+			return fmt.Errorf("monitor '%s': failure_threshold cannot be negative", monitor.Name)
+		}
+		for _, name := range monitor.Notify {
+			if _, ok := c.Notifiers[name]; !ok {
+				return fmt.Errorf("monitor '%s' uses unknown notifier '%s' (defined: %v)", monitor.Name, name, c.notifierNames())
+			}
+		}
+	}
+	return nil
+}
+
+// Stored reports whether the monitor runs a suite saved through the API rather
+// than a local file. Such suites come from API callers and are not trusted with
+// the server's environment.
+func (m Monitor) Stored() bool {
+	return m.SuiteID != ""
+}
+
+// Next returns when the monitor runs after t
+func (m Monitor) Next(t time.Time) time.Time {
+	return m.schedule.Next(t)
+}
+
+func (m Monitor) threshold() int {
+	if m.FailureThreshold < 1 {
+		return 1
+	}
+	return m.FailureThreshold
+}
+
+func (c *Config) notifierNames() []string {
+	names := make([]string, 0, len(c.Notifiers))
+	for name := range c.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// A Saturday
+	from := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2026, 3, 14, 10, 10, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 3, 14, 10, 25, 0, 0, time.UTC)},
+		{"7 10 * * *", time.Date(2026, 3, 15, 10, 7, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2026, 3, 15, 9, 30, 0, 0, time.UTC)},
+		{"0 9,17 * * *", time.Date(2026, 3, 14, 17, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month and day of week both restricted: either matches
+		{"0 12 13 * fri", time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2026, 3, 14, 10, 9, 0, 0, time.UTC)},
+		{" @every 1h ", time.Date(2026, 3, 14, 11, 7, 30, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule() error = %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"* * * *", "cron expression '* * * *' must have 5 fields (minute hour day month weekday)"},
+		{"@often", "cron expression '@often' must have 5 fields (minute hour day month weekday)"},
+		{"60 * * * *", "minute: '60' is not between 0 and 59"},
+		{"* 24 * * *", "hour: '24' is not between 0 and 23"},
+		{"* * 0 * *", "day of month: '0' is not between 1 and 31"},
+		{"* * * foo *", "month: 'foo' is not between 1 and 12"},
+		{"* * * * 8", "day of week: '8' is not between 0 and 7"},
+		{"*/0 * * * *", "minute: invalid step '0'"},
+		{"5-1 * * * *", "minute: invalid range '5-1'"},
+		{"@every soon", `invalid @every interval: time: invalid duration "soon"`},
+		{"@every 10ms", "@every interval must be at least 1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseSchedule(tt.spec)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("ParseSchedule() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert is sent when a monitor changes state
+type Alert struct {
+	Monitor    string    `json:"monitor"`
+	Suite      string    `json:"suite"`
+	Status     Status    `json:"status"`
+	Previous   Status    `json:"previous"`
+	Time       time.Time `json:"time"`
+	DurationMs int64     `json:"duration_ms"`
+	Total      int       `json:"total_tests"`
+	Passed     int       `json:"passed_tests"`
+	Failed     int       `json:"failed_tests"`
+	Skipped    int       `json:"skipped_tests"`
+	Failures   []Failure `json:"failures,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Failure is one failed test in an alert
+type Failure struct {
+	Test  string `json:"test"`
+	Error string `json:"error"`
+}
+
+// Subject is a one-line summary of the alert
+func (a Alert) Subject() string {
+	if a.Status == StatusFailing {
+		return a.Monitor + " is failing"
+	}
+	return a.Monitor + " recovered"
+}
+
+// Text is the plain-text alert body
+func (a Alert) Text() string {
+	var b strings.Builder
+	icon := "🟢"
+	if a.Status == StatusFailing {
+		icon = "🔴"
+	}
+	fmt.Fprintf(&b, "%s %s\n", icon, a.Subject())
+	if a.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", a.Error)
+	} else {
+		fmt.Fprintf(&b, "Suite %s: %d/%d passed, %d failed, %d skipped in %dms\n", a.Suite, a.Passed, a.Total, a.Failed, a.Skipped, a.DurationMs)
+	}
+	for _, failure := range a.Failures {
+		fmt.Fprintf(&b, "• %s: %s\n", failure.Test, failure.Error)
+	}
+	return b.String()
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NewNotifier builds the notifier described by config
+func NewNotifier(config NotifierConfig) (Notifier, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch config.Type {
+	case "webhook":
+		if config.URL == "" {
+			return nil, fmt.Errorf("webhook notifier needs a url")
+		}
+		return &webhookNotifier{url: config.URL, headers: config.Headers, client: client}, nil
+	case "slack":
+		if config.URL == "" {
+			return nil, fmt.Errorf("slack notifier needs a url")
+		}
+		return &slackNotifier{url: config.URL, client: client}, nil
+	case "email":
+		if config.Host == "" || config.From == "" || len(config.To) == 0 {
+			return nil, fmt.Errorf("email notifier needs host, from and to")
+		}
+		return &emailNotifier{config: config}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type '%s' (expected webhook, slack or email)", config.Type)
+	}
+}
+
+// webhookNotifier posts the alert as JSON
+type webhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, w.client, w.url, w.headers, alert)
+}
+
+// slackNotifier posts a Slack incoming-webhook message, which Mattermost,
+// Rocket.Chat and Discord's /slack endpoints also accept
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, s.client, s.url, nil, map[string]string{"text": alert.Text()})
+}
+
+// emailNotifier sends the alert over SMTP, using STARTTLS when the server offers it
+type emailNotifier struct {
+	config NotifierConfig
+}
+
+func (e *emailNotifier) Notify(ctx context.Context, alert Alert) error {
+	port := e.config.Port
+	if port == 0 {
+		port = 587
+	}
+	address := net.JoinHostPort(e.config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&message, "Subject: [comapi] %s\r\n", alert.Subject())
+	fmt.Fprintf(&message, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(alert.Text(), "\n", "\r\n"))
+
+	// net/smtp has no context support, so bound the send by abandoning it on cancel
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(address, auth, e.config.From, e.config.To, message.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "comapi-monitor")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %d", url, resp.StatusCode)
+	}
+	return nil
+}
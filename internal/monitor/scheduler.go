@@ -0,0 +1,355 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/metrics"
+	"github.com/Asadus16/comapi/internal/runner"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// ErrUnknownMonitor is returned for monitor names not in the config
+var ErrUnknownMonitor = errors.New("monitor not found")
+
+// ErrBusy is returned when a monitor is triggered while it is already running
+var ErrBusy = errors.New("monitor is already running")
+
+// notifyTimeout bounds how long one notifier may take
+const notifyTimeout = 30 * time.Second
+
+// SuiteLoader returns the suite a monitor runs
+type SuiteLoader func(monitor Monitor) (*types.TestSuite, error)
+
+// Run is the outcome of one monitor run
+type Run struct {
+	Monitor      Monitor           `json:"monitor"`
+	StartedAt    time.Time         `json:"started_at"`
+	Result       types.SuiteResult `json:"result"`
+	Error        string            `json:"error,omitempty"` // The suite could not be loaded
+	State        State             `json:"state"`
+	Alert        *Alert            `json:"alert,omitempty"`
+	NotifyErrors []string          `json:"notify_errors,omitempty"`
+}
+
+// Failed reports whether the run counts as a failure
+func (r Run) Failed() bool {
+	return r.Error != "" || r.Result.FailedTests > 0
+}
+
+// MonitorStatus is the API view of one monitor
+type MonitorStatus struct {
+	Monitor
+	State   State      `json:"state"`
+	Running bool       `json:"running"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// Scheduler runs monitors on their schedules and alerts on state changes
+type Scheduler struct {
+	config     *Config
+	notifiers  map[string]Notifier
+	states     *StateStore
+	loadSuite  SuiteLoader
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	// OnRun is called after every run, e.g. to print or record it
+	OnRun func(run Run)
+
+	mu      sync.Mutex
+	running map[string]bool
+	next    map[string]time.Time
+}
+
+// NewScheduler prepares the notifiers and loads the saved states of a validated config
+func NewScheduler(cfg *Config) (*Scheduler, error) {
+	states, err := OpenStateStore(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers := make(map[string]Notifier, len(cfg.Notifiers))
+	for name, notifierConfig := range cfg.Notifiers {
+		notifier, err := NewNotifier(notifierConfig)
+		if err != nil {
+			return nil, fmt.Errorf("notifier '%s': %w", name, err)
+		}
+		notifiers[name] = notifier
+	}
+
+	// Report the saved states until the monitors next run
+	for _, monitor := range cfg.Monitors {
+		if state := states.Get(monitor.Name); state.Status != StatusUnknown {
+			metrics.MonitorUp.Set(state.up(), monitor.Name)
+		}
+	}
+
+	return &Scheduler{
+		config:    cfg,
+		notifiers: notifiers,
+		states:    states,
+		loadSuite: loadSuiteFile,
+		logger:    slog.Default(),
+		running:   make(map[string]bool),
+		next:      make(map[string]time.Time),
+	}, nil
+}
+
+// SetSuiteLoader replaces how suites are loaded, e.g. to resolve suite_id
+func (s *Scheduler) SetSuiteLoader(loader SuiteLoader) {
+	s.loadSuite = loader
+}
+
+// SetHTTPClient makes monitors send their requests through client
+func (s *Scheduler) SetHTTPClient(client *http.Client) {
+	s.httpClient = client
+}
+
+// SetLogger sets where runs and notifier errors are logged
+func (s *Scheduler) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// Start runs every monitor on its schedule until ctx is cancelled, then waits
+// for runs in progress to stop
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, monitor := range s.config.Monitors {
+		wg.Add(1)
+		go func(monitor Monitor) {
+			defer wg.Done()
+			s.loop(ctx, monitor)
+		}(monitor)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, monitor Monitor) {
+	for {
+		next := monitor.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Error("monitor schedule never matches", "monitor", monitor.Name, "schedule", monitor.Schedule)
+			return
+		}
+		s.mu.Lock()
+		s.next[monitor.Name] = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.RunMonitor(ctx, monitor.Name); errors.Is(err, ErrBusy) {
+			s.logger.Warn("monitor still running, skipping scheduled run", "monitor", monitor.Name)
+		}
+	}
+}
+
+// RunAll runs every monitor once, one after another
+func (s *Scheduler) RunAll(ctx context.Context) []Run {
+	var runs []Run
+	for _, monitor := range s.config.Monitors {
+		run, err := s.RunMonitor(ctx, monitor.Name)
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// RunMonitor runs one monitor now, updates its state and sends any alert
+func (s *Scheduler) RunMonitor(ctx context.Context, name string) (Run, error) {
+	monitor, ok := s.find(name)
+	if !ok {
+		return Run{}, ErrUnknownMonitor
+	}
+
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return Run{}, ErrBusy
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}()
+
+	run := Run{Monitor: monitor, StartedAt: time.Now().UTC()}
+	suite, err := s.loadSuite(monitor)
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.Result = s.execute(ctx, monitor, suite)
+	}
+
+	// A run cut short by shutdown says nothing about the target
+	if ctx.Err() != nil {
+		return run, ctx.Err()
+	}
+
+	previous := s.states.Get(name)
+	state, alert := previous.advance(run.Failed(), monitor.threshold(), time.Now().UTC())
+	state.LastDurationMs = run.Result.Duration.Milliseconds()
+	state.LastPassed = run.Result.PassedTests
+	state.LastFailed = run.Result.FailedTests
+	state.LastError = run.Error
+	if err := s.states.Put(name, state); err != nil {
+		s.logger.Error("failed to save monitor state", "monitor", name, "error", err)
+	}
+	run.State = state
+
+	metrics.MonitorUp.Set(state.up(), name)
+
+	s.logger.Info("monitor run",
+		"monitor", name,
+		"status", state.Status,
+		"passed", run.Result.PassedTests,
+		"failed", run.Result.FailedTests,
+		"duration_ms", state.LastDurationMs,
+		"error", run.Error)
+
+	if alert {
+		a := newAlert(run, previous.Status)
+		run.Alert = &a
+		run.NotifyErrors = s.notify(ctx, monitor, a)
+	}
+
+	if s.OnRun != nil {
+		s.OnRun(run)
+	}
+	return run, nil
+}
+
+// Statuses returns every monitor with its current state, in config order
+func (s *Scheduler) Statuses() []MonitorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]MonitorStatus, 0, len(s.config.Monitors))
+	for _, monitor := range s.config.Monitors {
+		status := MonitorStatus{
+			Monitor: monitor,
+			State:   s.states.Get(monitor.Name),
+			Running: s.running[monitor.Name],
+		}
+		if next, ok := s.next[monitor.Name]; ok {
+			status.NextRun = &next
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (s *Scheduler) find(name string) (Monitor, bool) {
+	for _, monitor := range s.config.Monitors {
+		if monitor.Name == name {
+			return monitor, true
+		}
+	}
+	return Monitor{}, false
+}
+
+func (s *Scheduler) execute(ctx context.Context, monitor Monitor, suite *types.TestSuite) types.SuiteResult {
+	if monitor.BaseURL != "" {
+		suite.BaseURL = monitor.BaseURL
+	}
+	if len(monitor.Headers) > 0 {
+		headers := make(map[string]string, len(suite.Headers)+len(monitor.Headers))
+		for key, value := range suite.Headers {
+			headers[key] = value
+		}
+		for key, value := range monitor.Headers {
+			headers[key] = value
+		}
+		suite.Headers = headers
+	}
+
+	suiteRunner := runner.NewSuiteRunner(suite)
+	if monitor.Stored() {
+		suiteRunner.SetProcessEnv(false)
+	}
+	if s.httpClient != nil {
+		suiteRunner.SetHTTPClient(s.httpClient)
+	}
+	suiteRunner.OnTestComplete = func(index, total int, result types.TestResult) {
+		metrics.ObserveResult(suite.Name, result)
+	}
+	result := suiteRunner.RunContext(ctx)
+	metrics.ObserveRun(suite.Name, result, ctx.Err() != nil)
+	return result
+}
+
+// notify sends the alert to every notifier of the monitor and returns their errors
+func (s *Scheduler) notify(ctx context.Context, monitor Monitor, alert Alert) []string {
+	var errs []string
+	names := append([]string(nil), monitor.Notify...)
+	sort.Strings(names)
+	for _, name := range names {
+		notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := s.notifiers[name].Notify(notifyCtx, alert)
+		cancel()
+		if err != nil {
+			s.logger.Warn("failed to send alert", "monitor", monitor.Name, "notifier", name, "error", err)
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		s.logger.Info("alert sent", "monitor", monitor.Name, "notifier", name, "status", alert.Status)
+	}
+	return errs
+}
+
+func newAlert(run Run, previous Status) Alert {
+	alert := Alert{
+		Monitor:    run.Monitor.Name,
+		Suite:      run.Result.SuiteName,
+		Status:     run.State.Status,
+		Previous:   previous,
+		Time:       *run.State.LastRun,
+		DurationMs: run.Result.Duration.Milliseconds(),
+		Total:      run.Result.TotalTests,
+		Passed:     run.Result.PassedTests,
+		Failed:     run.Result.FailedTests,
+		Skipped:    run.Result.SkippedTests,
+		Error:      run.Error,
+	}
+	if alert.Suite == "" {
+		alert.Suite = run.Monitor.Suite + run.Monitor.SuiteID
+	}
+	for _, result := range run.Result.Results {
+		if result.Status == types.StatusFail {
+			alert.Failures = append(alert.Failures, Failure{Test: result.TestName, Error: result.FailureMessage()})
+		}
+	}
+	return alert
+}
+
+func loadSuiteFile(monitor Monitor) (*types.TestSuite, error) {
+	if monitor.Suite == "" {
+		return nil, fmt.Errorf("suite_id '%s' is only available when monitors run inside comapi server", monitor.SuiteID)
+	}
+	suite, err := config.LoadTestSuite(monitor.Suite)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ResolveSecrets(suite, filepath.Dir(monitor.Suite)); err != nil {
+		return nil, err
+	}
+	return suite, nil
+}
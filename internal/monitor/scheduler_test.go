@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestSchedulerProcessEnv(t *testing.T) {
+	t.Setenv("COMAPI_MONITOR_TEST_FLAG", "yes")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	cfg := &Config{
+		StateFile: filepath.Join(t.TempDir(), "state.json"),
+		Monitors: []Monitor{
+			{Name: "file", Suite: "suite.yaml", Schedule: "@hourly"},
+			{Name: "saved", SuiteID: "abc123", Schedule: "@hourly"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	scheduler, err := NewScheduler(cfg)
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	scheduler.SetSuiteLoader(func(m Monitor) (*types.TestSuite, error) {
+		return &types.TestSuite{
+			Name:    m.Name,
+			BaseURL: server.URL,
+			Tests: []types.TestCase{{
+				Name:       "flagged",
+				Method:     "GET",
+				Path:       "/",
+				When:       "COMAPI_MONITOR_TEST_FLAG",
+				Assertions: []types.Assertion{{Type: "status", Expected: 200}},
+			}},
+		}, nil
+	})
+
+	tests := []struct {
+		monitor string
+		want    types.TestStatus
+	}{
+		// Local suites are written by the operator and may read the environment
+		{"file", types.StatusPass},
+		// Saved suites come from API callers and must not see it
+		{"saved", types.StatusSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.monitor, func(t *testing.T) {
+			run, err := scheduler.RunMonitor(context.Background(), tt.monitor)
+			if err != nil {
+				t.Fatalf("RunMonitor() error = %v", err)
+			}
+			if len(run.Result.Results) != 1 || run.Result.Results[0].Status != tt.want {
+				t.Fatalf("RunMonitor() results = %+v, want one %s", run.Result.Results, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Status is the health of a monitor as seen by its recent runs
+type Status string
+
+const (
+	StatusUnknown Status = "unknown"
+	StatusPassing Status = "passing"
+	StatusFailing Status = "failing"
+)
+
+// State is what a monitor remembers between runs
+type State struct {
+	Status              Status     `json:"status"`
+	Since               *time.Time `json:"since,omitempty"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastDurationMs      int64      `json:"last_duration_ms"`
+	LastPassed          int        `json:"last_passed"`
+	LastFailed          int        `json:"last_failed"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// advance applies the outcome of one run and reports whether an alert is due.
+// A monitor turns failing after threshold failed runs in a row and passing after
+// one successful run; only those transitions alert, except that a monitor whose
+// very first known state is passing stays quiet.
+func (s State) advance(failed bool, threshold int, now time.Time) (State, bool) {
+	previous := s.Status
+	s.LastRun = &now
+
+	if failed {
+		s.ConsecutiveFailures++
+		if s.ConsecutiveFailures < threshold || previous == StatusFailing {
+			return s, false
+		}
+		s.Status = StatusFailing
+		s.Since = &now
+		return s, true
+	}
+
+	s.ConsecutiveFailures = 0
+	if previous == StatusPassing {
+		return s, false
+	}
+	s.Status = StatusPassing
+	s.Since = &now
+	return s, previous == StatusFailing
+}
+
+// up is the value of the comapi_monitor_up metric
+func (s State) up() float64 {
+	if s.Status == StatusFailing {
+		return 0
+	}
+	return 1
+}
+
+// StateStore keeps monitor states in a JSON file
+type StateStore struct {
+	mu     sync.Mutex
+	path   string
+	states map[string]State
+}
+
+// OpenStateStore loads the states saved at path; a missing file starts empty
+func OpenStateStore(path string) (*StateStore, error) {
+	store := &StateStore{path: path, states: make(map[string]State)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read monitor state: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.states); err != nil {
+		return nil, fmt.Errorf("invalid monitor state file %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Get returns the state of a monitor, StatusUnknown if it has never run
+func (s *StateStore) Get(name string) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[name]
+	if !ok || state.Status == "" {
+		state.Status = StatusUnknown
+	}
+	return state
+}
+
+// Put saves the state of a monitor
+func (s *StateStore) Put(name string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = state
+
+	data, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a truncated file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write monitor state: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
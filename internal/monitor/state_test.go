@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateAdvance(t *testing.T) {
+	type step struct {
+		failed bool
+		status Status
+		alert  bool
+	}
+	pass := func(status Status, alert bool) step { return step{false, status, alert} }
+	fail := func(status Status, alert bool) step { return step{true, status, alert} }
+
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{
+			name:      "first pass is quiet",
+			threshold: 1,
+			steps:     []step{pass(StatusPassing, false), pass(StatusPassing, false)},
+		},
+		{
+			name:      "first failure alerts",
+			threshold: 1,
+			steps:     []step{fail(StatusFailing, true), fail(StatusFailing, false)},
+		},
+		{
+			name:      "recovery alerts once",
+			threshold: 1,
+			steps: []step{
+				pass(StatusPassing, false),
+				fail(StatusFailing, true),
+				pass(StatusPassing, true),
+				pass(StatusPassing, false),
+			},
+		},
+		{
+			name:      "failures below the threshold are quiet",
+			threshold: 3,
+			steps: []step{
+				pass(StatusPassing, false),
+				fail(StatusPassing, false),
+				fail(StatusPassing, false),
+				pass(StatusPassing, false),
+				fail(StatusPassing, false),
+				fail(StatusPassing, false),
+				fail(StatusFailing, true),
+				fail(StatusFailing, false),
+				pass(StatusPassing, true),
+			},
+		},
+		{
+			name:      "unknown until the threshold is reached",
+			threshold: 2,
+			steps:     []step{fail(StatusUnknown, false), fail(StatusFailing, true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := State{Status: StatusUnknown}
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				now = now.Add(time.Minute)
+				previous := state.Status
+				var alert bool
+				state, alert = state.advance(s.failed, tt.threshold, now)
+				if state.Status != s.status || alert != s.alert {
+					t.Fatalf("step %d: status %s alert %v, want %s alert %v", i+1, state.Status, alert, s.status, s.alert)
+				}
+				if state.LastRun == nil || !state.LastRun.Equal(now) {
+					t.Fatalf("step %d: last run %v, want %v", i+1, state.LastRun, now)
+				}
+				if state.Status != previous && (state.Since == nil || !state.Since.Equal(now)) {
+					t.Fatalf("step %d: since %v, want %v", i+1, state.Since, now)
+				}
+				if !s.failed && state.ConsecutiveFailures != 0 {
+					t.Fatalf("step %d: consecutive failures %d after a pass", i+1, state.ConsecutiveFailures)
+				}
+			}
+		})
+	}
+}
+
+func TestStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "monitors.json")
+	store, err := OpenStateStore(path)
+	if err != nil {
+		t.Fatalf("OpenStateStore() error = %v", err)
+	}
+	if got := store.Get("api").Status; got != StatusUnknown {
+		t.Fatalf("Get() of a new monitor = %s, want %s", got, StatusUnknown)
+	}
+
+	state, _ := store.Get("api").advance(true, 1, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err := store.Put("api", state); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened, err := OpenStateStore(path)
+	if err != nil {
+		t.Fatalf("OpenStateStore() error = %v", err)
+	}
+	got := reopened.Get("api")
+	if got.Status != StatusFailing || got.ConsecutiveFailures != 1 || got.up() != 0 {
+		t.Errorf("reopened state = %+v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package netguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultDeny blocks the machine itself, private networks and link-local addresses,
+// which include cloud metadata endpoints such as 169.254.169.254. Deny rules win over
+// allow rules, so a server meant to test internal services replaces this list.
+var DefaultDeny = []string{
+	"0.0.0.0/8", "127.0.0.0/8", "::/128", "::1/128", // This host
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7", // Private networks
+	"169.254.0.0/16", "fe80::/10", // Link-local
+}
+
+// Policy decides which hosts outbound test requests may reach. Rules are host names
+// ("api.example.com", "*.example.com"), IP addresses or CIDR ranges ("10.0.0.0/8").
+// Deny rules always win; when allow rules are present, everything else is denied.
+type Policy struct {
+	allowHosts []string
+	allowNets  []*net.IPNet
+	denyHosts  []string
+	denyNets   []*net.IPNet
+}
+
+// NewPolicy parses allow and deny rules
+func NewPolicy(allow, deny []string) (*Policy, error) {
+	policy := &Policy{}
+	var err error
+	if policy.allowHosts, policy.allowNets, err = parseRules(allow); err != nil {
+		return nil, err
+	}
+	if policy.denyHosts, policy.denyNets, err = parseRules(deny); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Restricted reports whether the policy has any rules
+func (p *Policy) Restricted() bool {
+	return len(p.allowHosts)+len(p.allowNets)+len(p.denyHosts)+len(p.denyNets) > 0
+}
+
+// Client returns an http.Client whose connections are checked against the policy.
+// Addresses are checked after DNS resolution, so a permitted name that resolves to a
+// denied address is still blocked, and redirects are checked like any other request.
+func (p *Policy) Client(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// An environment proxy would make every connection go to the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = p.dialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func (p *Policy) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if matchHost(p.denyHosts, host) {
+		return nil, fmt.Errorf("outbound request to %s is denied by server policy", host)
+	}
+	hostAllowed := matchHost(p.allowHosts, host)
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ipString, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipString)
+			if ip == nil {
+				return fmt.Errorf("outbound request to %s is denied by server policy", host)
+			}
+			if containsIP(p.denyNets, ip) {
+				return fmt.Errorf("outbound request to %s (%s) is denied by server policy", host, ip)
+			}
+			if p.hasAllowRules() && !hostAllowed && !containsIP(p.allowNets, ip) {
+				return fmt.Errorf("outbound request to %s (%s) is not in the server's allow list", host, ip)
+			}
+			return nil
+		},
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+func (p *Policy) hasAllowRules() bool {
+	return len(p.allowHosts)+len(p.allowNets) > 0
+}
+
+// parseRules splits rules into host patterns and networks; single IPs become /32 or /128
+func parseRules(rules []string) ([]string, []*net.IPNet, error) {
+	var hosts []string
+	var nets []*net.IPNet
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" {
+			continue
+		}
+		if strings.Contains(rule, "/") {
+			_, network, err := net.ParseCIDR(rule)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid CIDR '%s': %w", rule, err)
+			}
+			nets = append(nets, network)
+			continue
+		}
+		if ip := net.ParseIP(rule); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		hosts = append(hosts, strings.TrimSuffix(rule, "."))
+	}
+	return hosts, nets, nil
+}
+
+// matchHost matches exact names and "*.example.com" wildcards, which also match example.com
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, network := range nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package netguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		restricted bool
+		error      string
+	}{
+		{name: "no rules"},
+		{name: "empty rules are skipped", deny: []string{"", "  "}},
+		{name: "default deny list", deny: DefaultDeny, restricted: true},
+		{name: "hosts, IPs and networks", allow: []string{"API.example.com.", "*.example.org", "203.0.113.7", "2001:db8::/32"}, restricted: true},
+		{name: "invalid CIDR", deny: []string{"10.0.0.0/33"}, error: "invalid CIDR '10.0.0.0/33'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.allow, tt.deny)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Fatalf("NewPolicy() error = %v, want %q", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+			if policy.Restricted() != tt.restricted {
+				t.Errorf("Restricted() = %v, want %v", policy.Restricted(), tt.restricted)
+			}
+		})
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"api.example.com", "*.example.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.example.com", true},
+		{"www.api.example.com", false},
+		{"example.com", false},
+		{"example.org", true},
+		{"a.b.example.org", true},
+		{"badexample.org", false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(patterns, tt.host); got != tt.want {
+			t.Errorf("matchHost(%s) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+// TestDialDenied checks addresses against the policy. The check runs before the
+// connection is made, so none of these addresses is contacted.
+func TestDialDenied(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		address string
+		error   string
+	}{
+		{name: "loopback", deny: DefaultDeny, address: "127.0.0.1:80", error: "outbound request to 127.0.0.1 (127.0.0.1) is denied"},
+		{name: "unspecified", deny: DefaultDeny, address: "0.0.0.0:80", error: "is denied"},
+		{name: "IPv6 loopback", deny: DefaultDeny, address: "[::1]:80", error: "is denied"},
+		{name: "IPv4-mapped loopback", deny: DefaultDeny, address: "[::ffff:127.0.0.1]:80", error: "is denied"},
+		{name: "private 10/8", deny: DefaultDeny, address: "10.1.2.3:80", error: "is denied"},
+		{name: "private 172.16/12", deny: DefaultDeny, address: "172.31.255.1:443", error: "is denied"},
+		{name: "private 192.168/16", deny: DefaultDeny, address: "192.168.0.10:8080", error: "is denied"},
+		{name: "unique local IPv6", deny: DefaultDeny, address: "[fd00::1]:80", error: "is denied"},
+		{name: "cloud metadata", deny: DefaultDeny, address: "169.254.169.254:80", error: "outbound request to 169.254.169.254 (169.254.169.254) is denied"},
+		{name: "link-local IPv6", deny: DefaultDeny, address: "[fe80::1]:80", error: "is denied"},
+		{name: "name resolving to loopback", deny: DefaultDeny, address: "localhost:80", error: "outbound request to localhost ("},
+		{name: "denied host name", deny: []string{"*.internal.example.com"}, address: "db.internal.example.com:5432", error: "outbound request to db.internal.example.com is denied"},
+		{name: "host names are matched without case or trailing dot", deny: []string{"metadata.example.com"}, address: "Metadata.Example.com.:80", error: "is denied"},
+		{name: "not in the allow list", allow: []string{"*.example.com", "198.51.100.0/24"}, address: "203.0.113.1:80", error: "outbound request to 203.0.113.1 (203.0.113.1) is not in the server's allow list"},
+		{name: "deny wins over allow", allow: []string{"10.0.0.0/8"}, deny: DefaultDeny, address: "10.0.0.1:80", error: "is denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			conn, err := policy.dialContext(ctx, "tcp", tt.address)
+			if err == nil {
+				conn.Close()
+				t.Fatalf("dial %s succeeded, want it denied", tt.address)
+			}
+			if !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("dial %s error = %v, want %q", tt.address, err, tt.error)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.URL.Query().Get("redirect"); target != "" {
+			http.Redirect(w, r, target, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	byName := "http://localhost:" + serverURL.Port()
+
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		url   string
+		error string
+	}{
+		{name: "no rules", url: server.URL},
+		{name: "default deny list", deny: DefaultDeny, url: server.URL, error: "is denied by server policy"},
+		{name: "name resolved at dial time", deny: DefaultDeny, url: byName, error: "outbound request to localhost ("},
+		{name: "allowed address", allow: []string{"127.0.0.1"}, url: server.URL},
+		{name: "allowed name", allow: []string{"localhost"}, url: byName},
+		{name: "allowed name, other address", allow: []string{"localhost"}, url: server.URL, error: "is not in the server's allow list"},
+		{name: "redirects are checked", deny: []string{"localhost"}, url: server.URL + "?redirect=" + url.QueryEscape(byName), error: "outbound request to localhost is denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := policy.Client(5 * time.Second).Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			switch {
+			case tt.error == "" && err != nil:
+				t.Errorf("GET %s error = %v", tt.url, err)
+			case tt.error != "" && (err == nil || !strings.Contains(err.Error(), tt.error)):
+				t.Errorf("GET %s error = %v, want %q", tt.url, err, tt.error)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Asadus16/comapi/internal/redact"
+	"github.com/Asadus16/comapi/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// maxKeyFields limits how many top-level JSON fields get an assertion per exchange
+const maxKeyFields = 5
+
+// maxRequestBody bounds the request bodies the proxy buffers
+const maxRequestBody = 10 << 20
+
+// skippedHeaders are connection-level or volatile and are never recorded
+var skippedHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Date":              true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Host":  true,
+	"X-Forwarded-Proto": true,
+}
+
+// Recorder is a reverse proxy that turns every exchange into a test case
+type Recorder struct {
+	target   *url.URL
+	outFile  string
+	proxy    *httputil.ReverseProxy
+	logf     func(format string, args ...interface{})
+	redactor *redact.Redactor
+
+	mu    sync.Mutex
+	suite types.TestSuite
+	names map[string]int
+}
+
+// NewRecorder creates a recorder that forwards to target and writes the suite to outFile
+// after every exchange, so nothing is lost when the process is interrupted
+func NewRecorder(target, outFile, suiteName string, logf func(format string, args ...interface{})) (*Recorder, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Scheme == "" || targetURL.Host == "" {
+		return nil, fmt.Errorf("invalid target URL '%s'", target)
+	}
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	r := &Recorder{
+		target:  targetURL,
+		outFile: outFile,
+		logf:    logf,
+		suite: types.TestSuite{
+			Name:    suiteName,
+			BaseURL: strings.TrimRight(target, "/"),
+		},
+		names:    make(map[string]int),
+		redactor: redact.New(),
+	}
+
+	r.proxy = httputil.NewSingleHostReverseProxy(targetURL)
+	director := r.proxy.Director
+	r.proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = targetURL.Host
+		// Let the transport handle compression so recorded bodies are plain text
+		req.Header.Del("Accept-Encoding")
+	}
+	r.proxy.ModifyResponse = r.capture
+
+	return r, nil
+}
+
+// ServeHTTP implements http.Handler
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Buffer the request body so it can be both forwarded and recorded
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(w, req.Body, maxRequestBody))
+		req.Body.Close()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("request body could not be read: %v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req = req.WithContext(withRequestBody(req.Context(), body))
+
+	r.proxy.ServeHTTP(w, req)
+}
+
+// capture records the exchange once the upstream response has arrived
+func (r *Recorder) capture(resp *http.Response) error {
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	resp.ContentLength = int64(len(responseBody))
+	resp.Header.Del("Content-Length")
+
+	req := resp.Request
+	requestBody := requestBodyFrom(req.Context())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	test := r.buildTest(req, requestBody, resp, responseBody)
+	r.suite.Tests = append(r.suite.Tests, test)
+	r.logf("🔴 %s %s -> %d (recorded as '%s')", req.Method, req.URL.RequestURI(), resp.StatusCode, test.Name)
+
+	if err := r.writeLocked(); err != nil {
+		r.logf("❌ Failed to write %s: %v", r.outFile, err)
+	}
+	return nil
+}
+
+// buildTest converts one exchange into a test case with a replayable mock response
+func (r *Recorder) buildTest(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte) types.TestCase {
+	path := strings.TrimPrefix(req.URL.RequestURI(), strings.TrimRight(r.target.Path, "/"))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	name := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+	r.names[name]++
+	if count := r.names[name]; count > 1 {
+		name = fmt.Sprintf("%s (%d)", name, count)
+	}
+
+	// Credentials in the query string and in either body are masked like headers
+	body := r.redactor.Credentials(string(requestBody))
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		body = strings.TrimPrefix(r.redactor.URL("?"+body), "?")
+	}
+	responseBody = []byte(r.redactor.Credentials(string(responseBody)))
+
+	test := types.TestCase{
+		Name:    name,
+		Method:  req.Method,
+		Path:    r.redactor.URL(path),
+		Headers: r.requestHeaders(req.Header),
+		Body:    body,
+		MockResponse: &types.MockResponse{
+			Status:  resp.StatusCode,
+			Headers: r.redactor.Headers(recordHeaders(resp.Header)),
+			Body:    string(responseBody),
+		},
+	}
+
+	test.Assertions = append(test.Assertions, types.Assertion{Type: "status", Expected: resp.StatusCode})
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+		test.Assertions = append(test.Assertions, types.Assertion{Type: "header", Target: "Content-Type", Operator: "contains", Expected: mediaType})
+	}
+	test.Assertions = append(test.Assertions, r.keyFieldAssertions(responseBody)...)
+
+	return test
+}
+
+// keyFieldAssertions asserts the scalar top-level fields of a JSON object response,
+// preferring identifiers and names. Credential fields are left out, since their
+// value would end up in the file.
+func (r *Recorder) keyFieldAssertions(body []byte) []types.Assertion {
+	var object map[string]interface{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(object))
+	for key, value := range object {
+		if r.redactor.IsSecretHeader(key) {
+			continue
+		}
+		switch value.(type) {
+		case string, float64, bool:
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := keyPriority(keys[i]), keyPriority(keys[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > maxKeyFields {
+		keys = keys[:maxKeyFields]
+	}
+
+	assertions := make([]types.Assertion, len(keys))
+	for i, key := range keys {
+		value := object[key]
+		if number, ok := value.(float64); ok && number == float64(int64(number)) {
+			value = int64(number)
+		}
+		assertions[i] = types.Assertion{Type: "json_path", Target: "$." + key, Expected: value}
+	}
+	return assertions
+}
+
+// keyPriority ranks field names that usually identify a resource first
+func keyPriority(key string) int {
+	lower := strings.ToLower(key)
+	switch {
+	case lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(key, "Id"):
+		return 0
+	case lower == "name" || lower == "type" || lower == "status" || lower == "login" || lower == "email":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// recordHeaders keeps end-to-end headers, taking the first value of each
+func recordHeaders(header http.Header) map[string]string {
+	recorded := make(map[string]string)
+	for name, values := range header {
+		if skippedHeaders[http.CanonicalHeaderKey(name)] || len(values) == 0 {
+			continue
+		}
+		recorded[name] = values[0]
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded
+}
+
+// requestHeaders records request headers with credentials such as Authorization
+// or X-Api-Key replaced by a {{NAME}} placeholder. Each placeholder is declared as
+// a secret read from the environment variable NAME, so replays can supply the
+// value without it being written to the file. Callers must hold r.mu.
+func (r *Recorder) requestHeaders(header http.Header) map[string]string {
+	recorded := recordHeaders(header)
+	for name, value := range recorded {
+		if !r.redactor.IsSecretHeader(name) {
+			continue
+		}
+		secret := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		placeholder := "{{" + secret + "}}"
+		if scheme, _, ok := strings.Cut(value, " "); ok && strings.HasSuffix(strings.ToLower(name), "authorization") {
+			placeholder = scheme + " " + placeholder
+		}
+		recorded[name] = placeholder
+
+		if _, declared := r.suite.Secrets[secret]; !declared {
+			if r.suite.Secrets == nil {
+				r.suite.Secrets = make(map[string]types.Secret)
+			}
+			r.suite.Secrets[secret] = types.Secret{Env: secret}
+			r.logf("🔑 %s header recorded as %s; set %s to replay it", name, placeholder, secret)
+		}
+	}
+	return recorded
+}
+
+// Suite returns a copy of the recorded suite
+func (r *Recorder) Suite() types.TestSuite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suite := r.suite
+	suite.Tests = append([]types.TestCase(nil), r.suite.Tests...)
+	if r.suite.Secrets != nil {
+		suite.Secrets = make(map[string]types.Secret, len(r.suite.Secrets))
+		for name, secret := range r.suite.Secrets {
+			suite.Secrets[name] = secret
+		}
+	}
+	return suite
+}
+
+// writeLocked saves the suite as YAML; callers must hold r.mu
+func (r *Recorder) writeLocked() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(r.suite); err != nil {
+		return err
+	}
+	encoder.Close()
+
+	header := fmt.Sprintf("# Recorded by comapi from %s\n# Replay offline with: comapi replay %s\n", r.suite.BaseURL, r.outFile)
+	return os.WriteFile(r.outFile, append([]byte(header), buf.Bytes()...), 0644)
+}
+
+// requestBodyKey stores the buffered request body in the request context
+type requestBodyKey struct{}
+
+func withRequestBody(ctx context.Context, body []byte) context.Context {
+	return context.WithValue(ctx, requestBodyKey{}, body)
+}
+
+func requestBodyFrom(ctx context.Context) []byte {
+	body, _ := ctx.Value(requestBodyKey{}).([]byte)
+	return body
+}
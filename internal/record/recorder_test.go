@@ -0,0 +1,237 @@
+package record
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestRecorderMasksCredentials(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "sid=cookie-value-1")
+		w.Write([]byte(`{"id": 7, "name": "ann", "access_token": "issued-token-1", "profile": {"password": "stored-password-1"}}`))
+	}))
+	defer upstream.Close()
+
+	out := filepath.Join(t.TempDir(), "suite.yaml")
+	recorder, err := NewRecorder(upstream.URL, out, "recorded", nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	requests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{"/login?api_key=query-key-1&page=2", "application/json", `{"user": "ann", "password": "sent-password-1"}`},
+		{"/login", "application/x-www-form-urlencoded", "user=ann&password=form-password-1"},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest("POST", proxy.URL+r.path, strings.NewReader(r.body))
+		req.Header.Set("Content-Type", r.contentType)
+		req.Header.Set("Authorization", "Bearer bearer-token-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request through the recorder failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := string(data)
+
+	for _, secret := range []string{
+		"bearer-token-1",
+		"query-key-1",
+		"sent-password-1",
+		"form-password-1",
+		"issued-token-1",
+		"stored-password-1",
+		"cookie-value-1",
+	} {
+		if strings.Contains(written, secret) {
+			t.Errorf("%s was written to the suite:\n%s", secret, written)
+		}
+	}
+	for _, kept := range []string{"Bearer {{AUTHORIZATION}}", "page=2", "user=ann", "$.id", "$.name"} {
+		if !strings.Contains(written, kept) {
+			t.Errorf("%q is missing from the suite:\n%s", kept, written)
+		}
+	}
+	if strings.Contains(written, "$.access_token") {
+		t.Errorf("credential field got an assertion:\n%s", written)
+	}
+}
+
+func TestKeyFieldAssertions(t *testing.T) {
+	recorder, err := NewRecorder("http://api.example.com", filepath.Join(t.TempDir(), "suite.yaml"), "recorded", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want []types.Assertion
+	}{
+		{name: "not JSON", body: "plain text"},
+		{name: "array", body: `[{"id": 1}]`},
+		{
+			name: "identifiers and names first",
+			body: `{"zeta": "z", "name": "ann", "user_id": 3, "id": 7, "active": true}`,
+			want: []types.Assertion{
+				{Type: "json_path", Target: "$.id", Expected: int64(7)},
+				{Type: "json_path", Target: "$.user_id", Expected: int64(3)},
+				{Type: "json_path", Target: "$.name", Expected: "ann"},
+				{Type: "json_path", Target: "$.active", Expected: true},
+				{Type: "json_path", Target: "$.zeta", Expected: "z"},
+			},
+		},
+		{
+			name: "objects, arrays and nulls are skipped",
+			body: `{"ratio": 1.5, "tags": ["a"], "owner": {"id": 1}, "deleted_at": null}`,
+			want: []types.Assertion{{Type: "json_path", Target: "$.ratio", Expected: 1.5}},
+		},
+		{
+			name: "credential fields are skipped",
+			body: `{"id": 1, "access_token": "t", "password": "p", "session_id": "s"}`,
+			want: []types.Assertion{{Type: "json_path", Target: "$.id", Expected: int64(1)}},
+		},
+		{
+			name: "at most five fields",
+			body: `{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7}`,
+			want: []types.Assertion{
+				{Type: "json_path", Target: "$.a", Expected: int64(1)},
+				{Type: "json_path", Target: "$.b", Expected: int64(2)},
+				{Type: "json_path", Target: "$.c", Expected: int64(3)},
+				{Type: "json_path", Target: "$.d", Expected: int64(4)},
+				{Type: "json_path", Target: "$.e", Expected: int64(5)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := recorder.keyFieldAssertions([]byte(tt.body))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keyFieldAssertions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    map[string]string
+		secrets []string
+	}{
+		{name: "no headers", headers: http.Header{}},
+		{
+			name:    "hop-by-hop and volatile headers are dropped",
+			headers: http.Header{"Accept": {"application/json"}, "Connection": {"keep-alive"}, "Date": {"today"}, "X-Forwarded-For": {"10.0.0.1"}},
+			want:    map[string]string{"Accept": "application/json"},
+		},
+		{
+			name:    "first value is kept",
+			headers: http.Header{"Accept": {"text/html", "application/json"}},
+			want:    map[string]string{"Accept": "text/html"},
+		},
+		{
+			name:    "authorization keeps its scheme",
+			headers: http.Header{"Authorization": {"Bearer abc"}},
+			want:    map[string]string{"Authorization": "Bearer {{AUTHORIZATION}}"},
+			secrets: []string{"AUTHORIZATION"},
+		},
+		{
+			name:    "other credentials become placeholders",
+			headers: http.Header{"X-Api-Key": {"k-1"}, "X-Session-Token": {"s-1"}, "Accept": {"*/*"}},
+			want:    map[string]string{"X-Api-Key": "{{X_API_KEY}}", "X-Session-Token": "{{X_SESSION_TOKEN}}", "Accept": "*/*"},
+			secrets: []string{"X_API_KEY", "X_SESSION_TOKEN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, err := NewRecorder("http://api.example.com", filepath.Join(t.TempDir(), "suite.yaml"), "recorded", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := recorder.requestHeaders(tt.headers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestHeaders() = %v, want %v", got, tt.want)
+			}
+
+			secrets := recorder.Suite().Secrets
+			if len(secrets) != len(tt.secrets) {
+				t.Fatalf("declared secrets %v, want %v", secrets, tt.secrets)
+			}
+			for _, name := range tt.secrets {
+				if secrets[name] != (types.Secret{Env: name}) {
+					t.Errorf("secret %s = %+v, want it read from the environment variable %s", name, secrets[name], name)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTestPathsAndNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		paths  []string
+		want   [][2]string // name and path of each recorded test
+	}{
+		{
+			name:   "repeated requests are numbered",
+			target: "http://api.example.com",
+			paths:  []string{"/users?page=1", "/users?page=2", "/users/1"},
+			want:   [][2]string{{"GET /users", "/users?page=1"}, {"GET /users (2)", "/users?page=2"}, {"GET /users/1", "/users/1"}},
+		},
+		{
+			name:   "target path is trimmed",
+			target: "http://api.example.com/v1/",
+			paths:  []string{"/v1/users", "/v1"},
+			want:   [][2]string{{"GET /v1/users", "/users"}, {"GET /v1", "/"}},
+		},
+		{
+			name:   "credential query parameters are masked",
+			target: "http://api.example.com",
+			paths:  []string{"/search?q=go&access_token=abcd"},
+			want:   [][2]string{{"GET /search", "/search?q=go&access_token=[REDACTED]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, err := NewRecorder(tt.target, filepath.Join(t.TempDir(), "suite.yaml"), "recorded", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, path := range tt.paths {
+				req := httptest.NewRequest("GET", path, nil)
+				resp := &http.Response{StatusCode: 200, Header: http.Header{}}
+				test := recorder.buildTest(req, nil, resp, nil)
+				if got := [2]string{test.Name, test.Path}; got != tt.want[i] {
+					t.Errorf("request %s recorded as %v, want %v", path, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
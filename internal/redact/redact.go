@@ -0,0 +1,293 @@
+package redact
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// Mask replaces secret values
+const Mask = "[REDACTED]"
+
+// DefaultHeaders are always masked
+var DefaultHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Access-Token",
+	"X-Csrf-Token",
+}
+
+// secretWords mark header and query parameter names that are masked even when not listed
+var secretWords = []string{"token", "secret", "password", "api-key", "api_key", "apikey", "session"}
+
+// minSecretLength keeps very short secret values, which would mask unrelated
+// text, from being searched for
+const minSecretLength = 4
+
+// Redactor masks secrets in headers, URLs and bodies before they are shown or saved
+type Redactor struct {
+	headers map[string]bool
+	paths   []string
+	secrets []string
+}
+
+// New creates a redactor for the default headers plus the given names
+func New(headers ...string) *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+	for _, name := range append(DefaultHeaders, headers...) {
+		r.headers[strings.ToLower(name)] = true
+	}
+	return r
+}
+
+// ForSuite creates a redactor for the suite's resolved secrets and redact rules
+func ForSuite(suite *types.TestSuite) *Redactor {
+	var r *Redactor
+	if suite.Redact != nil {
+		r = New(suite.Redact.Headers...)
+		r.AddPaths(suite.Redact.JSONPaths...)
+	} else {
+		r = New()
+	}
+	for _, secret := range suite.Secrets {
+		r.AddSecrets(secret.Value)
+	}
+	return r
+}
+
+// AddPaths masks the values at these gjson paths in JSON bodies
+func (r *Redactor) AddPaths(paths ...string) {
+	r.paths = append(r.paths, paths...)
+}
+
+// AddSecrets masks these values wherever they appear, also in URL-encoded form
+func (r *Redactor) AddSecrets(values ...string) {
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+		r.secrets = append(r.secrets, value)
+		if escaped := url.QueryEscape(value); escaped != value {
+			r.secrets = append(r.secrets, escaped)
+		}
+	}
+	// Longest first, so a secret containing another is masked whole
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+// String masks every secret value in s
+func (r *Redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// IsSecretHeader reports whether the value of a header or query parameter must be masked
+func (r *Redactor) IsSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	if r.headers[name] {
+		return true
+	}
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the value to show for a header. The scheme of an
+// Authorization value is kept so "Bearer" and "Basic" remain visible.
+func (r *Redactor) Header(name, value string) string {
+	if value == "" || !r.IsSecretHeader(name) {
+		return r.String(value)
+	}
+	if scheme, _, ok := strings.Cut(value, " "); ok && strings.HasSuffix(strings.ToLower(name), "authorization") {
+		return scheme + " " + Mask
+	}
+	return Mask
+}
+
+// Headers returns a copy of headers with secret values masked
+func (r *Redactor) Headers(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(headers))
+	for name, value := range headers {
+		masked[name] = r.Header(name, value)
+	}
+	return masked
+}
+
+// URL masks secret values and the values of secret-looking query parameters,
+// such as access_token or api_key
+func (r *Redactor) URL(raw string) string {
+	raw = r.String(raw)
+	base, query, ok := strings.Cut(raw, "?")
+	if !ok {
+		return raw
+	}
+	query, fragment, hasFragment := strings.Cut(query, "#")
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, ok := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if ok && r.IsSecretHeader(name) {
+			params[i] = param[:strings.Index(param, "=")+1] + Mask
+		}
+	}
+
+	masked := base + "?" + strings.Join(params, "&")
+	if hasFragment {
+		masked += "#" + fragment
+	}
+	return masked
+}
+
+// Body masks secret values and, in JSON bodies, the values at the configured paths
+func (r *Redactor) Body(body string) string {
+	body = r.String(body)
+	if len(r.paths) == 0 || !gjson.Valid(body) {
+		return body
+	}
+
+	// Collect the byte range of every matched value
+	type span struct{ start, end int }
+	var spans []span
+	for _, path := range r.paths {
+		result := gjson.Get(body, path)
+		switch {
+		case len(result.Indexes) > 0:
+			for i, item := range result.Array() {
+				if i < len(result.Indexes) {
+					spans = append(spans, span{result.Indexes[i], result.Indexes[i] + len(item.Raw)})
+				}
+			}
+		case result.Exists() && result.Index > 0:
+			spans = append(spans, span{result.Index, result.Index + len(result.Raw)})
+		}
+	}
+
+	// Drop values nested in one that is masked anyway, then splice from the end
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var outer []span
+	for _, s := range spans {
+		if n := len(outer); n > 0 && s.start < outer[n-1].end {
+			continue
+		}
+		outer = append(outer, s)
+	}
+	masked := body
+	for i := len(outer) - 1; i >= 0; i-- {
+		masked = masked[:outer[i].start] + `"` + Mask + `"` + masked[outer[i].end:]
+	}
+	return masked
+}
+
+// Credentials masks like Body and also, in JSON bodies, the value of every field
+// whose name looks like a credential, such as password or access_token, at any depth
+func (r *Redactor) Credentials(body string) string {
+	if !gjson.Valid(body) {
+		return r.Body(body)
+	}
+	var paths []string
+	var walk func(value gjson.Result, prefix string)
+	walk = func(value gjson.Result, prefix string) {
+		value.ForEach(func(key, item gjson.Result) bool {
+			path := key.String()
+			if !value.IsArray() {
+				path = gjson.Escape(path)
+			}
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			if value.IsObject() && r.IsSecretHeader(key.String()) {
+				paths = append(paths, path)
+			} else if item.IsObject() || item.IsArray() {
+				walk(item, path)
+			}
+			return true
+		})
+	}
+	walk(gjson.Parse(body), "")
+
+	fields := &Redactor{headers: r.headers, paths: append(paths, r.paths...), secrets: r.secrets}
+	return fields.Body(body)
+}
+
+// Result returns a copy of result with secrets masked in the request, the
+// response, the error and the assertion values
+func (r *Redactor) Result(result types.TestResult) types.TestResult {
+	result.Request.URL = r.URL(result.Request.URL)
+	result.Request.Headers = r.Headers(result.Request.Headers)
+	result.Request.Body = r.Body(result.Request.Body)
+	result.Response.Headers = r.Headers(result.Response.Headers)
+	result.Response.Body = r.Body(result.Response.Body)
+	result.Error = r.String(result.Error)
+
+	if len(result.Assertions) > 0 {
+		assertions := make([]types.AssertionResult, len(result.Assertions))
+		for i, assertion := range result.Assertions {
+			if r.coversAssertion(assertion) {
+				assertion.Actual = Mask
+				if !assertion.Passed {
+					assertion.Message = fmt.Sprintf("%s: value at %s is redacted", assertion.Type, assertion.Target)
+				}
+			} else {
+				assertion.Actual = r.value(assertion.Actual)
+				assertion.Message = r.String(assertion.Message)
+			}
+			assertion.Expected = r.value(assertion.Expected)
+			assertions[i] = assertion
+		}
+		result.Assertions = assertions
+	}
+	return result
+}
+
+// SuiteResult returns a copy of result with every test result redacted
+func (r *Redactor) SuiteResult(result types.SuiteResult) types.SuiteResult {
+	results := make([]types.TestResult, len(result.Results))
+	for i, test := range result.Results {
+		results[i] = r.Result(test)
+	}
+	result.Results = results
+	return result
+}
+
+// coversAssertion reports whether the assertion reads a masked header or JSON path
+func (r *Redactor) coversAssertion(assertion types.AssertionResult) bool {
+	switch assertion.Type {
+	case "header":
+		return r.IsSecretHeader(assertion.Target)
+	case "json_path":
+		target := strings.TrimPrefix(strings.TrimPrefix(assertion.Target, "$"), ".")
+		for _, path := range r.paths {
+			if target == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// value masks secrets inside a string assertion value
+func (r *Redactor) value(value interface{}) interface{} {
+	if s, ok := value.(string); ok {
+		return r.String(s)
+	}
+	return value
+}
@@ -0,0 +1,228 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestHeader(t *testing.T) {
+	r := New("X-Internal")
+	r.AddSecrets("s3cr3t-value")
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Authorization", "Bearer abc.def", "Bearer [REDACTED]"},
+		{"Proxy-Authorization", "Basic dXNlcjpwYXNz", "Basic [REDACTED]"},
+		{"authorization", "opaque", "[REDACTED]"},
+		{"Cookie", "session=1", "[REDACTED]"},
+		{"X-Internal", "yes", "[REDACTED]"},
+		{"X-Refresh-Token", "abc", "[REDACTED]"},
+		{"X-Client-Secret", "abc", "[REDACTED]"},
+		{"Content-Type", "application/json", "application/json"},
+		{"X-Trace", "id s3cr3t-value", "id [REDACTED]"},
+		{"Authorization", "", ""},
+	}
+	for _, tt := range tests {
+		if got := r.Header(tt.name, tt.value); got != tt.want {
+			t.Errorf("Header(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		input   string
+		want    string
+	}{
+		{name: "plain", secrets: []string{"hunter22"}, input: "password is hunter22!", want: "password is [REDACTED]!"},
+		{name: "url encoded", secrets: []string{"p@ss word"}, input: "pw=p%40ss+word", want: "pw=[REDACTED]"},
+		{name: "longest first", secrets: []string{"abcd", "abcdefgh"}, input: "abcdefgh abcd", want: "[REDACTED] [REDACTED]"},
+		{name: "short secrets are ignored", secrets: []string{"abc"}, input: "abc", want: "abc"},
+		{name: "no secrets", input: "nothing to hide", want: "nothing to hide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.AddSecrets(tt.secrets...)
+			if got := r.String(tt.input); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	r := New()
+	r.AddSecrets("k3y-in-path")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://api.example.com/users?page=2", "https://api.example.com/users?page=2"},
+		{"https://api.example.com/users?access_token=abc&page=2", "https://api.example.com/users?access_token=[REDACTED]&page=2"},
+		{"https://api.example.com/?API_KEY=abc#top", "https://api.example.com/?API_KEY=[REDACTED]#top"},
+		{"https://api.example.com/?client%5Fsecret=abc", "https://api.example.com/?client%5Fsecret=[REDACTED]"},
+		{"https://api.example.com/?token", "https://api.example.com/?token"},
+		{"https://api.example.com/k3y-in-path/items", "https://api.example.com/[REDACTED]/items"},
+	}
+	for _, tt := range tests {
+		if got := r.URL(tt.input); got != tt.want {
+			t.Errorf("URL(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		body  string
+		want  string
+	}{
+		{
+			name:  "top-level field",
+			paths: []string{"token"},
+			body:  `{"token": "abc", "user": "ann"}`,
+			want:  `{"token": "[REDACTED]", "user": "ann"}`,
+		},
+		{
+			name:  "nested object and number",
+			paths: []string{"data.card", "data.pin"},
+			body:  `{"data": {"card": {"number": "4111"}, "pin": 1234}}`,
+			want:  `{"data": {"card": "[REDACTED]", "pin": "[REDACTED]"}}`,
+		},
+		{
+			name:  "every element of an array",
+			paths: []string{"users.#.password"},
+			body:  `{"users": [{"name": "a", "password": "x"}, {"name": "b", "password": "y"}]}`,
+			want:  `{"users": [{"name": "a", "password": "[REDACTED]"}, {"name": "b", "password": "[REDACTED]"}]}`,
+		},
+		{
+			name:  "nested path inside a masked one",
+			paths: []string{"auth.token", "auth"},
+			body:  `{"auth": {"token": "abc"}}`,
+			want:  `{"auth": "[REDACTED]"}`,
+		},
+		{
+			name:  "missing path",
+			paths: []string{"secret"},
+			body:  `{"public": 1}`,
+			want:  `{"public": 1}`,
+		},
+		{
+			name:  "not JSON",
+			paths: []string{"token"},
+			body:  `token=abc`,
+			want:  `token=abc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.AddPaths(tt.paths...)
+			if got := r.Body(tt.body); got != tt.want {
+				t.Errorf("Body() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForSuiteResult(t *testing.T) {
+	suite := &types.TestSuite{
+		Secrets: map[string]types.Secret{"API_KEY": {Env: "API_KEY", Value: "live-key-123"}},
+		Redact:  &types.Redaction{Headers: []string{"X-Account"}, JSONPaths: []string{"data.ssn"}},
+	}
+	r := ForSuite(suite)
+
+	result := types.TestResult{
+		Request: types.RequestInfo{
+			URL:     "https://api.example.com/v1?key=live-key-123",
+			Headers: map[string]string{"Authorization": "Bearer live-key-123", "X-Account": "42"},
+			Body:    `{"note": "live-key-123"}`,
+		},
+		Response: types.ResponseInfo{
+			Headers: map[string]string{"Set-Cookie": "sid=1", "Content-Type": "application/json"},
+			Body:    `{"data": {"ssn": "123-45-6789", "name": "ann"}}`,
+		},
+		Error: "request with live-key-123 failed",
+		Assertions: []types.AssertionResult{
+			{Type: "json_path", Target: "$.data.ssn", Expected: "000-00-0000", Actual: "123-45-6789", Passed: false, Message: "expected 000-00-0000, got 123-45-6789"},
+			{Type: "header", Target: "X-Account", Expected: "42", Actual: "42", Passed: true},
+			{Type: "json_path", Target: "data.name", Expected: "live-key-123", Actual: "ann", Passed: false, Message: "expected live-key-123, got ann"},
+		},
+	}
+	got := r.Result(result)
+
+	want := types.TestResult{
+		Request: types.RequestInfo{
+			URL:     "https://api.example.com/v1?key=[REDACTED]",
+			Headers: map[string]string{"Authorization": "Bearer [REDACTED]", "X-Account": "[REDACTED]"},
+			Body:    `{"note": "[REDACTED]"}`,
+		},
+		Response: types.ResponseInfo{
+			Headers: map[string]string{"Set-Cookie": "[REDACTED]", "Content-Type": "application/json"},
+			Body:    `{"data": {"ssn": "[REDACTED]", "name": "ann"}}`,
+		},
+		Error: "request with [REDACTED] failed",
+		Assertions: []types.AssertionResult{
+			{Type: "json_path", Target: "$.data.ssn", Expected: "000-00-0000", Actual: "[REDACTED]", Passed: false, Message: "json_path: value at $.data.ssn is redacted"},
+			{Type: "header", Target: "X-Account", Expected: "42", Actual: "[REDACTED]", Passed: true},
+			{Type: "json_path", Target: "data.name", Expected: "[REDACTED]", Actual: "ann", Passed: false, Message: "expected [REDACTED], got ann"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Result() =\n%+v\nwant\n%+v", got, want)
+	}
+	if result.Request.Headers["Authorization"] != "Bearer live-key-123" {
+		t.Errorf("Result() modified its argument")
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "top-level fields",
+			body: `{"user": "ann", "password": "hunter22", "access_token": "abc"}`,
+			want: `{"user": "ann", "password": "[REDACTED]", "access_token": "[REDACTED]"}`,
+		},
+		{
+			name: "nested objects and arrays",
+			body: `{"data": {"sessions": [{"id": 1}], "keys": [{"api_key": "k1"}, {"api_key": "k2"}]}}`,
+			want: `{"data": {"sessions": "[REDACTED]", "keys": [{"api_key": "[REDACTED]"}, {"api_key": "[REDACTED]"}]}}`,
+		},
+		{
+			name: "keys with path characters",
+			body: `{"client.secret": "s", "count": 2}`,
+			want: `{"client.secret": "[REDACTED]", "count": 2}`,
+		},
+		{
+			name: "no credentials",
+			body: `{"id": 7, "tags": ["token"]}`,
+			want: `{"id": 7, "tags": ["token"]}`,
+		},
+		{
+			name: "not JSON",
+			body: `password=hunter22`,
+			want: `password=hunter22`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New().Credentials(tt.body); got != tt.want {
+				t.Errorf("Credentials() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
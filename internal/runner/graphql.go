@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Asadus16/comapi/internal/graphql"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// withGraphQL returns the test with its GraphQL operation encoded: as query
+// parameters for GET, otherwise as a JSON body. Headers the test sets win.
+func withGraphQL(test types.TestCase) (types.TestCase, error) {
+	request := *test.GraphQL
+	headers := make(map[string]string, len(test.Headers)+2)
+	headers["Accept"] = graphql.Accept
+
+	if strings.EqualFold(test.Method, http.MethodGet) {
+		values, err := graphql.Values(request)
+		if err != nil {
+			return test, err
+		}
+		if test.URL != "" {
+			test.URL = appendQuery(test.URL, values.Encode())
+		} else {
+			test.Path = appendQuery(test.Path, values.Encode())
+		}
+	} else {
+		body, err := graphql.Body(request)
+		if err != nil {
+			return test, err
+		}
+		test.Body = body
+		headers["Content-Type"] = graphql.ContentType
+	}
+
+	for name, value := range test.Headers {
+		for existing := range headers {
+			if strings.EqualFold(existing, name) {
+				delete(headers, existing)
+			}
+		}
+		headers[name] = value
+	}
+	test.Headers = headers
+	return test, nil
+}
+
+// appendQuery adds an encoded query string to a path or URL
+func appendQuery(target, query string) string {
+	if strings.Contains(target, "?") {
+		return target + "&" + query
+	}
+	return target + "?" + query
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/assertion"
+	"github.com/Asadus16/comapi/internal/grpc"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// executeGRPC calls the test's unary gRPC method on the server at target. The
+// gRPC status code becomes the response status, the response message as JSON
+// (or the status as JSON when the call failed) its body, and the response
+// headers and trailers its headers.
+func (h *HTTPClient) executeGRPC(testCase types.TestCase, target string) types.TestResult {
+	startTime := time.Now()
+	request := *testCase.GRPC
+
+	// Metadata goes on top of the suite and test headers
+	headers := h.mergeHeaders(testCase.Headers)
+	for name, value := range request.Metadata {
+		headers[name] = value
+	}
+	for name := range headers {
+		if strings.EqualFold(name, "Content-Type") {
+			delete(headers, name)
+		}
+	}
+	headers["Content-Type"] = grpc.ContentType
+
+	result := types.TestResult{
+		TestName: testCase.Name,
+		Status:   types.StatusFail, // Default to fail, change to pass if all assertions pass
+		Request: types.RequestInfo{
+			Method:  http.MethodPost,
+			URL:     grpc.MethodURL(target, request.Service, request.Method),
+			Headers: headers,
+			Body:    messageText(request.Message),
+		},
+	}
+
+	if h.grpc == nil {
+		h.grpc = grpc.NewClient(h.client)
+	}
+
+	// Look the method up first so reflection does not count towards the timings
+	if _, _, err := h.grpc.Resolve(h.ctx, target, request); err != nil {
+		result.Error = fmt.Sprintf("gRPC call failed: %v", err)
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	ctx, trace := withTrace(h.ctx)
+	resp, err := h.grpc.Invoke(ctx, target, request, headers)
+	result.Timings = trace.timings(time.Now())
+	result.Duration = time.Since(startTime)
+	if err != nil {
+		result.Error = fmt.Sprintf("gRPC call failed: %v", err)
+		return result
+	}
+
+	result.Response = types.ResponseInfo{
+		StatusCode: resp.Code,
+		Headers:    resp.Metadata,
+		Body:       resp.Body,
+		Size:       resp.Size,
+		Proto:      resp.Proto,
+	}
+
+	// Run assertions to determine if test passes or fails
+	assertion.CheckAssertionsWithVariables(testCase, &result, h.variables)
+
+	return result
+}
+
+// messageText renders a request message for results and traces
+func messageText(message interface{}) string {
+	switch value := message.(type) {
+	case nil:
+		return "{}"
+	case string:
+		return value
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(encoded)
+	}
+}
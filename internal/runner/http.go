@@ -2,6 +2,7 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,14 +10,18 @@ import (
 	"time"
 
 	"github.com/Asadus16/comapi/internal/assertion"
+	"github.com/Asadus16/comapi/internal/grpc"
 	"github.com/Asadus16/comapi/pkg/types"
 )
 
 // HTTPClient handles making HTTP requests for tests
 type HTTPClient struct {
-	client  *http.Client
-	baseURL string
-	headers map[string]string
+	client    *http.Client
+	baseURL   string
+	headers   map[string]string
+	variables map[string]string
+	ctx       context.Context
+	grpc      *grpc.Client // Created on the first gRPC test
 }
 
 // NewHTTPClient creates a new HTTP client for testing
@@ -27,12 +32,43 @@ func NewHTTPClient(baseURL string, defaultHeaders map[string]string) *HTTPClient
 		},
 		baseURL: strings.TrimRight(baseURL, "/"),
 		headers: defaultHeaders,
+		ctx:     context.Background(),
 	}
 }
 
+// SetVariables makes run variables available to expression assertions
+func (h *HTTPClient) SetVariables(variables map[string]string) {
+	h.variables = variables
+}
+
+// SetContext attaches ctx to every request, so cancelling it aborts requests in flight
+func (h *HTTPClient) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// SetHTTPClient replaces the underlying http.Client, e.g. with an httptest.Server's client
+func (h *HTTPClient) SetHTTPClient(client *http.Client) {
+	h.client = client
+	h.grpc = nil
+}
+
 // ExecuteTest runs a single test case and returns the result (legacy method)
 func (h *HTTPClient) ExecuteTest(testCase types.TestCase) types.TestResult {
+	if testCase.GRPC != nil {
+		return h.executeGRPC(testCase, h.baseURL)
+	}
 	startTime := time.Now()
+	if testCase.GraphQL != nil {
+		encoded, err := withGraphQL(testCase)
+		if err != nil {
+			return types.TestResult{
+				TestName: testCase.Name,
+				Status:   types.StatusFail,
+				Error:    fmt.Sprintf("Invalid GraphQL request: %v", err),
+			}
+		}
+		testCase = encoded
+	}
 	
 	result := types.TestResult{
 		TestName: testCase.Name,
@@ -44,45 +80,27 @@ func (h *HTTPClient) ExecuteTest(testCase types.TestCase) types.TestResult {
 	}
 
 	// Make the HTTP request
-	resp, err := h.makeRequest(testCase)
-	if err != nil {
-		result.Error = fmt.Sprintf("Request failed: %v", err)
-		result.Duration = time.Since(startTime)
-		return result
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		result.Error = fmt.Sprintf("Failed to read response body: %v", err)
-		result.Duration = time.Since(startTime)
-		return result
-	}
-
-	// Fill in response info
-	result.Response = types.ResponseInfo{
-		StatusCode: resp.StatusCode,
-		Headers:    convertHeaders(resp.Header),
-		Body:       string(bodyBytes),
-		Size:       int64(len(bodyBytes)),
-	}
-	
-	// Fill in request info
-	result.Request.Headers = h.mergeHeaders(testCase.Headers)
-	result.Request.Body = testCase.Body
-	
-	result.Duration = time.Since(startTime)
-
-	// Run assertions to determine if test passes or fails
-	assertion.CheckAssertions(testCase, &result)
-	
-	return result
+	resp, trace, err := h.makeRequest(testCase)
+	return h.completeResult(testCase, result, startTime, resp, trace, err)
 }
 
 // ExecuteTestWithFullURL runs a single test case with a complete URL
 func (h *HTTPClient) ExecuteTestWithFullURL(testCase types.TestCase) types.TestResult {
+	if testCase.GRPC != nil {
+		return h.executeGRPC(testCase, strings.TrimRight(testCase.URL, "/"))
+	}
 	startTime := time.Now()
+	if testCase.GraphQL != nil {
+		encoded, err := withGraphQL(testCase)
+		if err != nil {
+			return types.TestResult{
+				TestName: testCase.Name,
+				Status:   types.StatusFail,
+				Error:    fmt.Sprintf("Invalid GraphQL request: %v", err),
+			}
+		}
+		testCase = encoded
+	}
 	
 	result := types.TestResult{
 		TestName: testCase.Name,
@@ -94,16 +112,26 @@ func (h *HTTPClient) ExecuteTestWithFullURL(testCase types.TestCase) types.TestR
 	}
 
 	// Make the HTTP request with full URL
-	resp, err := h.makeRequestWithFullURL(testCase)
+	resp, trace, err := h.makeRequestWithFullURL(testCase)
+	return h.completeResult(testCase, result, startTime, resp, trace, err)
+}
+
+// completeResult reads the response of a request sent at startTime, records its
+// timings and runs the test's assertions
+func (h *HTTPClient) completeResult(testCase types.TestCase, result types.TestResult, startTime time.Time, resp *http.Response, trace *timingTrace, err error) types.TestResult {
 	if err != nil {
 		result.Error = fmt.Sprintf("Request failed: %v", err)
 		result.Duration = time.Since(startTime)
+		if trace != nil {
+			result.Timings = trace.timings(time.Now())
+		}
 		return result
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	bodyBytes, err := io.ReadAll(resp.Body)
+	result.Timings = trace.timings(time.Now())
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to read response body: %v", err)
 		result.Duration = time.Since(startTime)
@@ -116,6 +144,7 @@ func (h *HTTPClient) ExecuteTestWithFullURL(testCase types.TestCase) types.TestR
 		Headers:    convertHeaders(resp.Header),
 		Body:       string(bodyBytes),
 		Size:       int64(len(bodyBytes)),
+		Proto:      resp.Proto,
 	}
 	
 	// Fill in request info
@@ -125,13 +154,13 @@ func (h *HTTPClient) ExecuteTestWithFullURL(testCase types.TestCase) types.TestR
 	result.Duration = time.Since(startTime)
 
 	// Run assertions to determine if test passes or fails
-	assertion.CheckAssertions(testCase, &result)
+	assertion.CheckAssertionsWithVariables(testCase, &result, h.variables)
 	
 	return result
 }
 
 // makeRequest creates and executes the HTTP request (legacy method)
-func (h *HTTPClient) makeRequest(testCase types.TestCase) (*http.Response, error) {
+func (h *HTTPClient) makeRequest(testCase types.TestCase) (*http.Response, *timingTrace, error) {
 	url := h.baseURL + testCase.Path
 	
 	// Create request body
@@ -140,10 +169,11 @@ func (h *HTTPClient) makeRequest(testCase types.TestCase) (*http.Response, error
 		body = bytes.NewBufferString(testCase.Body)
 	}
 	
-	// Create request
-	req, err := http.NewRequest(testCase.Method, url, body)
+	// Create request, recording the timing of each phase
+	ctx, trace := withTrace(h.ctx)
+	req, err := http.NewRequestWithContext(ctx, testCase.Method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	// Add headers (merge default headers with test-specific headers)
@@ -153,11 +183,12 @@ func (h *HTTPClient) makeRequest(testCase types.TestCase) (*http.Response, error
 	}
 	
 	// Make the request
-	return h.client.Do(req)
+	resp, err := h.client.Do(req)
+	return resp, trace, err
 }
 
 // makeRequestWithFullURL creates and executes the HTTP request using complete URL
-func (h *HTTPClient) makeRequestWithFullURL(testCase types.TestCase) (*http.Response, error) {
+func (h *HTTPClient) makeRequestWithFullURL(testCase types.TestCase) (*http.Response, *timingTrace, error) {
 	url := testCase.URL
 	
 	// Create request body
@@ -166,10 +197,11 @@ func (h *HTTPClient) makeRequestWithFullURL(testCase types.TestCase) (*http.Resp
 		body = bytes.NewBufferString(testCase.Body)
 	}
 	
-	// Create request
-	req, err := http.NewRequest(testCase.Method, url, body)
+	// Create request, recording the timing of each phase
+	ctx, trace := withTrace(h.ctx)
+	req, err := http.NewRequestWithContext(ctx, testCase.Method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	// Add headers (merge default headers with test-specific headers)
@@ -179,7 +211,8 @@ func (h *HTTPClient) makeRequestWithFullURL(testCase types.TestCase) (*http.Resp
 	}
 	
 	// Make the request
-	return h.client.Do(req)
+	resp, err := h.client.Do(req)
+	return resp, trace, err
 }
 
 // mergeHeaders combines default headers with test-specific headers
@@ -0,0 +1,251 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/condition"
+	"github.com/Asadus16/comapi/internal/redact"
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// SuiteRunner executes all tests of a suite, honouring depends_on and when
+type SuiteRunner struct {
+	suite     *types.TestSuite
+	client    *HTTPClient
+	variables map[string]string
+	secrets   vars.Lookup
+	redactor  *redact.Redactor
+	// processEnv lets when expressions fall back to the process environment
+	processEnv bool
+
+	// OnTestStart is called before each test is executed (optional)
+	OnTestStart func(index, total int, test types.TestCase)
+	// OnTestComplete is called with each result as soon as it is available (optional)
+	OnTestComplete func(index, total int, result types.TestResult)
+}
+
+// NewSuiteRunner creates a runner for the given suite. Resolved secrets replace
+// {{NAME}} in requests and, like the suite's redact rules, are masked in every result.
+func NewSuiteRunner(suite *types.TestSuite) *SuiteRunner {
+	variables := make(map[string]string, len(suite.Environment)+len(suite.Secrets))
+	for key, value := range suite.Environment {
+		variables[key] = value
+	}
+	secrets := make(map[string]interface{}, len(suite.Secrets))
+	for name, secret := range suite.Secrets {
+		variables[name] = secret.Value
+		secrets[name] = secret.Value
+	}
+	lookup := vars.FromMap(secrets)
+
+	client := NewHTTPClient(suite.BaseURL, vars.ExpandMap(suite.Headers, lookup))
+	client.SetVariables(variables)
+
+	return &SuiteRunner{
+		suite:     suite,
+		client:    client,
+		variables: variables,
+		secrets:   lookup,
+		redactor:  redact.ForSuite(suite),
+
+		processEnv: true,
+	}
+}
+
+// SetVariable sets or overrides a run variable
+func (s *SuiteRunner) SetVariable(name, value string) {
+	s.variables[name] = value
+}
+
+// SetProcessEnv controls whether when expressions can read the process
+// environment. Suites from untrusted sources should only see their own variables.
+func (s *SuiteRunner) SetProcessEnv(enabled bool) {
+	s.processEnv = enabled
+}
+
+// SetHTTPClient replaces the http.Client used for every request
+func (s *SuiteRunner) SetHTTPClient(client *http.Client) {
+	s.client.SetHTTPClient(client)
+}
+
+// lookupVariable resolves a variable from the suite environment, then the process environment
+func (s *SuiteRunner) lookupVariable(name string) (string, bool) {
+	if value, ok := s.variables[name]; ok {
+		return value, true
+	}
+	if !s.processEnv {
+		return "", false
+	}
+	return os.LookupEnv(name)
+}
+
+// Run executes the suite and returns the aggregated result
+func (s *SuiteRunner) Run() types.SuiteResult {
+	return s.RunContext(context.Background())
+}
+
+// RunContext executes the suite until ctx is cancelled. Tests that have not
+// started by then are reported as skipped.
+func (s *SuiteRunner) RunContext(ctx context.Context) types.SuiteResult {
+	s.client.SetContext(ctx)
+	startTime := time.Now()
+	order := executionOrder(s.suite.Tests)
+	statuses := make(map[string]types.TestStatus, len(order))
+
+	suiteResult := types.SuiteResult{
+		SuiteName:  s.suite.Name,
+		TotalTests: len(order),
+	}
+
+	for i, test := range order {
+		if s.OnTestStart != nil {
+			s.OnTestStart(i, len(order), test)
+		}
+
+		var result types.TestResult
+		if ctx.Err() != nil {
+			result = types.TestResult{
+				TestName:   test.Name,
+				Status:     types.StatusSkip,
+				SkipReason: "Run cancelled",
+			}
+		} else if reason := s.skipReason(test, statuses); reason != "" {
+			result = types.TestResult{
+				TestName:   test.Name,
+				Status:     types.StatusSkip,
+				SkipReason: reason,
+			}
+		} else {
+			result = s.redactor.Result(s.execute(test))
+			// A request aborted by cancellation says nothing about the API
+			if ctx.Err() != nil && result.Status == types.StatusFail {
+				result = types.TestResult{
+					TestName:   test.Name,
+					Status:     types.StatusSkip,
+					SkipReason: "Run cancelled",
+				}
+			}
+		}
+
+		statuses[test.Name] = result.Status
+		suiteResult.Results = append(suiteResult.Results, result)
+
+		switch result.Status {
+		case types.StatusPass:
+			suiteResult.PassedTests++
+		case types.StatusSkip:
+			suiteResult.SkippedTests++
+		default:
+			suiteResult.FailedTests++
+		}
+
+		if s.OnTestComplete != nil {
+			s.OnTestComplete(i, len(order), result)
+		}
+	}
+
+	suiteResult.Duration = time.Since(startTime)
+	return suiteResult
+}
+
+// execute runs a single test, using the full URL when one is given
+func (s *SuiteRunner) execute(test types.TestCase) types.TestResult {
+	if len(s.suite.Secrets) > 0 {
+		test.URL = vars.Expand(test.URL, s.secrets)
+		test.Path = vars.Expand(test.Path, s.secrets)
+		test.Headers = vars.ExpandMap(test.Headers, s.secrets)
+		test.Body = vars.Expand(test.Body, s.secrets)
+		if test.GraphQL != nil {
+			request := *test.GraphQL
+			request.Query = vars.Expand(request.Query, s.secrets)
+			request.Variables = expandVariables(request.Variables, s.secrets)
+			test.GraphQL = &request
+		}
+		if test.GRPC != nil {
+			request := *test.GRPC
+			request.Message = vars.ExpandValue(request.Message, s.secrets)
+			request.Metadata = vars.ExpandMap(request.Metadata, s.secrets)
+			test.GRPC = &request
+		}
+	}
+	if test.URL != "" {
+		return s.client.ExecuteTestWithFullURL(test)
+	}
+	return s.client.ExecuteTest(test)
+}
+
+// expandVariables replaces placeholders inside GraphQL variables
+func expandVariables(variables map[string]interface{}, lookup vars.Lookup) map[string]interface{} {
+	if variables == nil {
+		return nil
+	}
+	expanded, _ := vars.ExpandValue(variables, lookup).(map[string]interface{})
+	return expanded
+}
+
+// skipReason returns why a test must be skipped, or "" if it should run
+func (s *SuiteRunner) skipReason(test types.TestCase, statuses map[string]types.TestStatus) string {
+	var unmet []string
+	for _, dep := range test.DependsOn {
+		status, ran := statuses[dep]
+		switch {
+		case !ran:
+			unmet = append(unmet, fmt.Sprintf("'%s' did not run", dep))
+		case status != types.StatusPass:
+			unmet = append(unmet, fmt.Sprintf("'%s' %s", dep, strings.ToLower(string(status))))
+		}
+	}
+	if len(unmet) > 0 {
+		return "Dependency not met: " + strings.Join(unmet, ", ")
+	}
+
+	if test.When != "" {
+		ok, err := condition.Evaluate(test.When, s.lookupVariable)
+		if err != nil {
+			return fmt.Sprintf("Invalid when expression: %v", err)
+		}
+		if !ok {
+			return fmt.Sprintf("Condition not met: %s", test.When)
+		}
+	}
+
+	return ""
+}
+
+// executionOrder returns the tests in declaration order, except that a test's
+// dependencies are always moved ahead of it
+func executionOrder(tests []types.TestCase) []types.TestCase {
+	byName := make(map[string]int, len(tests))
+	for i, test := range tests {
+		byName[test.Name] = i
+	}
+
+	ordered := make([]types.TestCase, 0, len(tests))
+	visited := make([]bool, len(tests))
+
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, dep := range tests[i].DependsOn {
+			if j, ok := byName[dep]; ok {
+				visit(j)
+			}
+		}
+		ordered = append(ordered, tests[i])
+	}
+
+	for i := range tests {
+		visit(i)
+	}
+
+	return ordered
+}
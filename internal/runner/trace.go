@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"math"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// timingTrace records when each phase of a request starts and ends. When
+// redirects are followed each hop starts over, so the phases describe the last
+// hop while start still marks the first. The transport may call hooks from its
+// dialing goroutines, hence the lock.
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	hopStart     time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// withTrace returns ctx instrumented to record timings into a new trace
+func withTrace(ctx context.Context) (context.Context, *timingTrace) {
+	t := &timingTrace{}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { t.startHop() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		DNSStart:     func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart: func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.mark(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.mark(&t.tlsDone)
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// startHop begins a new round trip: the first request or a redirect
+func (t *timingTrace) startHop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.start.IsZero() {
+		t.start = now
+	}
+	t.hopStart = now
+	t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+	t.connectStart, t.connectDone = time.Time{}, time.Time{}
+	t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+	t.wroteRequest, t.firstByte = time.Time{}, time.Time{}
+	t.reused = false
+}
+
+// mark records the first time a phase boundary is reached in the current hop;
+// with several addresses the transport may dial more than once
+func (t *timingTrace) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// timings computes the phases once the body has been read at end
+func (t *timingTrace) timings(end time.Time) *types.Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() {
+		return nil
+	}
+	return &types.Timings{
+		DNS:      between(t.dnsStart, t.dnsDone),
+		Connect:  between(t.connectStart, t.connectDone),
+		TLS:      between(t.tlsStart, t.tlsDone),
+		Wait:     between(t.wroteRequest, t.firstByte),
+		TTFB:     between(t.hopStart, t.firstByte),
+		Transfer: between(t.firstByte, end),
+		Total:    between(t.start, end),
+		Reused:   t.reused,
+
+		StartedAt: t.start,
+	}
+}
+
+// between is the time from start to end in milliseconds, or 0 if either is unknown
+func between(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return math.Round(float64(end.Sub(start).Microseconds())) / 1000
+}
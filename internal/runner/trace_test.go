@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestTimingsAfterRedirect(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	result := NewHTTPClient(server.URL, nil).ExecuteTest(types.TestCase{Name: "redirect", Method: "GET", Path: "/start"})
+	if result.Error != "" {
+		t.Fatalf("ExecuteTest() error = %s", result.Error)
+	}
+	timings := result.Timings
+	if timings == nil {
+		t.Fatal("no timings recorded")
+	}
+
+	// The wait belongs to the final hop; the redirect's own response must not end it early
+	ms := float64(delay.Milliseconds())
+	if timings.Wait < ms || timings.TTFB < ms {
+		t.Errorf("wait %.1fms, ttfb %.1fms, want at least %.0fms", timings.Wait, timings.TTFB, ms)
+	}
+	if timings.Transfer >= ms {
+		t.Errorf("transfer %.1fms includes the final hop's wait", timings.Transfer)
+	}
+	if timings.Total < timings.TTFB+timings.Transfer {
+		t.Errorf("total %.1fms is less than ttfb %.1fms + transfer %.1fms", timings.Total, timings.TTFB, timings.Transfer)
+	}
+}
@@ -0,0 +1,411 @@
+package suites
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// historyDir holds previous versions, one directory per suite
+const historyDir = ".history"
+
+var (
+	// ErrNotFound is returned for unknown suite IDs or versions
+	ErrNotFound = errors.New("suite not found")
+	// ErrConflict is returned when an update was based on an outdated version
+	ErrConflict = errors.New("suite was modified since it was loaded")
+
+	validID     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	slugIllegal = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Summary describes a stored suite in listings
+type Summary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	BaseURL   string    `json:"base_url,omitempty"`
+	Tests     int       `json:"tests"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Path      string    `json:"path"`
+}
+
+// Stored is one version of a suite with its YAML source
+type Stored struct {
+	Summary
+	Content string           `json:"content"`
+	Suite   *types.TestSuite `json:"test_suite"`
+}
+
+// Version describes one entry of a suite's history
+type Version struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Size      int64     `json:"size"`
+	Current   bool      `json:"current"`
+}
+
+// Store keeps suites as <id>.yaml files in a directory, so the CLI can run them directly.
+// Every update or delete first copies the current file to .history/<id>/<version>.yaml.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore opens (and creates if needed) a suite directory
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create suite directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory backing the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Path returns the file a suite is stored in
+func (s *Store) Path(id string) string {
+	return filepath.Join(s.dir, id+".yaml")
+}
+
+// List returns every stored suite, sorted by ID
+func (s *Store) List() ([]Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite directory: %w", err)
+	}
+
+	summaries := []Summary{}
+	for _, entry := range entries {
+		id, ok := suiteID(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+		stored, err := s.readLocked(id)
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, stored.Summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	return summaries, nil
+}
+
+// Get returns the current version of a suite
+func (s *Store) Get(id string) (*Stored, error) {
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked(id)
+}
+
+// Create stores a new suite under an ID derived from its name and returns it
+func (s *Store) Create(content []byte) (*Stored, error) {
+	name, err := suiteName(content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := slugify(name)
+	id := base
+	for n := 2; s.existsLocked(id); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	if err := writeFile(s.Path(id), content); err != nil {
+		return nil, err
+	}
+	return s.readLocked(id)
+}
+
+// Update replaces a suite, keeping the previous content in its history. When
+// baseVersion is non-zero it must match the current version.
+func (s *Store) Update(id string, content []byte, baseVersion int) (*Stored, error) {
+	if _, err := suiteName(content); err != nil {
+		return nil, err
+	}
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.existsLocked(id) {
+		return nil, ErrNotFound
+	}
+	version := s.currentVersionLocked(id)
+	if baseVersion != 0 && baseVersion != version {
+		return nil, ErrConflict
+	}
+
+	if err := s.archiveLocked(id, version); err != nil {
+		return nil, err
+	}
+	if err := writeFile(s.Path(id), content); err != nil {
+		return nil, err
+	}
+	return s.readLocked(id)
+}
+
+// Delete removes a suite. Its history is kept, so a later create with the same
+// name continues the version numbering.
+func (s *Store) Delete(id string) error {
+	if !validID.MatchString(id) {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.existsLocked(id) {
+		return ErrNotFound
+	}
+	if err := s.archiveLocked(id, s.currentVersionLocked(id)); err != nil {
+		return err
+	}
+	if err := os.Remove(s.Path(id)); err != nil {
+		return fmt.Errorf("failed to delete suite: %w", err)
+	}
+	return nil
+}
+
+// Versions lists a suite's history, newest first
+func (s *Store) Versions(id string) ([]Version, error) {
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var versions []Version
+	if info, err := os.Stat(s.Path(id)); err == nil {
+		versions = append(versions, Version{
+			Version:   s.currentVersionLocked(id),
+			UpdatedAt: info.ModTime().UTC(),
+			Size:      info.Size(),
+			Current:   true,
+		})
+	}
+
+	for _, version := range s.archivedLocked(id) {
+		info, err := os.Stat(s.versionPath(id, version))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{Version: version, UpdatedAt: info.ModTime().UTC(), Size: info.Size()})
+	}
+
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+// GetVersion returns one version of a suite, current or archived
+func (s *Store) GetVersion(id string, version int) (*Stored, error) {
+	if !validID.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.existsLocked(id) && version == s.currentVersionLocked(id) {
+		return s.readLocked(id)
+	}
+	return s.readFileLocked(id, s.versionPath(id, version), version)
+}
+
+func (s *Store) readLocked(id string) (*Stored, error) {
+	return s.readFileLocked(id, s.Path(id), s.currentVersionLocked(id))
+}
+
+func (s *Store) readFileLocked(id, path string, version int) (*Stored, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+
+	// Decode without resolving templates or data, so clients edit what is on disk
+	var suite types.TestSuite
+	if err := yaml.Unmarshal(content, &suite); err != nil {
+		return nil, fmt.Errorf("stored suite %s is not valid YAML: %w", id, err)
+	}
+
+	return &Stored{
+		Summary: Summary{
+			ID:        id,
+			Name:      suite.Name,
+			BaseURL:   suite.BaseURL,
+			Tests:     len(suite.Tests),
+			Version:   version,
+			UpdatedAt: info.ModTime().UTC(),
+			Path:      path,
+		},
+		Content: string(content),
+		Suite:   &suite,
+	}, nil
+}
+
+func (s *Store) existsLocked(id string) bool {
+	_, err := os.Stat(s.Path(id))
+	return err == nil
+}
+
+// currentVersionLocked is one more than the newest archived version
+func (s *Store) currentVersionLocked(id string) int {
+	archived := s.archivedLocked(id)
+	if len(archived) == 0 {
+		return 1
+	}
+	return archived[len(archived)-1] + 1
+}
+
+// archivedLocked returns the archived version numbers of a suite in ascending order
+func (s *Store) archivedLocked(id string) []int {
+	entries, err := os.ReadDir(filepath.Join(s.dir, historyDir, id))
+	if err != nil {
+		return nil
+	}
+	var versions []int
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".yaml"))
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// archiveLocked copies the current file into the history as the given version,
+// keeping its modification time as the time that version was saved
+func (s *Store) archiveLocked(id string, version int) error {
+	content, err := os.ReadFile(s.Path(id))
+	if err != nil {
+		return fmt.Errorf("failed to read suite: %w", err)
+	}
+	info, err := os.Stat(s.Path(id))
+	if err != nil {
+		return fmt.Errorf("failed to read suite: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, historyDir, id), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	path := s.versionPath(id, version)
+	if err := writeFile(path, content); err != nil {
+		return err
+	}
+	return os.Chtimes(path, info.ModTime(), info.ModTime())
+}
+
+func (s *Store) versionPath(id string, version int) string {
+	return filepath.Join(s.dir, historyDir, id, fmt.Sprintf("%06d.yaml", version))
+}
+
+// writeFile writes through a temporary file so readers never see a partial suite
+func writeFile(path string, content []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write suite: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write suite: %w", err)
+	}
+	return nil
+}
+
+// suiteID returns the ID for a file name in the suite directory
+func suiteID(fileName string) (string, bool) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		if strings.HasSuffix(fileName, ext) {
+			id := strings.TrimSuffix(fileName, ext)
+			return id, ext == ".yaml" && validID.MatchString(id)
+		}
+	}
+	return "", false
+}
+
+// suiteName reads the name field, which is required to derive IDs
+func suiteName(content []byte) (string, error) {
+	var header struct {
+		Name string `yaml:"name"`
+	}
+	if err := yaml.Unmarshal(content, &header); err != nil {
+		return "", fmt.Errorf("invalid YAML: %w", err)
+	}
+	if strings.TrimSpace(header.Name) == "" {
+		return "", errors.New("suite name is required")
+	}
+	return header.Name, nil
+}
+
+// slugify turns "User API (v2)" into "user-api-v2"
+func slugify(name string) string {
+	slug := strings.Trim(slugIllegal.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "suite"
+	}
+	return slug
+}
+
+// Encode renders a suite node as block-style YAML, e.g. one taken from a JSON request
+func Encode(node *yaml.Node) ([]byte, error) {
+	blockStyle(node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle clears flow and quoting styles; the encoder re-quotes strings that need it
+func blockStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || !strings.Contains(node.Value, "\n") {
+		node.Style = 0
+	} else {
+		node.Style = yaml.LiteralStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
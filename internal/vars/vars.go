@@ -0,0 +1,101 @@
+package vars
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lookup resolves a variable name to its value
+type Lookup func(name string) (interface{}, bool)
+
+// placeholderPattern matches {{name}} with optional surrounding whitespace
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.\-]+)\s*\}\}`)
+
+// FromMap returns a Lookup backed by a map
+func FromMap(values map[string]interface{}) Lookup {
+	return func(name string) (interface{}, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+// HasPlaceholders reports whether s contains any {{name}} placeholder
+func HasPlaceholders(s string) bool {
+	return placeholderPattern.MatchString(s)
+}
+
+// Expand replaces every {{name}} in s with its value. Unknown names are left untouched.
+func Expand(s string, lookup Lookup) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := lookup(name); ok {
+			return Format(value)
+		}
+		return match
+	})
+}
+
+// Format renders a value for substitution into text. Floats are written out in
+// full, so 1234567.0 from a JSON document becomes 1234567 rather than 1.234567e+06.
+func Format(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ExpandValue expands placeholders inside an arbitrary YAML/JSON value. A string that
+// consists of a single placeholder is replaced by the raw value so its type is kept
+// (e.g. `expected: "{{status}}"` becomes the integer 404).
+func ExpandValue(value interface{}, lookup Lookup) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := placeholderPattern.FindStringSubmatchIndex(v); match != nil && match[0] == 0 && match[1] == len(v) {
+			if raw, ok := lookup(v[match[2]:match[3]]); ok {
+				return raw
+			}
+		}
+		return Expand(v, lookup)
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = ExpandValue(item, lookup)
+		}
+		return expanded
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			expanded[key] = ExpandValue(item, lookup)
+		}
+		return expanded
+	case map[interface{}]interface{}:
+		expanded := make(map[interface{}]interface{}, len(v))
+		for key, item := range v {
+			expanded[key] = ExpandValue(item, lookup)
+		}
+		return expanded
+	default:
+		return value
+	}
+}
+
+// ExpandMap expands placeholders in every value of a string map
+func ExpandMap(values map[string]string, lookup Lookup) map[string]string {
+	if values == nil {
+		return nil
+	}
+	expanded := make(map[string]string, len(values))
+	for key, value := range values {
+		expanded[key] = Expand(value, lookup)
+	}
+	return expanded
+}
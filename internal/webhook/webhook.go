@@ -0,0 +1,295 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/vars"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Headers set on every delivery
+const (
+	EventHeader     = "X-Comapi-Event"
+	DeliveryHeader  = "X-Comapi-Delivery"
+	SignatureHeader = "X-Comapi-Signature-256"
+)
+
+// EventSuiteCompleted is the only event sent so far
+const EventSuiteCompleted = "suite.completed"
+
+// When a webhook fires
+const (
+	OnAlways  = "always"
+	OnFailure = "failure"
+)
+
+// DefaultMaxAttempts is used when a webhook does not set max_attempts
+const DefaultMaxAttempts = 3
+
+// Payload is the default JSON body, and the data available to templates
+type Payload struct {
+	Event      string    `json:"event"`
+	Suite      string    `json:"suite"`
+	Status     string    `json:"status"` // "passed" or "failed"
+	Total      int       `json:"total_tests"`
+	Passed     int       `json:"passed_tests"`
+	Failed     int       `json:"failed_tests"`
+	Skipped    int       `json:"skipped_tests"`
+	DurationMs int64     `json:"duration_ms"`
+	FinishedAt time.Time `json:"finished_at"`
+	Failures   []Failure `json:"failures,omitempty"`
+
+	// Result is the full suite result for templates that need more detail
+	Result types.SuiteResult `json:"-"`
+}
+
+// Failure is one failed test in the payload
+type Failure struct {
+	Test  string `json:"test"`
+	Error string `json:"error"`
+}
+
+// Delivery reports what happened to one webhook
+type Delivery struct {
+	URL        string `json:"url"`
+	Attempts   int    `json:"attempts"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OK reports whether the receiver accepted the delivery
+func (d Delivery) OK() bool {
+	return d.Error == ""
+}
+
+// Target is the webhook URL without path or query, which often hold secrets
+func (d Delivery) Target() string {
+	parsed, err := url.Parse(d.URL)
+	if err != nil || parsed.Host == "" {
+		return "webhook"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// templateFuncs are available to webhook templates in addition to the builtins
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {"text": {{json .Suite}}}
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// ParseTemplate checks a webhook body template
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// NewPayload summarises a suite result
+func NewPayload(result types.SuiteResult, finishedAt time.Time) Payload {
+	payload := Payload{
+		Event:      EventSuiteCompleted,
+		Suite:      result.SuiteName,
+		Status:     "passed",
+		Total:      result.TotalTests,
+		Passed:     result.PassedTests,
+		Failed:     result.FailedTests,
+		Skipped:    result.SkippedTests,
+		DurationMs: result.Duration.Milliseconds(),
+		FinishedAt: finishedAt.UTC(),
+		Result:     result,
+	}
+	if result.FailedTests > 0 {
+		payload.Status = "failed"
+	}
+	for _, test := range result.Results {
+		if test.Status == types.StatusFail {
+			payload.Failures = append(payload.Failures, Failure{Test: test.TestName, Error: test.FailureMessage()})
+		}
+	}
+	return payload
+}
+
+// Sign returns the signature header value for body: "sha256=" and the hex HMAC-SHA256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Sender delivers webhooks
+type Sender struct {
+	client     *http.Client
+	backoff    time.Duration
+	processEnv bool
+}
+
+// NewSender creates a sender; a nil client uses one with a 10s timeout
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Sender{client: client, backoff: time.Second}
+}
+
+// SetProcessEnv lets placeholders fall back to the process environment. Only
+// the CLI should enable it: a server would hand its own environment to
+// whatever URL a submitted suite names.
+func (s *Sender) SetProcessEnv(enabled bool) {
+	s.processEnv = enabled
+}
+
+// Notify delivers the suite's webhooks that apply to result. {{NAME}} placeholders
+// in URLs, headers and secrets are resolved from the suite's secrets and
+// environment, then, when enabled, the process environment.
+func (s *Sender) Notify(ctx context.Context, suite *types.TestSuite, result types.SuiteResult) []Delivery {
+	lookup := func(name string) (interface{}, bool) {
+		if secret, ok := suite.Secrets[name]; ok {
+			return secret.Value, true
+		}
+		if value, ok := suite.Environment[name]; ok {
+			return value, true
+		}
+		if s.processEnv {
+			return os.LookupEnv(name)
+		}
+		return nil, false
+	}
+
+	payload := NewPayload(result, time.Now())
+	var deliveries []Delivery
+	for _, hook := range suite.Webhooks {
+		if hook.On == OnFailure && result.FailedTests == 0 {
+			continue
+		}
+		deliveries = append(deliveries, s.Deliver(ctx, expand(hook, lookup), payload))
+	}
+	return deliveries
+}
+
+// Deliver sends payload to one webhook, retrying network errors, 429 and 5xx
+// answers with exponential backoff
+func (s *Sender) Deliver(ctx context.Context, hook types.Webhook, payload Payload) Delivery {
+	delivery := Delivery{URL: hook.URL}
+
+	body, contentType, err := render(hook, payload)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	attempts := hook.MaxAttempts
+	if attempts < 1 {
+		attempts = DefaultMaxAttempts
+	}
+	id := newDeliveryID()
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		delivery.Attempts = attempt
+		var retry bool
+		delivery.StatusCode, retry, err = s.post(ctx, hook, id, body, contentType)
+		if err == nil {
+			delivery.Error = ""
+			return delivery
+		}
+		delivery.Error = err.Error()
+		if !retry || attempt == attempts {
+			break
+		}
+
+		wait := s.backoff << (attempt - 1)
+		select {
+		case <-ctx.Done():
+			delivery.Error = ctx.Err().Error()
+			return delivery
+		case <-time.After(wait):
+		}
+	}
+	return delivery
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (s *Sender) post(ctx context.Context, hook types.Webhook, id string, body []byte, contentType string) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "comapi-webhook")
+	req.Header.Set(EventHeader, EventSuiteCompleted)
+	req.Header.Set(DeliveryHeader, id)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return resp.StatusCode, retry, fmt.Errorf("receiver answered %d", resp.StatusCode)
+}
+
+// render produces the request body: the template output, or the JSON payload
+func render(hook types.Webhook, payload Payload) ([]byte, string, error) {
+	if hook.Template == "" {
+		body, err := json.Marshal(payload)
+		return body, "application/json", err
+	}
+
+	tmpl, err := ParseTemplate(hook.Template)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid template: %v", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, payload); err != nil {
+		return nil, "", fmt.Errorf("template failed: %v", err)
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if json.Valid(body.Bytes()) {
+		contentType = "application/json"
+	}
+	return body.Bytes(), contentType, nil
+}
+
+// expand resolves placeholders in the parts of a webhook that commonly hold secrets
+func expand(hook types.Webhook, lookup vars.Lookup) types.Webhook {
+	hook.URL = vars.Expand(hook.URL, lookup)
+	hook.Secret = vars.Expand(hook.Secret, lookup)
+	hook.Headers = vars.ExpandMap(hook.Headers, lookup)
+	return hook
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte("The quick brown fox jumps over the lazy dog")
+	// The HMAC-SHA256 of body with the key "key"
+	signature := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+
+	if got := Sign("key", body); got != signature {
+		t.Fatalf("Sign() = %s, want %s", got, signature)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+		want      bool
+	}{
+		{name: "matching", secret: "key", body: string(body), signature: signature, want: true},
+		{name: "other secret", secret: "other", body: string(body), signature: signature},
+		{name: "changed body", secret: "key", body: string(body) + ".", signature: signature},
+		{name: "without prefix", secret: "key", body: string(body), signature: strings.TrimPrefix(signature, "sha256=")},
+		{name: "upper case hex", secret: "key", body: string(body), signature: strings.ToUpper(signature)},
+		{name: "empty", secret: "key", body: string(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, []byte(tt.body), tt.signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// receiver records every delivery and answers with the given statuses in turn,
+// repeating the last one
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.statuses[min(len(r.requests), len(r.statuses)-1)]
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	w.WriteHeader(status)
+}
+
+func TestDeliverSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     types.Webhook
+		signed   bool
+		template bool
+	}{
+		{name: "unsigned", hook: types.Webhook{}},
+		{name: "signed JSON payload", hook: types.Webhook{Secret: "s3cret"}, signed: true},
+		{name: "signed template", hook: types.Webhook{Secret: "s3cret", Template: `{"text": {{json .Suite}}}`}, signed: true, template: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := &receiver{statuses: []int{http.StatusOK}}
+			server := httptest.NewServer(hook)
+			defer server.Close()
+
+			tt.hook.URL = server.URL
+			payload := NewPayload(types.SuiteResult{SuiteName: "smoke", TotalTests: 1, PassedTests: 1}, time.Now())
+			delivery := NewSender(nil).Deliver(context.Background(), tt.hook, payload)
+			if !delivery.OK() || len(hook.requests) != 1 {
+				t.Fatalf("Deliver() = %+v after %d requests, want one accepted delivery", delivery, len(hook.requests))
+			}
+
+			req, body := hook.requests[0], hook.bodies[0]
+			signature := req.Header.Get(SignatureHeader)
+			if tt.signed != (signature != "") {
+				t.Fatalf("%s = %q, want signed %v", SignatureHeader, signature, tt.signed)
+			}
+			if tt.signed && !Verify(tt.hook.Secret, []byte(body), signature) {
+				t.Errorf("%s = %s does not match the body %s", SignatureHeader, signature, body)
+			}
+			if tt.template && body != `{"text": "smoke"}` {
+				t.Errorf("body = %s, want the rendered template", body)
+			}
+			if got := req.Header.Get(EventHeader); got != EventSuiteCompleted {
+				t.Errorf("%s = %q, want %q", EventHeader, got, EventSuiteCompleted)
+			}
+			if req.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", req.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		maxAttempts int
+		attempts    int
+		status      int
+		error       string
+	}{
+		{name: "accepted", statuses: []int{http.StatusNoContent}, attempts: 1, status: http.StatusNoContent},
+		{name: "server errors are retried", statuses: []int{500, 502, 200}, attempts: 3, status: 200},
+		{name: "rate limits are retried", statuses: []int{429, 200}, attempts: 2, status: 200},
+		{name: "client errors are not retried", statuses: []int{400, 200}, attempts: 1, status: 400, error: "receiver answered 400"},
+		{name: "gives up after the default attempts", statuses: []int{503}, attempts: DefaultMaxAttempts, status: 503, error: "receiver answered 503"},
+		{name: "max attempts", statuses: []int{503}, maxAttempts: 5, attempts: 5, status: 503, error: "receiver answered 503"},
+		{name: "one attempt", statuses: []int{503, 200}, maxAttempts: 1, attempts: 1, status: 503, error: "receiver answered 503"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(hook)
+			defer server.Close()
+
+			sender := NewSender(nil)
+			sender.backoff = time.Millisecond
+			delivery := sender.Deliver(context.Background(), types.Webhook{URL: server.URL, MaxAttempts: tt.maxAttempts}, Payload{})
+
+			want := Delivery{URL: server.URL, Attempts: tt.attempts, StatusCode: tt.status, Error: tt.error}
+			if !reflect.DeepEqual(delivery, want) {
+				t.Errorf("Deliver() = %+v, want %+v", delivery, want)
+			}
+			if len(hook.requests) != tt.attempts {
+				t.Errorf("receiver got %d requests, want %d", len(hook.requests), tt.attempts)
+			}
+			// Retries are the same delivery, so receivers can drop duplicates
+			for _, req := range hook.requests {
+				if id := req.Header.Get(DeliveryHeader); id == "" || id != hook.requests[0].Header.Get(DeliveryHeader) {
+					t.Errorf("%s = %q, want one id for every attempt", DeliveryHeader, id)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	sender := NewSender(nil)
+	sender.backoff = time.Millisecond
+	delivery := sender.Deliver(context.Background(), types.Webhook{URL: url}, Payload{})
+	if delivery.OK() || delivery.Attempts != DefaultMaxAttempts || delivery.StatusCode != 0 {
+		t.Errorf("Deliver() = %+v, want %d failed attempts", delivery, DefaultMaxAttempts)
+	}
+
+	// Cancelling stops the backoff between attempts
+	ctx, cancel := context.WithCancel(context.Background())
+	sender.backoff = time.Hour
+	time.AfterFunc(10*time.Millisecond, cancel)
+	delivery = sender.Deliver(ctx, types.Webhook{URL: url}, Payload{})
+	if delivery.Attempts != 1 || delivery.Error != context.Canceled.Error() {
+		t.Errorf("Deliver() = %+v, want one attempt then %v", delivery, context.Canceled)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("COMAPI_WEBHOOK_TEST_TOKEN", "from-process")
+
+	tests := []struct {
+		name       string
+		on         string
+		failed     int
+		processEnv bool
+		delivered  bool
+	}{
+		{name: "always", on: OnAlways, delivered: true},
+		{name: "failure only, run passed", on: OnFailure},
+		{name: "failure only, run failed", on: OnFailure, failed: 1, delivered: true},
+		{name: "process environment when enabled", on: OnAlways, processEnv: true, delivered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := &receiver{statuses: []int{http.StatusOK}}
+			server := httptest.NewServer(hook)
+			defer server.Close()
+
+			suite := &types.TestSuite{
+				Secrets: map[string]types.Secret{"TOKEN": {Value: "from-secret"}},
+				Webhooks: []types.Webhook{{
+					URL: server.URL + "/{{PATH}}", On: tt.on,
+					Headers: map[string]string{"X-Token": "{{TOKEN}}", "X-Process": "{{COMAPI_WEBHOOK_TEST_TOKEN}}"},
+				}},
+				Environment: map[string]string{"PATH": "hooks"},
+			}
+			sender := NewSender(nil)
+			sender.SetProcessEnv(tt.processEnv)
+			deliveries := sender.Notify(context.Background(), suite, types.SuiteResult{FailedTests: tt.failed})
+
+			if len(deliveries) != len(hook.requests) || (len(deliveries) == 1) != tt.delivered {
+				t.Fatalf("Notify() = %+v with %d requests, want delivered %v", deliveries, len(hook.requests), tt.delivered)
+			}
+			if !tt.delivered {
+				return
+			}
+			req := hook.requests[0]
+			if req.URL.Path != "/hooks" || req.Header.Get("X-Token") != "from-secret" {
+				t.Errorf("request to %s with X-Token %q, want /hooks with the secret", req.URL.Path, req.Header.Get("X-Token"))
+			}
+			// The process environment is only read when enabled
+			wantProcess := "{{COMAPI_WEBHOOK_TEST_TOKEN}}"
+			if tt.processEnv {
+				wantProcess = "from-process"
+			}
+			if got := req.Header.Get("X-Process"); got != wantProcess {
+				t.Errorf("X-Process = %q, want %q", got, wantProcess)
+			}
+		})
+	}
+}
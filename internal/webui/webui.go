@@ -0,0 +1,92 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigPath is the script the UI loads to discover where the API lives
+const ConfigPath = "/comapi-config.js"
+
+// Config is exposed to the UI as window.COMAPI_CONFIG
+type Config struct {
+	APIBaseURL   string `json:"apiBaseUrl"`
+	AuthRequired bool   `json:"authRequired"`
+}
+
+// Handler serves a built single-page app from files. Existing files are served as is;
+// any other GET outside /api/ falls back to index.html so client-side routes work on
+// reload. With files == nil it serves a page explaining how to build the UI.
+func Handler(files fs.FS, config Config) gin.HandlerFunc {
+	configScript := configScript(config)
+	var fileServer http.Handler
+	if files != nil {
+		fileServer = http.FileServer(http.FS(files))
+	}
+
+	return func(c *gin.Context) {
+		requestPath := c.Request.URL.Path
+		if strings.HasPrefix(requestPath, "/api/") {
+			c.JSON(404, gin.H{"error": "Not found"})
+			return
+		}
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.JSON(405, gin.H{"error": "Method not allowed"})
+			return
+		}
+
+		if requestPath == ConfigPath {
+			c.Header("Cache-Control", "no-cache")
+			c.Data(200, "application/javascript; charset=utf-8", configScript)
+			return
+		}
+
+		if files == nil {
+			c.Data(404, "text/html; charset=utf-8", []byte(notBuiltPage))
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(requestPath), "/")
+		if info, err := fs.Stat(files, name); err == nil && !info.IsDir() && name != "index.html" {
+			// Create React App puts content-hashed assets under static/
+			if strings.HasPrefix(name, "static/") {
+				c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			}
+			fileServer.ServeHTTP(c.Writer, c.Request)
+			return
+		}
+
+		index, err := fs.ReadFile(files, "index.html")
+		if err != nil {
+			c.Data(404, "text/html; charset=utf-8", []byte(notBuiltPage))
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(200, "text/html; charset=utf-8", index)
+	}
+}
+
+func configScript(config Config) []byte {
+	encoded, _ := json.Marshal(config)
+	return []byte(fmt.Sprintf("window.COMAPI_CONFIG = %s;\n", encoded))
+}
+
+const notBuiltPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Comapi</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto">
+<h1>🧭 Comapi</h1>
+<p>This binary was built without the web UI. The API is available under <a href="/api/v1/health">/api/v1</a>.</p>
+<p>To bundle the UI, build it and compile with the <code>embedui</code> tag:</p>
+<pre>cd frontend &amp;&amp; npm ci &amp;&amp; npm run build &amp;&amp; cd ..
+go build -tags embedui .</pre>
+<p>Or serve an existing build with <code>comapi server --ui-dir frontend/build</code>.</p>
+</body>
+</html>
+`
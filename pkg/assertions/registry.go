@@ -0,0 +1,146 @@
+// Package assertions lets programs that embed comapi add their own assertion
+// types. A registered type can be used in suite files like any built-in one:
+//
+//	assertions.MustRegister("hal_link", assertions.CheckerFunc(
+//		func(a types.Assertion, r *types.TestResult) types.AssertionResult {
+//			link := gjson.Get(r.Response.Body, "_links."+a.Target+".href")
+//			return types.AssertionResult{
+//				Type:    a.Type,
+//				Target:  a.Target,
+//				Actual:  link.String(),
+//				Passed:  link.Exists(),
+//				Message: fmt.Sprintf("Expected HAL link '%s'", a.Target),
+//			}
+//		}))
+//
+//	# suite.yaml
+//	assertions:
+//	  - type: hal_link
+//	    target: self
+package assertions
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Checker evaluates a custom assertion against a test result
+type Checker interface {
+	Check(assertion types.Assertion, result *types.TestResult) types.AssertionResult
+}
+
+// Validator is optionally implemented by a Checker to reject malformed
+// assertions when a suite is loaded, before anything runs
+type Validator interface {
+	Validate(assertion types.Assertion) error
+}
+
+// OperatorLister is optionally implemented by a Checker to declare the operators
+// it accepts. Without it any operator is passed through unchecked.
+type OperatorLister interface {
+	Operators() []string
+}
+
+// CheckerFunc adapts a plain function to the Checker interface
+type CheckerFunc func(assertion types.Assertion, result *types.TestResult) types.AssertionResult
+
+// Check calls f(assertion, result)
+func (f CheckerFunc) Check(assertion types.Assertion, result *types.TestResult) types.AssertionResult {
+	return f(assertion, result)
+}
+
+// builtin lists the assertion types handled by comapi itself and the operators
+// each accepts; the first one is the default. Built-in types cannot be replaced.
+var builtin = map[string][]string{
+	"status":         {"equals"},
+	"json_path":      {"equals", "not_equals", "contains", "greater_than", "less_than"},
+	"header":         {"equals", "contains"},
+	"response_time":  {"less_than", "greater_than", "equals"},
+	"expr":           {},
+	"graphql_errors": {"absent", "present", "contains", "equals"},
+}
+
+// Builtin returns the built-in assertion types and the operators each accepts,
+// the default first. The result is a copy that callers may modify.
+func Builtin() map[string][]string {
+	copied := make(map[string][]string, len(builtin))
+	for name, operators := range builtin {
+		copied[name] = append([]string{}, operators...)
+	}
+	return copied
+}
+
+// BuiltinOperators returns the operators a built-in assertion type accepts, the
+// default first, and false if the type is not built in
+func BuiltinOperators(assertionType string) ([]string, bool) {
+	operators, ok := builtin[assertionType]
+	if !ok {
+		return nil, false
+	}
+	return append([]string{}, operators...), true
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Checker)
+)
+
+// Register adds a custom assertion type. It fails if the name is empty, is a
+// built-in type or has already been registered.
+func Register(name string, checker Checker) error {
+	if name == "" {
+		return fmt.Errorf("assertion type name is required")
+	}
+	if _, ok := builtin[name]; ok {
+		return fmt.Errorf("assertion type '%s' is built in and cannot be replaced", name)
+	}
+	if checker == nil {
+		return fmt.Errorf("assertion type '%s': checker is nil", name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		return fmt.Errorf("assertion type '%s' is already registered", name)
+	}
+	registry[name] = checker
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It is intended for init functions.
+func MustRegister(name string, checker Checker) {
+	if err := Register(name, checker); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a custom assertion type, mainly for tests
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(registry, name)
+}
+
+// Lookup returns the checker registered for an assertion type
+func Lookup(name string) (Checker, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	checker, ok := registry[name]
+	return checker, ok
+}
+
+// Registered returns the names of all custom assertion types, sorted
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
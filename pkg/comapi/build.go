@@ -0,0 +1,96 @@
+package comapi
+
+import "github.com/Asadus16/comapi/pkg/types"
+
+// Suite builds a test suite in code
+func Suite(name, baseURL string, tests ...types.TestCase) *types.TestSuite {
+	return &types.TestSuite{
+		Name:    name,
+		BaseURL: baseURL,
+		Tests:   tests,
+	}
+}
+
+// Test builds a test case. Set Body, Headers, DependsOn etc. on the returned value as needed.
+func Test(name, method, path string, assertions ...types.Assertion) types.TestCase {
+	return types.TestCase{
+		Name:       name,
+		Method:     method,
+		Path:       path,
+		Assertions: assertions,
+	}
+}
+
+// GraphQL builds a test that POSTs a GraphQL operation to path. Set
+// GraphQL.OperationName or GraphQL.Schema on the returned value as needed.
+func GraphQL(name, path, query string, variables map[string]interface{}, assertions ...types.Assertion) types.TestCase {
+	return types.TestCase{
+		Name:       name,
+		Method:     "POST",
+		Path:       path,
+		GraphQL:    &types.GraphQLRequest{Query: query, Variables: variables},
+		Assertions: assertions,
+	}
+}
+
+// GRPC builds a test that calls a unary gRPC method, found through server
+// reflection, on the suite's base URL. message is a mapping or JSON text; set
+// GRPC.ProtoFiles or GRPC.Metadata on the returned value as needed.
+func GRPC(name, service, method string, message interface{}, assertions ...types.Assertion) types.TestCase {
+	return types.TestCase{
+		Name:       name,
+		Method:     "POST",
+		GRPC:       &types.GRPCRequest{Service: service, Method: method, Message: message},
+		Assertions: assertions,
+	}
+}
+
+// Status asserts the response status code
+func Status(expected int) types.Assertion {
+	return types.Assertion{Type: "status", Expected: expected}
+}
+
+// JSONPath asserts that the value at a JSON path equals expected
+func JSONPath(target string, expected interface{}) types.Assertion {
+	return types.Assertion{Type: "json_path", Target: target, Expected: expected}
+}
+
+// JSONPathOp asserts a JSON path value with an operator such as "contains" or "greater_than"
+func JSONPathOp(target, operator string, expected interface{}) types.Assertion {
+	return types.Assertion{Type: "json_path", Target: target, Operator: operator, Expected: expected}
+}
+
+// Header asserts that a response header equals expected
+func Header(name, expected string) types.Assertion {
+	return types.Assertion{Type: "header", Target: name, Expected: expected}
+}
+
+// ResponseTime asserts that the response arrives in under maxMillis milliseconds
+func ResponseTime(maxMillis int) types.Assertion {
+	return types.Assertion{Type: "response_time", Operator: "less_than", Expected: maxMillis}
+}
+
+// PhaseTime asserts that one timing phase, such as "ttfb" or "tls", takes under maxMillis milliseconds
+func PhaseTime(phase string, maxMillis int) types.Assertion {
+	return types.Assertion{Type: "response_time", Target: phase, Operator: "less_than", Expected: maxMillis}
+}
+
+// NoGraphQLErrors asserts that a GraphQL response has no errors
+func NoGraphQLErrors() types.Assertion {
+	return types.Assertion{Type: "graphql_errors", Operator: "absent"}
+}
+
+// GraphQLError asserts that a GraphQL response has an error whose message contains text
+func GraphQLError(text string) types.Assertion {
+	return types.Assertion{Type: "graphql_errors", Operator: "contains", Expected: text}
+}
+
+// GRPCStatus asserts the status of a gRPC call by name, e.g. "NOT_FOUND"
+func GRPCStatus(name string) types.Assertion {
+	return types.Assertion{Type: "status", Expected: name}
+}
+
+// Expr asserts that an expression over the response evaluates to true
+func Expr(expression, failureMessage string) types.Assertion {
+	return types.Assertion{Type: "expr", Expr: expression, Message: failureMessage}
+}
@@ -0,0 +1,154 @@
+// Package comapi is the stable Go API for loading and running comapi test
+// suites from your own programs and `go test` code.
+//
+//	suite, err := comapi.LoadSuite("testdata/users.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	result, err := comapi.Run(suite, comapi.Options{BaseURL: "http://localhost:8080"})
+//
+// Suites can also be built in code with Suite, Test and the assertion helpers,
+// and RunT runs a suite inside a Go test with one subtest per test case.
+package comapi
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/Asadus16/comapi/internal/config"
+	"github.com/Asadus16/comapi/internal/runner"
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// Options controls how a suite is run. The zero value runs the suite as written.
+type Options struct {
+	// BaseURL replaces the suite's base_url, e.g. with an httptest.Server URL
+	BaseURL string
+	// Headers are added to the suite headers, overriding same-named ones
+	Headers map[string]string
+	// Variables are added to the suite environment for when and expr expressions
+	Variables map[string]string
+	// HTTPClient sends the requests; defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+	// Timeout applies to each request when HTTPClient is not set
+	Timeout time.Duration
+	// BaseDir resolves relative data, secret and proto files for suites built in code; defaults to the working directory
+	BaseDir string
+	// OnResult is called with each test result as soon as it is available
+	OnResult func(result types.TestResult)
+}
+
+// LoadSuite reads a suite file, resolving includes, templates and data rows.
+// If the suite is invalid the error lists every problem found. The suite is
+// validated once here, so Run does not check it again; tests added to it
+// afterwards are run as written.
+func LoadSuite(path string) (*types.TestSuite, error) {
+	return config.LoadTestSuite(path)
+}
+
+// ParseSuite reads a suite from YAML or JSON text. Includes are not supported.
+func ParseSuite(data []byte) (*types.TestSuite, error) {
+	suite, diagnostics := config.ValidateDocument(data, "suite")
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	return suite, nil
+}
+
+// Validate checks a suite without running it and returns all problems found.
+// Warnings alone do not make the returned error non-nil.
+func Validate(suite *types.TestSuite) error {
+	prepared := cloneSuite(suite)
+	if diagnostics := config.PrepareSuite(prepared, "."); diagnostics.HasErrors() {
+		return diagnostics
+	}
+	return nil
+}
+
+// Run validates and runs a suite built in code, or runs one from LoadSuite or
+// ParseSuite. The suite passed in is not modified.
+func Run(suite *types.TestSuite, options Options) (types.SuiteResult, error) {
+	suiteRunner, err := newSuiteRunner(suite, options)
+	if err != nil {
+		return types.SuiteResult{}, err
+	}
+	return suiteRunner.Run(), nil
+}
+
+// RunFile loads and runs a suite file
+func RunFile(path string, options Options) (types.SuiteResult, error) {
+	suite, err := LoadSuite(path)
+	if err != nil {
+		return types.SuiteResult{}, err
+	}
+	if options.BaseDir == "" {
+		options.BaseDir = filepath.Dir(path)
+	}
+	return Run(suite, options)
+}
+
+// newSuiteRunner prepares a copy of the suite and a runner configured from options
+func newSuiteRunner(suite *types.TestSuite, options Options) (*runner.SuiteRunner, error) {
+	prepared := cloneSuite(suite)
+	if options.BaseURL != "" {
+		prepared.BaseURL = options.BaseURL
+	}
+	if len(options.Headers) > 0 {
+		headers := make(map[string]string, len(prepared.Headers)+len(options.Headers))
+		for key, value := range prepared.Headers {
+			headers[key] = value
+		}
+		for key, value := range options.Headers {
+			headers[key] = value
+		}
+		prepared.Headers = headers
+	}
+
+	baseDir := options.BaseDir
+	if baseDir == "" {
+		baseDir = "."
+	}
+	if diagnostics := config.PrepareSuite(prepared, baseDir); diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	if err := config.ResolveSecrets(prepared, baseDir); err != nil {
+		return nil, err
+	}
+
+	suiteRunner := runner.NewSuiteRunner(prepared)
+	for name, value := range options.Variables {
+		suiteRunner.SetVariable(name, value)
+	}
+	switch {
+	case options.HTTPClient != nil:
+		suiteRunner.SetHTTPClient(options.HTTPClient)
+	case options.Timeout > 0:
+		suiteRunner.SetHTTPClient(&http.Client{Timeout: options.Timeout})
+	}
+	if options.OnResult != nil {
+		suiteRunner.OnTestComplete = func(index, total int, result types.TestResult) {
+			options.OnResult(result)
+		}
+	}
+
+	return suiteRunner, nil
+}
+
+// cloneSuite copies a suite deeply enough that preparing it leaves the original untouched
+func cloneSuite(suite *types.TestSuite) *types.TestSuite {
+	clone := *suite
+	clone.Tests = make([]types.TestCase, len(suite.Tests))
+	for i, test := range suite.Tests {
+		test.Assertions = append([]types.Assertion(nil), test.Assertions...)
+		test.DependsOn = append([]string(nil), test.DependsOn...)
+		clone.Tests[i] = test
+	}
+	if suite.Secrets != nil {
+		clone.Secrets = make(map[string]types.Secret, len(suite.Secrets))
+		for name, secret := range suite.Secrets {
+			clone.Secrets[name] = secret
+		}
+	}
+	return &clone
+}
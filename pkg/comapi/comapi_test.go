@@ -0,0 +1,89 @@
+package comapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+func TestRunFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "` + r.URL.Path[len("/users/"):] + `"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"suite.yaml": `name: users
+base_url: http://localhost:1
+assertion_groups:
+  ok:
+    - type: status
+      expected: 200
+tests:
+  - name: user {{id}}
+    method: GET
+    path: /users/{{id}}
+    extends: [ok]
+    data:
+      file: data/users.csv
+    assertions:
+      - type: json_path
+        target: id
+        expected: "{{id}}"
+`,
+		"data/users.csv": "id\n1\n2\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := RunFile(filepath.Join(dir, "suite.yaml"), Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("RunFile() error = %v", err)
+	}
+	if result.TotalTests != 2 || result.PassedTests != 2 {
+		t.Fatalf("RunFile() ran %d tests, %d passed, want 2 and 2: %+v", result.TotalTests, result.PassedTests, result.Results)
+	}
+	// The group's assertion is inherited once, not again when the loaded suite runs
+	for _, test := range result.Results {
+		if len(test.Assertions) != 2 {
+			t.Errorf("%s: %d assertions, want 2", test.TestName, len(test.Assertions))
+		}
+	}
+}
+
+func TestRunPreparesSuitesBuiltInCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	suite := Suite("built", server.URL, Test("home", "GET", "/", Status(200)))
+	suite.AssertionGroups = map[string][]types.Assertion{"fast": {ResponseTime(5000)}}
+	suite.Tests[0].Extends = []string{"fast"}
+
+	result, err := Run(suite, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.PassedTests != 1 || len(result.Results[0].Assertions) != 2 {
+		t.Errorf("Run() = %+v", result)
+	}
+	if suite.Prepared || len(suite.Tests[0].Assertions) != 1 {
+		t.Errorf("Run() modified the suite passed in")
+	}
+
+	if err := Validate(Suite("invalid", server.URL, Test("", "FETCH", "/"))); err == nil {
+		t.Error("Validate() accepted an invalid suite")
+	}
+}
@@ -0,0 +1,82 @@
+package comapi
+
+import (
+	"testing"
+
+	"github.com/Asadus16/comapi/pkg/types"
+)
+
+// RunT runs a suite inside a Go test, reporting each test case as a subtest.
+// Failed assertions fail the subtest and skipped tests are marked as skipped.
+//
+//	func TestUsersAPI(t *testing.T) {
+//		server := httptest.NewServer(newRouter())
+//		defer server.Close()
+//
+//		suite, err := comapi.LoadSuite("testdata/users.yaml")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		comapi.RunT(t, suite, comapi.Options{BaseURL: server.URL, HTTPClient: server.Client()})
+//	}
+func RunT(t *testing.T, suite *types.TestSuite, options Options) types.SuiteResult {
+	t.Helper()
+
+	onResult := options.OnResult
+	options.OnResult = func(result types.TestResult) {
+		t.Run(result.TestName, func(t *testing.T) {
+			reportResult(t, result)
+		})
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+
+	suiteRunner, err := newSuiteRunner(suite, options)
+	if err != nil {
+		t.Fatalf("invalid test suite %q:\n%v", suite.Name, err)
+		return types.SuiteResult{}
+	}
+	return suiteRunner.Run()
+}
+
+// RunFileT loads a suite file and runs it with RunT
+func RunFileT(t *testing.T, path string, options Options) types.SuiteResult {
+	t.Helper()
+
+	suite, err := LoadSuite(path)
+	if err != nil {
+		t.Fatalf("failed to load %s:\n%v", path, err)
+		return types.SuiteResult{}
+	}
+	return RunT(t, suite, options)
+}
+
+// reportResult translates a TestResult into testing.T calls
+func reportResult(t *testing.T, result types.TestResult) {
+	t.Helper()
+
+	switch result.Status {
+	case types.StatusSkip:
+		t.Skip(result.SkipReason)
+	case types.StatusPass:
+		return
+	}
+
+	if result.Error != "" {
+		t.Error(result.Error)
+	}
+	for _, assertion := range result.Assertions {
+		if assertion.Passed {
+			continue
+		}
+		if assertion.Target != "" {
+			t.Errorf("%s %s: %s", assertion.Type, assertion.Target, assertion.Message)
+		} else {
+			t.Errorf("%s: %s", assertion.Type, assertion.Message)
+		}
+	}
+	if result.Error == "" && len(result.Assertions) == 0 {
+		t.Errorf("test failed with status %s", result.Status)
+	}
+}
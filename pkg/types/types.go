@@ -1,36 +1,145 @@
 package types
 
 import (
+	"fmt"
 	"time"
 )
 
 // TestSuite represents a collection of API tests
 type TestSuite struct {
-	Name        string            `json:"name" yaml:"name"`
-	BaseURL     string            `json:"base_url" yaml:"base_url"`
+	Name            string                 `json:"name" yaml:"name"`
+	BaseURL         string                 `json:"base_url" yaml:"base_url"`
+	Include         []string               `json:"include,omitempty" yaml:"include,omitempty"`                   // Other suite files to merge in
+	Headers         map[string]string      `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Environment     map[string]string      `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Templates       map[string]TestCase    `json:"templates,omitempty" yaml:"templates,omitempty"`               // Reusable request templates
+	AssertionGroups map[string][]Assertion `json:"assertion_groups,omitempty" yaml:"assertion_groups,omitempty"` // Reusable sets of assertions
+	Tests           []TestCase             `json:"tests" yaml:"tests"`
+	Webhooks        []Webhook              `json:"webhooks,omitempty" yaml:"webhooks,omitempty"` // Notified when a run completes
+	Secrets         map[string]Secret      `json:"secrets,omitempty" yaml:"secrets,omitempty"`   // Values used as {{NAME}} and masked in every report
+	Redact          *Redaction             `json:"redact,omitempty" yaml:"redact,omitempty"`     // Extra values to mask in results
+	Prepared        bool                   `json:"-" yaml:"-"`                                   // Set once templates and data rows are resolved and the suite is valid
+}
+
+// Secret is a value read from the environment or a file when the suite runs.
+// It replaces {{NAME}} in URLs, headers and bodies, and is masked wherever a
+// result is shown or stored.
+type Secret struct {
+	Env   string `json:"env,omitempty" yaml:"env,omitempty"`   // Environment variable holding the value
+	File  string `json:"file,omitempty" yaml:"file,omitempty"` // File holding the value, relative to the suite file
+	Value string `json:"-" yaml:"-"`                           // Set by config.ResolveSecrets, never serialized
+	Dir   string `json:"-" yaml:"-"`                           // Directory of the suite file that defined it
+}
+
+// Redaction lists what is masked in results besides the secrets and the
+// built-in credential headers
+type Redaction struct {
+	Headers   []string `json:"headers,omitempty" yaml:"headers,omitempty"`       // Request and response header names
+	JSONPaths []string `json:"json_paths,omitempty" yaml:"json_paths,omitempty"` // gjson paths in request and response bodies, e.g. data.token or users.#.password
+}
+
+// Webhook receives a summary of the suite result when a run completes
+type Webhook struct {
+	URL         string            `json:"url" yaml:"url"`
+	On          string            `json:"on,omitempty" yaml:"on,omitempty"`                     // "always" (default) or "failure"
 	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Environment map[string]string `json:"environment,omitempty" yaml:"environment,omitempty"`
-	Tests       []TestCase        `json:"tests" yaml:"tests"`
+	Secret      string            `json:"secret,omitempty" yaml:"secret,omitempty"`             // Signs the body with HMAC-SHA256
+	Template    string            `json:"template,omitempty" yaml:"template,omitempty"`         // Go template for the body instead of the JSON summary
+	MaxAttempts int               `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"` // Delivery attempts before giving up (default 3)
 }
 
 // TestCase represents a single API test
 type TestCase struct {
 	Name        string            `json:"name" yaml:"name"`
 	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Extends     []string          `json:"extends,omitempty" yaml:"extends,omitempty"` // Templates or assertion groups to inherit from
 	Method      string            `json:"method" yaml:"method"`
-	Path        string            `json:"path" yaml:"path"`           // For backward compatibility
-	URL         string            `json:"url" yaml:"url"`             // New: complete URL
+	Path        string            `json:"path" yaml:"path,omitempty"` // For backward compatibility
+	URL         string            `json:"url" yaml:"url,omitempty"`   // New: complete URL
 	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Body        string            `json:"body,omitempty" yaml:"body,omitempty"`
+	DependsOn   []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"` // Tests that must pass first
+	When        string            `json:"when,omitempty" yaml:"when,omitempty"`             // Condition on variables, e.g. ENV == "staging"
+	Data        *TestData         `json:"data,omitempty" yaml:"data,omitempty"`             // Expands the test into one case per row
+	MockResponse *MockResponse    `json:"mock_response,omitempty" yaml:"mock_response,omitempty"` // Stub served by `comapi mock`
+	GraphQL     *GraphQLRequest   `json:"graphql,omitempty" yaml:"graphql,omitempty"`           // Sent as a GraphQL request instead of Body
+	GRPC        *GRPCRequest      `json:"grpc,omitempty" yaml:"grpc,omitempty"`                 // Calls a unary gRPC method instead of sending an HTTP request
 	Assertions  []Assertion       `json:"assertions" yaml:"assertions"`
+	Source      Position          `json:"-" yaml:"-"` // Where the test was defined
+}
+
+// GraphQLRequest is a GraphQL operation. It is POSTed as JSON, or sent as query
+// parameters when the test's method is GET.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" yaml:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty" yaml:"variables,omitempty"`
+	OperationName string                 `json:"operation_name,omitempty" yaml:"operation_name,omitempty"` // Sent as operationName
+	Schema        string                 `json:"schema,omitempty" yaml:"schema,omitempty"`                 // Introspection result file, or endpoint URL to introspect, to validate the query against at load time
+}
+
+// GRPCRequest calls a unary gRPC method on the test's base_url or url, e.g.
+// http://localhost:50051 (plaintext) or https://api.example.com (TLS). The
+// response status holds the gRPC status code (0 is OK; status assertions also
+// take names such as NOT_FOUND) and the body holds the response message as
+// JSON, with field names as written in the .proto file.
+type GRPCRequest struct {
+	Service     string            `json:"service" yaml:"service"`                                 // Fully qualified service, e.g. helloworld.Greeter
+	Method      string            `json:"method" yaml:"method"`                                   // Method of the service, e.g. SayHello
+	Message     interface{}       `json:"message,omitempty" yaml:"message,omitempty"`             // Request message as a mapping or JSON text
+	Metadata    map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`           // Sent as request headers
+	ProtoFiles  []string          `json:"proto_files,omitempty" yaml:"proto_files,omitempty"`     // .proto files describing the service; server reflection is used when empty
+	ImportPaths []string          `json:"import_paths,omitempty" yaml:"import_paths,omitempty"`   // Directories searched for imports of the proto files
+}
+
+// MockResponse is the stub response `comapi mock` serves for a test's method and path.
+// When omitted, the mock derives a response from the test's assertions.
+type MockResponse struct {
+	Status      int               `json:"status,omitempty" yaml:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body        string            `json:"body,omitempty" yaml:"body,omitempty"`
+	DelayMs     int               `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`         // Wait before responding
+	ErrorRate   float64           `json:"error_rate,omitempty" yaml:"error_rate,omitempty"`     // Fraction of requests (0-1) answered with error_status
+	ErrorStatus int               `json:"error_status,omitempty" yaml:"error_status,omitempty"` // Defaults to 500
+}
+
+// Position locates a definition in a suite file
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// String formats the position as file:line:column
+func (p Position) String() string {
+	switch {
+	case p.File == "":
+		return ""
+	case p.Line == 0:
+		return p.File
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+}
+
+// TestData describes the parameter rows of a data-driven test.
+// Row values replace {{name}} placeholders in the path, URL, body, headers and assertions.
+type TestData struct {
+	Rows   []map[string]interface{} `json:"rows,omitempty" yaml:"rows,omitempty"`     // Inline parameter rows
+	File   string                   `json:"file,omitempty" yaml:"file,omitempty"`     // CSV (with header row) or JSON array of objects
+	Matrix map[string][]interface{} `json:"matrix,omitempty" yaml:"matrix,omitempty"` // Every combination of the listed values
 }
 
 // Assertion represents a test assertion
 type Assertion struct {
-	Type     string      `json:"type" yaml:"type"`         // "status", "header", "json_path", "response_time"
+	Type     string      `json:"type" yaml:"type"`         // "status", "header", "json_path", "response_time", "expr", "graphql_errors"
 	Target   string      `json:"target,omitempty" yaml:"target,omitempty"`   // JSON path, header name, etc.
 	Expected interface{} `json:"expected" yaml:"expected"` // Expected value
 	Operator string      `json:"operator,omitempty" yaml:"operator,omitempty"` // "equals", "contains", "less_than", etc.
+	Expr     string      `json:"expr,omitempty" yaml:"expr,omitempty"`         // Boolean expression for "expr" assertions
+	Message  string      `json:"message,omitempty" yaml:"message,omitempty"`   // Custom failure message
+	Source   Position    `json:"-" yaml:"-"`                                    // Where the assertion was defined
 }
 
 // TestResult represents the result of a single test
@@ -42,6 +151,67 @@ type TestResult struct {
 	Response     ResponseInfo        `json:"response"`
 	Assertions   []AssertionResult   `json:"assertions"`
 	Error        string              `json:"error,omitempty"`
+	SkipReason   string              `json:"skip_reason,omitempty"`
+	Timings      *Timings            `json:"timings,omitempty"` // Set once the request was sent
+}
+
+// Timings breaks one request down into phases, in milliseconds. DNS, Connect
+// and TLS are zero when a kept-alive connection was reused. When redirects are
+// followed the phases describe the last request, while Total covers them all.
+type Timings struct {
+	DNS      float64 `json:"dns_ms"`      // Resolving the host name
+	Connect  float64 `json:"connect_ms"`  // Opening the TCP connection
+	TLS      float64 `json:"tls_ms"`      // TLS handshake
+	Wait     float64 `json:"wait_ms"`     // From the request being written to the first response byte: server time
+	TTFB     float64 `json:"ttfb_ms"`     // From sending the request to the first response byte
+	Transfer float64 `json:"transfer_ms"` // Reading the response body
+	Total    float64 `json:"total_ms"`    // From sending the request to the end of the body
+	Reused   bool    `json:"reused_connection,omitempty"`
+	// StartedAt is when the request was sent
+	StartedAt time.Time `json:"started_at"`
+}
+
+// TimingPhases lists the phases response_time assertions can target
+var TimingPhases = []string{"dns", "connect", "tls", "wait", "ttfb", "transfer", "total"}
+
+// Phase returns the duration of a phase named in TimingPhases
+func (t Timings) Phase(name string) (float64, bool) {
+	switch name {
+	case "dns":
+		return t.DNS, true
+	case "connect":
+		return t.Connect, true
+	case "tls":
+		return t.TLS, true
+	case "wait":
+		return t.Wait, true
+	case "ttfb":
+		return t.TTFB, true
+	case "transfer":
+		return t.Transfer, true
+	case "total":
+		return t.Total, true
+	default:
+		return 0, false
+	}
+}
+
+// FailureMessage summarises why a test failed: the request error, or else the
+// first failed assertion
+func (r TestResult) FailureMessage() string {
+	if r.Error != "" {
+		return r.Error
+	}
+	for _, assertion := range r.Assertions {
+		if assertion.Passed {
+			continue
+		}
+		if assertion.Message != "" {
+			return assertion.Message
+		}
+		return fmt.Sprintf("%s: expected %v, got %v", assertion.Type, assertion.Expected, assertion.Actual)
+	}
+	return "failed"
 }
 
 // TestStatus represents the status of a test
@@ -67,6 +237,7 @@ type ResponseInfo struct {
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	Size       int64             `json:"size"`
+	Proto      string            `json:"proto,omitempty"` // e.g. HTTP/1.1
 }
 
 // AssertionResult represents the result of a single assertion